@@ -1,18 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+	_ "time/tzdata"
 	"bank-api/internal/handler"
 	"bank-api/internal/middleware"
+	"bank-api/internal/models"
 	"bank-api/internal/repository"
+	"bank-api/internal/scheduler"
 	"bank-api/internal/service"
 	"bank-api/pkg/centralbank"
+	"bank-api/pkg/daycount"
+	"bank-api/pkg/email"
+	"bank-api/pkg/pinblock"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -33,6 +41,53 @@ func main() {
 		log.Fatal("JWT_SECRET is required in .env file")
 	}
 
+	pinEncryptor, err := pinblock.NewEncryptor(os.Getenv("PIN_KEY"))
+	if err != nil {
+		log.Fatal("PIN_KEY is invalid: ", err)
+	}
+
+	// Макропруденциальные пороги ПДН
+	pdnLimits := service.DefaultPDNLimits()
+	pdnLimits.FlagThreshold = getEnvFloat("PDN_FLAG_THRESHOLD", pdnLimits.FlagThreshold)
+	pdnLimits.BlockThreshold = getEnvFloat("PDN_BLOCK_THRESHOLD", pdnLimits.BlockThreshold)
+
+	// Комиссии и страхование, входящие в ПСК
+	creditFees := service.DefaultCreditFees()
+	creditFees.IssueFeePercent = getEnvFloat("CREDIT_ISSUE_FEE_PERCENT", creditFees.IssueFeePercent)
+	creditFees.InsuranceRatePercent = getEnvFloat("CREDIT_INSURANCE_RATE_PERCENT", creditFees.InsuranceRatePercent)
+
+	// Условия кредитных линий
+	creditLineTerms := service.DefaultCreditLineTerms()
+	creditLineTerms.GraceDays = getEnvInt("CREDIT_LINE_GRACE_DAYS", creditLineTerms.GraceDays)
+	creditLineTerms.MinPaymentPercent = getEnvFloat("CREDIT_LINE_MIN_PAYMENT_PERCENT", creditLineTerms.MinPaymentPercent)
+	creditLineTerms.PaymentDueDays = getEnvInt("CREDIT_LINE_PAYMENT_DUE_DAYS", creditLineTerms.PaymentDueDays)
+
+	// Условия вкладов: спреды к ключевой ставке и ставка при досрочном закрытии
+	depositTerms := service.DefaultDepositTerms()
+	depositTerms.SavingsSpread = getEnvFloat("DEPOSIT_SAVINGS_SPREAD", depositTerms.SavingsSpread)
+	depositTerms.TermSpread = getEnvFloat("DEPOSIT_TERM_SPREAD", depositTerms.TermSpread)
+	depositTerms.EarlyWithdrawalRate = getEnvFloat("DEPOSIT_EARLY_WITHDRAWAL_RATE", depositTerms.EarlyWithdrawalRate)
+
+	// Повторы исполнения постоянных поручений при недостатке средств
+	standingOrderTerms := service.DefaultStandingOrderTerms()
+	standingOrderTerms.MaxAttempts = getEnvInt("STANDING_ORDER_MAX_ATTEMPTS", standingOrderTerms.MaxAttempts)
+	standingOrderTerms.RetryInterval = time.Duration(getEnvInt("STANDING_ORDER_RETRY_HOURS", int(standingOrderTerms.RetryInterval/time.Hour))) * time.Hour
+
+	// Лимиты внесения и снятия средств через кассу и другие банки
+	cashLimits := service.DefaultCashLimits()
+	cashLimits.MaxDeposit = getEnvFloat("CASH_MAX_DEPOSIT", cashLimits.MaxDeposit)
+	cashLimits.MaxWithdrawal = getEnvFloat("CASH_MAX_WITHDRAWAL", cashLimits.MaxWithdrawal)
+	cashLimits.DailyWithdrawal = getEnvFloat("CASH_DAILY_WITHDRAWAL_LIMIT", cashLimits.DailyWithdrawal)
+
+	// Конвенция расчета дней для начисления процентов по новым кредитам
+	dayCount := daycount.Actual365
+	if value := os.Getenv("INTEREST_DAY_COUNT"); value != "" {
+		dayCount, err = daycount.Parse(value)
+		if err != nil {
+			log.Fatal("INTEREST_DAY_COUNT is invalid: ", err)
+		}
+	}
+
 	// Подключение к базе данных
 	dbConnStr := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
@@ -56,19 +111,52 @@ func main() {
 
 	// Инициализация клиентов
 	centralBankClient := centralbank.NewClient()
+	emailClient := email.NewClient(email.Config{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     getEnvInt("SMTP_PORT", 587),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
 
 	// Инициализация репозиториев
 	userRepo := repository.NewUserRepository(db)
 	accountRepo := repository.NewAccountRepository(db)
 	cardRepo := repository.NewCardRepository(db)
 	creditRepo := repository.NewCreditRepository(db)
+	calendarRepo := repository.NewCalendarRepository(db)
+	creditLineRepo := repository.NewCreditLineRepository(db)
+	depositRepo := repository.NewDepositRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	budgetRepo := repository.NewBudgetRepository(db)
+	standingOrderRepo := repository.NewStandingOrderRepository(db)
 
 	// Инициализация сервисов
 	userService := service.NewUserService(userRepo)
-	accountService := service.NewAccountService(accountRepo)
-	cardService := service.NewCardService(cardRepo)
-	creditService := service.NewCreditService(creditRepo, centralBankClient, accountService)
-	analyticsService := service.NewAnalyticsService(accountRepo, creditRepo)
+	categoryService := service.NewCategoryService(categoryRepo, accountRepo)
+	accountService := service.NewAccountService(accountRepo, userRepo, categoryService, cashLimits)
+	cardService := service.NewCardService(cardRepo, accountService, pinEncryptor)
+	budgetService := service.NewBudgetService(budgetRepo, analyticsRepo, userRepo, categoryService, emailClient)
+	standingOrderService := service.NewStandingOrderService(standingOrderRepo, accountService, userRepo, standingOrderTerms)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, userRepo, accountRepo, creditRepo, creditLineRepo, categoryService, budgetService, standingOrderService)
+	creditService := service.NewCreditService(creditRepo, centralBankClient, accountService, analyticsService, service.NewRuleBasedScorer(), pdnLimits, creditFees, calendarRepo, userRepo, dayCount)
+	creditLineService := service.NewCreditLineService(creditLineRepo, centralBankClient, accountService, analyticsService, service.NewRuleBasedScorer(), creditLineTerms)
+	depositService := service.NewDepositService(depositRepo, centralBankClient, accountService, depositTerms)
+
+	// Запуск фоновых задач
+	jobs := scheduler.NewScheduler()
+	jobs.Add("expire-cards", 24*time.Hour, cardService.ExpireCards)
+	jobs.Add("close-single-use-cards", 10*time.Minute, cardService.CloseExpiredSingleUse)
+	jobs.Add("accrue-interest", time.Hour, creditService.AccrueInterest)
+	jobs.Add("process-credit-lines", time.Hour, creditLineService.ProcessCreditLines)
+	jobs.Add("process-deposits", time.Hour, depositService.ProcessDeposits)
+	jobs.Add("check-budgets", 15*time.Minute, budgetService.CheckBudgets)
+	jobs.Add("process-standing-orders", 5*time.Minute, standingOrderService.ProcessStandingOrders)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs.Start(ctx)
 
 	// Инициализация middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtSecret)
@@ -78,7 +166,12 @@ func main() {
 	accountHandler := handler.NewAccountHandler(accountService)
 	cardHandler := handler.NewCardHandler(cardService)
 	creditHandler := handler.NewCreditHandler(creditService)
+	creditLineHandler := handler.NewCreditLineHandler(creditLineService)
+	depositHandler := handler.NewDepositHandler(depositService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
 	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	budgetHandler := handler.NewBudgetHandler(budgetService)
+	standingOrderHandler := handler.NewStandingOrderHandler(standingOrderService)
 
 	// Настройка маршрутизации
 	r := mux.NewRouter()
@@ -93,11 +186,14 @@ func main() {
 
 	// Маршруты пользователей
 	authRouter.HandleFunc("/profile", userHandler.GetProfile).Methods("GET")
+	authRouter.HandleFunc("/profile", userHandler.UpdateProfile).Methods("PUT")
 
 	// Маршруты счетов
 	authRouter.HandleFunc("/accounts", accountHandler.Create).Methods("POST")
 	authRouter.HandleFunc("/accounts", accountHandler.GetByUserID).Methods("GET")
 	authRouter.HandleFunc("/accounts/{id}", accountHandler.GetByID).Methods("GET")
+	authRouter.HandleFunc("/accounts/{id}/statement", accountHandler.GetStatement).Methods("GET")
+	authRouter.HandleFunc("/accounts/{id}/withdraw", accountHandler.Withdraw).Methods("POST")
 	authRouter.HandleFunc("/transfer", accountHandler.Transfer).Methods("POST")
 	authRouter.HandleFunc("/transactions", accountHandler.GetTransactions).Methods("GET")
 
@@ -106,16 +202,94 @@ func main() {
 	authRouter.HandleFunc("/cards/{id}", cardHandler.GetByID).Methods("GET")
 	authRouter.HandleFunc("/cards", cardHandler.GetByAccountID).Methods("GET")
 	authRouter.HandleFunc("/cards/{id}/deactivate", cardHandler.Deactivate).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/block", cardHandler.Block).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/unblock", cardHandler.Unblock).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/reissue", cardHandler.Reissue).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/pin", cardHandler.SetPIN).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/pin", cardHandler.ChangePIN).Methods("PUT")
+	authRouter.HandleFunc("/cards/{id}/limits", cardHandler.GetLimits).Methods("GET")
+	authRouter.HandleFunc("/cards/{id}/limits", cardHandler.UpdateLimits).Methods("PUT")
 
 	// Маршруты кредитов
-	authRouter.HandleFunc("/credits", creditHandler.Create).Methods("POST")
+	authRouter.HandleFunc("/credit-applications", creditHandler.SubmitApplication).Methods("POST")
+	authRouter.HandleFunc("/credit-applications", creditHandler.GetApplications).Methods("GET")
+	authRouter.HandleFunc("/credit-applications/{id}", creditHandler.GetApplication).Methods("GET")
+	authRouter.HandleFunc("/credit-applications/{id}/disburse", creditHandler.DisburseApplication).Methods("POST")
+	authRouter.HandleFunc("/credits/quote", creditHandler.Quote).Methods("POST")
 	authRouter.HandleFunc("/credits/{id}", creditHandler.GetByID).Methods("GET")
 	authRouter.HandleFunc("/credits", creditHandler.GetByUserID).Methods("GET")
 	authRouter.HandleFunc("/credits/{id}/schedule", creditHandler.GetPaymentSchedule).Methods("GET")
 	authRouter.HandleFunc("/credits/{id}/payments/{payment_id}", creditHandler.ProcessPayment).Methods("POST")
+	authRouter.HandleFunc("/credits/{id}/prepay", creditHandler.Prepay).Methods("POST")
+	authRouter.HandleFunc("/credits/{id}/interest", creditHandler.GetAccruedInterest).Methods("GET")
+	authRouter.HandleFunc("/credits/{id}/restructuring", creditHandler.RequestRestructuring).Methods("POST")
+	authRouter.HandleFunc("/credits/{id}/restructuring", creditHandler.GetRestructurings).Methods("GET")
+	authRouter.HandleFunc("/credits/{id}/documents", creditHandler.GetDocuments).Methods("GET")
+	authRouter.HandleFunc("/credits/{id}/documents/{document_id}", creditHandler.GetDocument).Methods("GET")
+
+	// Маршруты кредитных линий
+	authRouter.HandleFunc("/credit-lines", creditLineHandler.Open).Methods("POST")
+	authRouter.HandleFunc("/credit-lines", creditLineHandler.GetByUserID).Methods("GET")
+	authRouter.HandleFunc("/credit-lines/{id}", creditLineHandler.GetByID).Methods("GET")
+	authRouter.HandleFunc("/credit-lines/{id}/statements", creditLineHandler.GetStatements).Methods("GET")
+	authRouter.HandleFunc("/credit-lines/{id}/close", creditLineHandler.Close).Methods("POST")
+
+	// Маршруты вкладов
+	authRouter.HandleFunc("/deposits/rates", depositHandler.GetRates).Methods("GET")
+	authRouter.HandleFunc("/deposits", depositHandler.Open).Methods("POST")
+	authRouter.HandleFunc("/deposits", depositHandler.GetByUserID).Methods("GET")
+	authRouter.HandleFunc("/deposits/{id}", depositHandler.GetByID).Methods("GET")
+	authRouter.HandleFunc("/deposits/{id}/top-up", depositHandler.TopUp).Methods("POST")
+	authRouter.HandleFunc("/deposits/{id}/withdraw", depositHandler.Withdraw).Methods("POST")
+	authRouter.HandleFunc("/deposits/{id}/close", depositHandler.Close).Methods("POST")
+
+	// Маршруты категорий операций
+	authRouter.HandleFunc("/categories", categoryHandler.GetCategories).Methods("GET")
+	authRouter.HandleFunc("/category-rules", categoryHandler.CreateRule).Methods("POST")
+	authRouter.HandleFunc("/category-rules", categoryHandler.GetRules).Methods("GET")
+	authRouter.HandleFunc("/category-rules/{id}", categoryHandler.DeleteRule).Methods("DELETE")
+	authRouter.HandleFunc("/transactions/{id}/category", categoryHandler.Recategorize).Methods("PUT")
+	authRouter.HandleFunc("/transactions/{id}/refund", accountHandler.Refund).Methods("POST")
+
+	// Маршруты постоянных поручений
+	authRouter.HandleFunc("/standing-orders", standingOrderHandler.Create).Methods("POST")
+	authRouter.HandleFunc("/standing-orders", standingOrderHandler.GetByUserID).Methods("GET")
+	authRouter.HandleFunc("/standing-orders/{id}", standingOrderHandler.GetByID).Methods("GET")
+	authRouter.HandleFunc("/standing-orders/{id}/pause", standingOrderHandler.Pause).Methods("POST")
+	authRouter.HandleFunc("/standing-orders/{id}/resume", standingOrderHandler.Resume).Methods("POST")
+	authRouter.HandleFunc("/standing-orders/{id}/cancel", standingOrderHandler.Cancel).Methods("POST")
+	authRouter.HandleFunc("/standing-orders/{id}/executions", standingOrderHandler.GetExecutions).Methods("GET")
+
+	// Маршруты бюджетов
+	authRouter.HandleFunc("/budgets", budgetHandler.Create).Methods("POST")
+	authRouter.HandleFunc("/budgets", budgetHandler.GetBudgets).Methods("GET")
+	authRouter.HandleFunc("/budgets/{id}", budgetHandler.GetByID).Methods("GET")
+	authRouter.HandleFunc("/budgets/{id}", budgetHandler.Update).Methods("PUT")
+	authRouter.HandleFunc("/budgets/{id}", budgetHandler.Delete).Methods("DELETE")
 
 	// Маршруты аналитики
 	authRouter.HandleFunc("/analytics", analyticsHandler.GetAnalytics).Methods("GET")
+	authRouter.HandleFunc("/analytics/history", analyticsHandler.GetHistory).Methods("GET")
+
+	// Маршруты операторов банка
+	operatorRouter := authRouter.PathPrefix("/operator").Subrouter()
+	operatorRouter.Use(authMiddleware.RequireRole(models.RoleOperator))
+	operatorRouter.HandleFunc("/restructurings", creditHandler.GetPendingRestructurings).Methods("GET")
+	operatorRouter.HandleFunc("/restructurings/{id}/approve", creditHandler.ApproveRestructuring).Methods("POST")
+	operatorRouter.HandleFunc("/restructurings/{id}/reject", creditHandler.RejectRestructuring).Methods("POST")
+	operatorRouter.HandleFunc("/category-rules", categoryHandler.CreateCounterpartyRule).Methods("POST")
+	operatorRouter.HandleFunc("/category-rules", categoryHandler.GetCounterpartyRules).Methods("GET")
+	operatorRouter.HandleFunc("/category-rules/{id}", categoryHandler.DeleteCounterpartyRule).Methods("DELETE")
+	operatorRouter.HandleFunc("/cash/deposit", accountHandler.CashDeposit).Methods("POST")
+	operatorRouter.HandleFunc("/cash/withdrawal", accountHandler.CashWithdrawal).Methods("POST")
+	operatorRouter.HandleFunc("/top-ups", accountHandler.TopUp).Methods("POST")
+	operatorRouter.HandleFunc("/transactions/{id}/reverse", accountHandler.Reverse).Methods("POST")
+	operatorRouter.HandleFunc("/cards/{id}/unblock", cardHandler.OperatorUnblock).Methods("POST")
+
+	// Маршруты эквайеров: авторизация операций по картам
+	acquirerRouter := authRouter.PathPrefix("/acquiring").Subrouter()
+	acquirerRouter.Use(authMiddleware.RequireRole(models.RoleAcquirer))
+	acquirerRouter.HandleFunc("/authorize", cardHandler.Authorize).Methods("POST")
 
 	// Запуск сервера
 	port := os.Getenv("PORT")
@@ -125,4 +299,34 @@ func main() {
 
 	log.Printf("Server starting on port %s", port)
 	log.Fatal(http.ListenAndServe(":"+port, r))
-} 
\ No newline at end of file
+}
+
+// getEnvFloat возвращает числовое значение переменной окружения или значение по умолчанию
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+
+	return parsed
+}
+
+// getEnvInt возвращает целое значение переменной окружения или значение по умолчанию
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be an integer: %v", key, err)
+	}
+
+	return parsed
+}
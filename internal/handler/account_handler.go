@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"bank-api/internal/models"
 	"bank-api/internal/service"
 	"strconv"
+	"time"
 	"github.com/gorilla/mux"
 )
 
@@ -96,7 +99,7 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.accountService.Transfer(r.Context(), input.FromAccountID, input.ToAccountID, input.Amount); err != nil {
+	if _, err := h.accountService.Transfer(r.Context(), input.FromAccountID, input.ToAccountID, input.Amount); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -104,6 +107,117 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// TopUp зачисляет на счет клиента подтвержденный платеж из другого банка (для операторов)
+func (h *AccountHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+	operatorIDStr := r.Context().Value("userID").(string)
+	operatorID, err := strconv.ParseInt(operatorIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.ExternalTopUp
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.accountService.TopUp(r.Context(), operatorID, input)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// Withdraw выводит средства со счета пользователя в другой банк
+func (h *AccountHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CashOperation
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.accountService.Withdraw(r.Context(), userID, id, input.Amount)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// CashDeposit проводит внесение наличных на счет клиента в кассе (для операторов)
+func (h *AccountHandler) CashDeposit(w http.ResponseWriter, r *http.Request) {
+	operatorIDStr := r.Context().Value("userID").(string)
+	operatorID, err := strconv.ParseInt(operatorIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CashOperation
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.accountService.CashDeposit(r.Context(), operatorID, input)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// CashWithdrawal проводит выдачу наличных со счета клиента в кассе (для операторов)
+func (h *AccountHandler) CashWithdrawal(w http.ResponseWriter, r *http.Request) {
+	operatorIDStr := r.Context().Value("userID").(string)
+	operatorID, err := strconv.ParseInt(operatorIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CashOperation
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.accountService.CashWithdrawal(r.Context(), operatorID, input)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
 func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	// Получаем ID пользователя из контекста
 	userIDStr := r.Context().Value("userID").(string)
@@ -113,32 +227,229 @@ func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Получаем все счета пользователя
-	accounts, err := h.accountService.GetByUserID(r.Context(), userID)
+	query := r.URL.Query()
+	filter := models.TransactionFilter{
+		Type:         query.Get("type"),
+		Status:       query.Get("status"),
+		Category:     query.Get("category"),
+		Counterparty: query.Get("counterparty"),
+		Sort:         query.Get("sort"),
+		Order:        query.Get("order"),
+	}
+
+	if accountIDStr := query.Get("account_id"); accountIDStr != "" {
+		filter.AccountID, err = strconv.ParseInt(accountIDStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid account ID", http.StatusBadRequest)
+			return
+		}
+	}
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, _, err := parseTransactionTime(fromStr)
+		if err != nil {
+			http.Error(w, "Invalid from, expected RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		filter.From = &from
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, dateOnly, err := parseTransactionTime(toStr)
+		if err != nil {
+			http.Error(w, "Invalid to, expected RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		// Дата без времени включает весь день
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+	if minAmountStr := query.Get("min_amount"); minAmountStr != "" {
+		minAmount, err := strconv.ParseFloat(minAmountStr, 64)
+		if err != nil {
+			http.Error(w, "Invalid min_amount", http.StatusBadRequest)
+			return
+		}
+		filter.MinAmount = &minAmount
+	}
+	if maxAmountStr := query.Get("max_amount"); maxAmountStr != "" {
+		maxAmount, err := strconv.ParseFloat(maxAmountStr, 64)
+		if err != nil {
+			http.Error(w, "Invalid max_amount", http.StatusBadRequest)
+			return
+		}
+		filter.MaxAmount = &maxAmount
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || filter.Limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.accountService.FindTransactions(r.Context(), userID, filter, query.Get("cursor"))
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// Refund возвращает отправителю всю сумму операции или ее часть (инициирует получатель)
+func (h *AccountHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.TransactionRefund
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.accountService.Refund(r.Context(), userID, id, input)
+	if err != nil {
+		writeAccountError(w, err)
 		return
 	}
 
-	// Собираем транзакции со всех счетов
-	transactionMap := make(map[int64]*models.Transaction)
-	for _, account := range accounts {
-		transactions, err := h.accountService.GetTransactions(r.Context(), account.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// Reverse полностью отменяет операцию с указанием причины (для операторов)
+func (h *AccountHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	operatorIDStr := r.Context().Value("userID").(string)
+	operatorID, err := strconv.ParseInt(operatorIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.TransactionReversal
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.accountService.Reverse(r.Context(), operatorID, id, input)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *AccountHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	// По умолчанию - выписка в CSV с начала текущего месяца по сегодняшний день
+	query := r.URL.Query()
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, 1-to.Day())
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, "Invalid from, expected YYYY-MM-DD", http.StatusBadRequest)
 			return
 		}
-		for _, transaction := range transactions {
-			transactionMap[transaction.ID] = transaction
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			http.Error(w, "Invalid to, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
 		}
 	}
 
-	// Преобразуем map обратно в слайс
-	allTransactions := make([]*models.Transaction, 0, len(transactionMap))
-	for _, transaction := range transactionMap {
-		allTransactions = append(allTransactions, transaction)
+	format := query.Get("format")
+	if format == "" {
+		format = models.StatementFormatCSV
+	}
+	contentType, extension, err := service.StatementContentType(format)
+	if err != nil {
+		writeAccountError(w, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(allTransactions)
+	statement, err := h.accountService.GetStatement(r.Context(), userID, id, from, to)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("statement_%s_%s_%s.%s", statement.Account.Number, from.Format("20060102"), to.Format("20060102"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Строки выписки передаются клиенту по мере чтения, поэтому после начала ответа
+	// ошибку уже нельзя вернуть статусом - ответ обрывается
+	if err := h.accountService.WriteStatement(r.Context(), statement, format, w); err != nil {
+		log.Printf("Statement for account %d failed: %v", id, err)
+	}
+}
+
+// parseTransactionTime разбирает момент времени в формате RFC 3339 или дату YYYY-MM-DD
+func parseTransactionTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
+
+// writeAccountError преобразует ошибки сервиса счетов в HTTP-ответы
+func writeAccountError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrAccountNotFound, service.ErrTransactionNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidTransactionFilter, service.ErrInvalidCursor, service.ErrInvalidStatementPeriod,
+		service.ErrInvalidStatementFormat, service.ErrInvalidAmount, service.ErrReasonRequired,
+		service.ErrExternalReferenceRequired:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrInsufficientFunds, service.ErrTransactionNotReversible, service.ErrTransactionReversed,
+		service.ErrExternalPaymentCredited:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case service.ErrOperationLimitExceeded, service.ErrDailyLimitExceeded, service.ErrRefundExceedsAmount:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 } 
\ No newline at end of file
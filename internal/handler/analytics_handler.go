@@ -45,4 +45,39 @@ func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(analytics)
-} 
\ No newline at end of file
+}
+
+func (h *AnalyticsHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	months := 0 // значение по умолчанию задается сервисом
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		months, err = strconv.Atoi(monthsStr)
+		if err != nil || months <= 0 {
+			http.Error(w, "Invalid months", http.StatusBadRequest)
+			return
+		}
+	}
+
+	history, err := h.analyticsService.GetHistory(r.Context(), userID, months, r.URL.Query().Get("granularity"))
+	switch err {
+	case nil:
+	case service.ErrInvalidHistoryParams:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case service.ErrUserNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"bank-api/internal/models"
 	"bank-api/internal/service"
+	"bank-api/pkg/pinblock"
 	"strconv"
 	"github.com/gorilla/mux"
 )
@@ -20,34 +22,40 @@ func NewCardHandler(cardService *service.CardService) *CardHandler {
 }
 
 func (h *CardHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	var input models.CardCreate
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	card, err := h.cardService.Create(r.Context(), input)
+	card, err := h.cardService.Create(r.Context(), userID, input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeCardError(w, err)
 		return
 	}
 
 	// Преобразуем в CardResponse для безопасности
-	response := models.CardResponse{
-		ID:            card.ID,
-		AccountID:     card.AccountID,
-		Number:        card.Number,
-		ExpiryDate:    card.ExpiryDate,
-		CardholderName: card.CardholderName,
-		IsActive:      card.IsActive,
-		CreatedAt:     card.CreatedAt,
-	}
+	response := toCardResponse(card)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *CardHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -56,28 +64,27 @@ func (h *CardHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	card, err := h.cardService.GetByID(r.Context(), id)
+	card, err := h.cardService.GetByID(r.Context(), userID, id)
 	if err != nil {
-		http.Error(w, "Card not found", http.StatusNotFound)
+		writeCardError(w, err)
 		return
 	}
 
 	// Преобразуем в CardResponse для безопасности
-	response := models.CardResponse{
-		ID:            card.ID,
-		AccountID:     card.AccountID,
-		Number:        card.Number,
-		ExpiryDate:    card.ExpiryDate,
-		CardholderName: card.CardholderName,
-		IsActive:      card.IsActive,
-		CreatedAt:     card.CreatedAt,
-	}
+	response := toCardResponse(card)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *CardHandler) GetByAccountID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	accountIDStr := r.URL.Query().Get("account_id")
 	accountID, err := strconv.ParseInt(accountIDStr, 10, 64)
 	if err != nil {
@@ -85,24 +92,16 @@ func (h *CardHandler) GetByAccountID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cards, err := h.cardService.GetByAccountID(r.Context(), accountID)
+	cards, err := h.cardService.GetByAccountID(r.Context(), userID, accountID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeCardError(w, err)
 		return
 	}
 
 	// Преобразуем в CardResponse для безопасности
 	responses := make([]models.CardResponse, len(cards))
 	for i, card := range cards {
-		responses[i] = models.CardResponse{
-			ID:            card.ID,
-			AccountID:     card.AccountID,
-			Number:        card.Number,
-			ExpiryDate:    card.ExpiryDate,
-			CardholderName: card.CardholderName,
-			IsActive:      card.IsActive,
-			CreatedAt:     card.CreatedAt,
-		}
+		responses[i] = toCardResponse(card)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -110,6 +109,13 @@ func (h *CardHandler) GetByAccountID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CardHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -118,10 +124,307 @@ func (h *CardHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.cardService.Deactivate(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.cardService.Deactivate(r.Context(), userID, id); err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *CardHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.cardService.GetLimits(r.Context(), userID, id)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+func (h *CardHandler) UpdateLimits(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CardLimitsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.cardService.UpdateLimits(r.Context(), userID, id, input)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+// Authorize проводит авторизацию операции по карте (для эквайеров)
+func (h *CardHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	acquirerIDStr := r.Context().Value("userID").(string)
+	acquirerID, err := strconv.ParseInt(acquirerIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CardAuthorization
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.cardService.Authorize(r.Context(), acquirerID, input)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *CardHandler) Block(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CardStatusChange
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardService.Block(r.Context(), userID, id, input.Reason)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCardResponse(card))
+}
+
+func (h *CardHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardService.Unblock(r.Context(), userID, id)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCardResponse(card))
+}
+
+// OperatorUnblock снимает любую временную блокировку карты (для операторов)
+func (h *CardHandler) OperatorUnblock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardService.OperatorUnblock(r.Context(), id)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCardResponse(card))
+}
+
+func (h *CardHandler) Reissue(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CardStatusChange
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardService.Reissue(r.Context(), userID, id, input.Reason)
+	if err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCardResponse(card))
+}
+
+func (h *CardHandler) SetPIN(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CardPINSet
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cardService.SetPIN(r.Context(), userID, id, input.PIN); err != nil {
+		writeCardError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-} 
\ No newline at end of file
+}
+
+func (h *CardHandler) ChangePIN(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CardPINSet
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cardService.ChangePIN(r.Context(), userID, id, input.OldPIN, input.PIN); err != nil {
+		writeCardError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Вспомогательные функции
+
+func toCardResponse(card *models.Card) models.CardResponse {
+	return models.CardResponse{
+		ID:             card.ID,
+		AccountID:      card.AccountID,
+		Number:         card.Number,
+		ExpiryDate:     card.ExpiryDate,
+		CardholderName: card.CardholderName,
+		IsActive:       card.IsActive,
+		Status:         card.Status,
+		StatusReason:   card.StatusReason,
+		PreviousCardID: card.PreviousCardID,
+		ReissueReason:  card.ReissueReason,
+		AutoReissue:    card.AutoReissue,
+		Type:           card.Type,
+		MerchantAccountID: card.MerchantAccountID,
+		ValidUntil:     card.ValidUntil,
+		PaymentSystem:  card.PaymentSystem,
+		ProductCode:    card.ProductCode,
+		ProductType:    card.ProductType,
+		PINSet:         card.EncryptedPINBlock != "",
+		CreatedAt:      card.CreatedAt,
+	}
+}
+
+func writeCardError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		http.Error(w, "Card not found", http.StatusNotFound)
+	case service.ErrCardNotFound, service.ErrMerchantAccountNotFound, service.ErrAccountNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidReason, service.ErrUnknownProduct, pinblock.ErrInvalidPIN:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrIncorrectPIN, service.ErrPINTriesExceeded, service.ErrUnblockNotAllowed:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case service.ErrInvalidCardStatus, service.ErrPINAlreadySet, service.ErrPINNotSet:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
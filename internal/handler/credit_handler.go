@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"bank-api/internal/models"
 	"bank-api/internal/service"
 	"strconv"
+	"time"
 	"github.com/gorilla/mux"
 )
 
@@ -19,7 +22,7 @@ func NewCreditHandler(creditService *service.CreditService) *CreditHandler {
 	}
 }
 
-func (h *CreditHandler) Create(w http.ResponseWriter, r *http.Request) {
+func (h *CreditHandler) SubmitApplication(w http.ResponseWriter, r *http.Request) {
 	// Получаем ID пользователя из контекста
 	userIDStr := r.Context().Value("userID").(string)
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -37,16 +40,103 @@ func (h *CreditHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Устанавливаем ID пользователя из контекста
 	input.UserID = userID
 
-	credit, err := h.creditService.Create(r.Context(), input)
+	application, err := h.creditService.SubmitApplication(r.Context(), input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(application)
+}
+
+func (h *CreditHandler) GetApplications(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	applications, err := h.creditService.GetApplications(r.Context(), userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(applications)
+}
+
+func (h *CreditHandler) GetApplication(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid application ID", http.StatusBadRequest)
+		return
+	}
+
+	application, err := h.creditService.GetApplication(r.Context(), userID, id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(application)
+}
+
+func (h *CreditHandler) DisburseApplication(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid application ID", http.StatusBadRequest)
+		return
+	}
+
+	credit, err := h.creditService.DisburseApplication(r.Context(), userID, id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(credit)
 }
 
+func (h *CreditHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	var input models.CreditQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.creditService.Quote(r.Context(), input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
 func (h *CreditHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -85,6 +175,13 @@ func (h *CreditHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CreditHandler) GetPaymentSchedule(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	vars := mux.Vars(r)
 	creditIDStr := vars["id"]
 	creditID, err := strconv.ParseInt(creditIDStr, 10, 64)
@@ -93,9 +190,22 @@ func (h *CreditHandler) GetPaymentSchedule(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	schedule, err := h.creditService.GetPaymentSchedule(r.Context(), creditID)
+	// История версий графика (досрочные погашения, реструктуризации)
+	if r.URL.Query().Get("history") == "true" {
+		versions, err := h.creditService.GetScheduleHistory(r.Context(), userID, creditID)
+		if err != nil {
+			writeCreditError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(versions)
+		return
+	}
+
+	schedule, err := h.creditService.GetPaymentSchedule(r.Context(), userID, creditID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeCreditError(w, err)
 		return
 	}
 
@@ -103,7 +213,155 @@ func (h *CreditHandler) GetPaymentSchedule(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(schedule)
 }
 
+func (h *CreditHandler) Prepay(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	creditID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CreditPrepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.creditService.Prepay(r.Context(), userID, creditID, input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *CreditHandler) GetAccruedInterest(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	creditID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	date := time.Now()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		date, err = time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	interest, err := h.creditService.GetAccruedInterest(r.Context(), userID, creditID, date)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(interest)
+}
+
+func (h *CreditHandler) RequestRestructuring(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	creditID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CreditRestructuringRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	restructuring, err := h.creditService.RequestRestructuring(r.Context(), userID, creditID, input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(restructuring)
+}
+
+func (h *CreditHandler) GetRestructurings(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	creditID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	restructurings, err := h.creditService.GetRestructurings(r.Context(), userID, creditID)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restructurings)
+}
+
+func (h *CreditHandler) GetPendingRestructurings(w http.ResponseWriter, r *http.Request) {
+	restructurings, err := h.creditService.GetPendingRestructurings(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restructurings)
+}
+
+func (h *CreditHandler) ApproveRestructuring(w http.ResponseWriter, r *http.Request) {
+	h.decideRestructuring(w, r, h.creditService.ApproveRestructuring)
+}
+
+func (h *CreditHandler) RejectRestructuring(w http.ResponseWriter, r *http.Request) {
+	h.decideRestructuring(w, r, h.creditService.RejectRestructuring)
+}
+
 func (h *CreditHandler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	vars := mux.Vars(r)
 	creditIDStr := vars["id"]
 	paymentIDStr := vars["payment_id"]
@@ -120,10 +378,123 @@ func (h *CreditHandler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.creditService.ProcessPayment(r.Context(), creditID, paymentID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.creditService.ProcessPayment(r.Context(), userID, creditID, paymentID); err != nil {
+		writeCreditError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-} 
\ No newline at end of file
+}
+
+// Вспомогательные функции
+
+// decideRestructuring разбирает запрос оператора и выполняет решение по реструктуризации
+func (h *CreditHandler) decideRestructuring(w http.ResponseWriter, r *http.Request, decide func(context.Context, int64, int64, models.CreditRestructuringDecision) (*models.CreditRestructuring, error)) {
+	operatorIDStr := r.Context().Value("userID").(string)
+	operatorID, err := strconv.ParseInt(operatorIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid restructuring ID", http.StatusBadRequest)
+		return
+	}
+
+	var decision models.CreditRestructuringDecision
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	restructuring, err := decide(r.Context(), operatorID, id, decision)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restructuring)
+}
+
+func (h *CreditHandler) GetDocuments(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	creditID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	documents, err := h.creditService.GetDocuments(r.Context(), userID, creditID)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(documents)
+}
+
+// GetDocument отдает PDF-документ; контрольная сумма передается в заголовке X-Checksum-SHA256
+func (h *CreditHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	creditID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit ID", http.StatusBadRequest)
+		return
+	}
+
+	documentID, err := strconv.ParseInt(vars["document_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+
+	document, err := h.creditService.GetDocument(r.Context(), userID, creditID, documentID)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	w.Header().Set("Content-Length", strconv.Itoa(len(document.Content)))
+	w.Header().Set("X-Checksum-SHA256", document.Checksum)
+	w.Write(document.Content)
+}
+
+func writeCreditError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrApplicationNotFound, service.ErrCreditNotFound, service.ErrRestructuringNotFound,
+		service.ErrDocumentNotFound, service.ErrPaymentNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidCreditParams, service.ErrInvalidRepaymentType, service.ErrInvalidPrepaymentMode,
+		service.ErrInvalidPrepayment, service.ErrTermReductionBullet, service.ErrInvalidAccrualDate,
+		service.ErrInvalidRestructuring:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrApplicationNotApproved, service.ErrCreditNotActive, service.ErrCreditOverdue,
+		service.ErrInsufficientFunds, service.ErrRestructuringNotPending, service.ErrPaymentProcessed:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
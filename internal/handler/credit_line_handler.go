@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"bank-api/internal/models"
+	"bank-api/internal/service"
+	"strconv"
+	"github.com/gorilla/mux"
+)
+
+type CreditLineHandler struct {
+	creditLineService *service.CreditLineService
+}
+
+func NewCreditLineHandler(creditLineService *service.CreditLineService) *CreditLineHandler {
+	return &CreditLineHandler{
+		creditLineService: creditLineService,
+	}
+}
+
+func (h *CreditLineHandler) Open(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CreditLineCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Устанавливаем ID пользователя из контекста
+	input.UserID = userID
+
+	line, err := h.creditLineService.Open(r.Context(), input)
+	if err != nil {
+		writeCreditLineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(line)
+}
+
+func (h *CreditLineHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit line ID", http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.creditLineService.GetByID(r.Context(), userID, id)
+	if err != nil {
+		writeCreditLineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(line)
+}
+
+func (h *CreditLineHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	lines, err := h.creditLineService.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lines)
+}
+
+func (h *CreditLineHandler) GetStatements(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit line ID", http.StatusBadRequest)
+		return
+	}
+
+	statements, err := h.creditLineService.GetStatements(r.Context(), userID, id)
+	if err != nil {
+		writeCreditLineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statements)
+}
+
+func (h *CreditLineHandler) Close(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid credit line ID", http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.creditLineService.Close(r.Context(), userID, id)
+	if err != nil {
+		writeCreditLineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(line)
+}
+
+// writeCreditLineError преобразует ошибки сервиса кредитных линий в HTTP-ответы
+func writeCreditLineError(w http.ResponseWriter, err error) {
+	// Отказ скоринга содержит причины, поэтому сравнивается через errors.Is
+	if errors.Is(err, service.ErrCreditLineRejected) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	switch err {
+	case service.ErrCreditLineNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidCreditLimit:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrCreditLineExists, service.ErrCreditLineHasDebt:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
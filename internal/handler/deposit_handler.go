@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"bank-api/internal/models"
+	"bank-api/internal/service"
+	"strconv"
+	"github.com/gorilla/mux"
+)
+
+type DepositHandler struct {
+	depositService *service.DepositService
+}
+
+func NewDepositHandler(depositService *service.DepositService) *DepositHandler {
+	return &DepositHandler{
+		depositService: depositService,
+	}
+}
+
+func (h *DepositHandler) GetRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.depositService.GetRates(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+func (h *DepositHandler) Open(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.DepositCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Устанавливаем ID пользователя из контекста
+	input.UserID = userID
+
+	deposit, err := h.depositService.Open(r.Context(), input)
+	if err != nil {
+		writeDepositError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(deposit)
+}
+
+func (h *DepositHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid deposit ID", http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := h.depositService.GetByID(r.Context(), userID, id)
+	if err != nil {
+		writeDepositError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deposit)
+}
+
+func (h *DepositHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	deposits, err := h.depositService.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deposits)
+}
+
+func (h *DepositHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+	h.operate(w, r, h.depositService.TopUp)
+}
+
+func (h *DepositHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.operate(w, r, h.depositService.Withdraw)
+}
+
+func (h *DepositHandler) Close(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid deposit ID", http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := h.depositService.Close(r.Context(), userID, id)
+	if err != nil {
+		writeDepositError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deposit)
+}
+
+// operate выполняет пополнение или частичное снятие средств вклада
+func (h *DepositHandler) operate(w http.ResponseWriter, r *http.Request, operation func(context.Context, int64, int64, models.DepositOperation) (*models.Deposit, error)) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid deposit ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.DepositOperation
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := operation(r.Context(), userID, id, input)
+	if err != nil {
+		writeDepositError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deposit)
+}
+
+// writeDepositError преобразует ошибки сервиса вкладов в HTTP-ответы
+func writeDepositError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrDepositNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidDepositType, service.ErrInvalidDepositAmount, service.ErrInvalidDepositTerm:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrDepositNotActive, service.ErrDepositTopUpNotAllowed, service.ErrPartialWithdrawalNotAllowed,
+		service.ErrInsufficientFunds, service.ErrDepositConflict:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"bank-api/internal/models"
+	"bank-api/internal/service"
+	"strconv"
+	"github.com/gorilla/mux"
+)
+
+type StandingOrderHandler struct {
+	standingOrderService *service.StandingOrderService
+}
+
+func NewStandingOrderHandler(standingOrderService *service.StandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		standingOrderService: standingOrderService,
+	}
+}
+
+func (h *StandingOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.StandingOrderCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.standingOrderService.Create(r.Context(), userID, input)
+	if err != nil {
+		writeStandingOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	orders, err := h.standingOrderService.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *StandingOrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid standing order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.standingOrderService.GetByID(r.Context(), userID, id)
+	if err != nil {
+		writeStandingOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid standing order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.standingOrderService.Pause(r.Context(), userID, id)
+	if err != nil {
+		writeStandingOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid standing order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.standingOrderService.Resume(r.Context(), userID, id)
+	if err != nil {
+		writeStandingOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid standing order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.standingOrderService.Cancel(r.Context(), userID, id)
+	if err != nil {
+		writeStandingOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) GetExecutions(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid standing order ID", http.StatusBadRequest)
+		return
+	}
+
+	executions, err := h.standingOrderService.GetExecutions(r.Context(), userID, id)
+	if err != nil {
+		writeStandingOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(executions)
+}
+
+// writeStandingOrderError преобразует ошибки сервиса поручений в HTTP-ответы
+func writeStandingOrderError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrStandingOrderNotFound, service.ErrAccountNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidStandingOrder, service.ErrInvalidSchedule:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrStandingOrderStatus:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -30,12 +30,17 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user, err := h.userService.Register(r.Context(), input)
-	if err != nil {
+	switch err {
+	case nil:
+	case service.ErrInvalidTimezone:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	token, err := h.auth.GenerateToken(fmt.Sprintf("%d", user.ID))
+	token, err := h.auth.GenerateToken(fmt.Sprintf("%d", user.ID), user.Role)
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
@@ -63,7 +68,7 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.auth.GenerateToken(fmt.Sprintf("%d", user.ID))
+	token, err := h.auth.GenerateToken(fmt.Sprintf("%d", user.ID), user.Role)
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
@@ -92,6 +97,38 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userIDStr := r.Context().Value("userID").(string)
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.UserProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userID, input)
+	switch err {
+	case nil:
+	case service.ErrInvalidTimezone:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case service.ErrUserNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 } 
\ No newline at end of file
@@ -4,10 +4,17 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"bank-api/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 	"time"
 )
 
+// Claims - утверждения JWT-токена: идентификатор пользователя в Subject и его роль
+type Claims struct {
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
 type AuthMiddleware struct {
 	jwtSecret []byte
 }
@@ -27,7 +34,7 @@ func (m *AuthMiddleware) Auth(next http.Handler) http.Handler {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		claims := &jwt.RegisteredClaims{}
+		claims := &Claims{}
 
 		token, err := jwt.ParseWithClaims(tokenString, claims,
 			func(token *jwt.Token) (interface{}, error) {
@@ -39,16 +46,44 @@ func (m *AuthMiddleware) Auth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Токены, выпущенные до появления ролей, считаются клиентскими
+		role := claims.Role
+		if role == "" {
+			role = models.RoleCustomer
+		}
+
 		ctx := context.WithValue(r.Context(), "userID", claims.Subject)
+		ctx = context.WithValue(ctx, "role", role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (m *AuthMiddleware) GenerateToken(userID string) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// RequireRole пропускает запрос только пользователям с одной из указанных ролей.
+// Должен применяться после Auth.
+func (m *AuthMiddleware) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("role").(string)
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		})
+	}
+}
+
+func (m *AuthMiddleware) GenerateToken(userID, role string) (string, error) {
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -5,13 +5,14 @@ import (
 )
 
 type Account struct {
-	ID        int64     `json:"id" db:"id"`
-	UserID    int64     `json:"user_id" db:"user_id"`
-	Number    string    `json:"number" db:"number"`
-	Balance   float64   `json:"balance" db:"balance"`
-	Currency  string    `json:"currency" db:"currency"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID             int64     `json:"id" db:"id"`
+	UserID         int64     `json:"user_id" db:"user_id"`
+	Number         string    `json:"number" db:"number"`
+	Balance        float64   `json:"balance" db:"balance"`
+	OverdraftLimit float64   `json:"overdraft_limit" db:"overdraft_limit"` // лимит кредитной линии, до которого баланс может уходить в минус
+	Currency       string    `json:"currency" db:"currency"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type AccountCreate struct {
@@ -20,12 +21,131 @@ type AccountCreate struct {
 }
 
 type Transaction struct {
-	ID            int64     `json:"id" db:"id"`
-	FromAccountID int64     `json:"from_account_id" db:"from_account_id"`
-	ToAccountID   int64     `json:"to_account_id" db:"to_account_id"`
-	Amount        float64   `json:"amount" db:"amount"`
-	Type          string    `json:"type" db:"type"`
-	Status        string    `json:"status" db:"status"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
-} 
\ No newline at end of file
+	ID                    int64     `json:"id" db:"id"`
+	FromAccountID         int64     `json:"from_account_id" db:"from_account_id"`
+	ToAccountID           int64     `json:"to_account_id" db:"to_account_id"`
+	Amount                float64   `json:"amount" db:"amount"`
+	Type                  string    `json:"type" db:"type"`
+	Status                string    `json:"status" db:"status"`
+	MCC                   string    `json:"mcc,omitempty" db:"mcc"`                                         // код категории торговой точки
+	MerchantName          string    `json:"merchant_name,omitempty" db:"merchant_name"`                     // наименование торговой точки
+	OperatorID            *int64    `json:"operator_id,omitempty" db:"operator_id"`                         // оператор, проведший кассовую операцию или отмену
+	OriginalTransactionID *int64    `json:"original_transaction_id,omitempty" db:"original_transaction_id"` // отменяемая операция или операция, по которой сделан возврат
+	Reason                string    `json:"reason,omitempty" db:"reason"`                                   // причина отмены или возврата
+	ExternalReference     string    `json:"external_reference,omitempty" db:"external_reference"`           // идентификатор платежа в другом банке
+	RefundedAmount        float64   `json:"refunded_amount,omitempty"`                                      // сумма отмены и возвратов по исходной операции
+	Category              string    `json:"category,omitempty"`                                             // категория с точки зрения пользователя
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TransactionReversal представляет запрос оператора на полную отмену операции
+type TransactionReversal struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// TransactionRefund представляет запрос получателя на возврат всей суммы операции или ее части
+type TransactionRefund struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Reason string  `json:"reason"`
+}
+
+// Внутренние счета банка: через них деньги поступают в систему и выводятся из нее,
+// на них учитываются средства вкладчиков, процентные доходы и расходы
+const (
+	SystemAccountCash            = "cash"             // касса: внесение и выдача наличных
+	SystemAccountClearing        = "clearing"         // корреспондентский счет: пополнение из других банков и вывод в другие банки
+	SystemAccountLoans           = "loans"            // ссудный счет: погашение кредитов
+	SystemAccountDeposits        = "deposits"         // счет вкладов: суммы вкладов и капитализированные проценты
+	SystemAccountInterestExpense = "interest_expense" // процентные расходы: проценты по вкладам
+	SystemAccountInterestIncome  = "interest_income"  // процентные доходы: проценты по кредитным линиям
+)
+
+// CashOperation представляет запрос на внесение или снятие средств.
+// AccountNumber используется для кассовых операций оператора, счет клиента задается номером.
+type CashOperation struct {
+	AccountNumber string  `json:"account_number,omitempty"`
+	Amount        float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// ExternalTopUp представляет подтвержденный входящий платеж из другого банка,
+// зачисляемый оператором на счет клиента
+type ExternalTopUp struct {
+	AccountNumber     string  `json:"account_number" validate:"required"`
+	Amount            float64 `json:"amount" validate:"required,gt=0"`
+	ExternalReference string  `json:"external_reference" validate:"required"`
+}
+
+// Сортировка истории транзакций
+const (
+	TransactionSortCreatedAt = "created_at"
+	TransactionSortAmount    = "amount"
+	SortOrderAsc             = "asc"
+	SortOrderDesc            = "desc"
+)
+
+// TransactionFilter задает отбор, сортировку и страницу истории транзакций
+type TransactionFilter struct {
+	UserID       int64
+	AccountIDs   []int64 // счета пользователя, по которым ищутся транзакции
+	AccountID    int64   // отбор по одному счету пользователя
+	From         *time.Time
+	To           *time.Time // не включая
+	MinAmount    *float64
+	MaxAmount    *float64
+	Type         string
+	Status       string
+	Category     string
+	Counterparty string // номер счета второй стороны операции
+	Sort         string
+	Order        string
+	After        *TransactionCursor // последняя транзакция предыдущей страницы
+	Limit        int
+}
+
+// TransactionCursor - позиция в истории транзакций для получения следующей страницы
+type TransactionCursor struct {
+	Sort      string    `json:"s"`
+	Order     string    `json:"o"`
+	CreatedAt time.Time `json:"c"`
+	Amount    float64   `json:"a"`
+	ID        int64     `json:"i"`
+}
+
+// TransactionPage представляет страницу истории транзакций
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+	HasMore      bool           `json:"has_more"`
+}
+
+// Форматы выписки по счету
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatPDF = "pdf"
+	StatementFormat1C  = "1c" // формат обмена 1CClientBankExchange
+)
+
+// AccountStatement - заголовок выписки по счету за период: остатки и обороты.
+// Строки выписки передаются отдельно, по одной.
+type AccountStatement struct {
+	Account        *Account
+	Owner          string
+	From           time.Time // первый день периода
+	To             time.Time // последний день периода (включительно)
+	OpeningBalance float64
+	TotalIncoming  float64
+	TotalOutgoing  float64
+	ClosingBalance float64
+	CreatedAt      time.Time
+}
+
+// StatementLine - строка выписки по счету
+type StatementLine struct {
+	Transaction         *Transaction
+	CounterpartyAccount string
+	CounterpartyName    string
+	Incoming            float64
+	Outgoing            float64
+	Balance             float64 // остаток после операции
+}
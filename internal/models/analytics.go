@@ -7,6 +7,7 @@ type Analytics struct {
 	MonthlyStats    MonthlyStats    `json:"monthly_stats"`
 	CreditLoad      CreditLoad      `json:"credit_load"`
 	BalanceForecast BalanceForecast `json:"balance_forecast"`
+	Budgets         []*BudgetProgress `json:"budgets"` // расходование бюджетов в текущем месяце
 }
 
 // MonthlyStats представляет статистику доходов/расходов за месяц
@@ -26,12 +27,28 @@ type CategoryStats struct {
 	Count    int     `json:"count"`
 }
 
+// CategoryTotal - сумма и количество операций по направлению и категории
+type CategoryTotal struct {
+	Direction string  `json:"direction"`
+	Category  string  `json:"category"`
+	Amount    float64 `json:"amount"`
+	Count     int     `json:"count"`
+}
+
+// PeriodTotal - сумма и количество операций по категории за период истории
+type PeriodTotal struct {
+	Period time.Time // начало периода (дата в часовом поясе пользователя)
+	CategoryTotal
+}
+
 // CreditLoad представляет аналитику кредитной нагрузки
 type CreditLoad struct {
 	TotalCredits        float64 `json:"total_credits"`
 	ActiveCredits       int     `json:"active_credits"`
 	MonthlyPayments     float64 `json:"monthly_payments"`
 	TotalDebt           float64 `json:"total_debt"`
+	CreditLimit         float64 `json:"credit_limit"`       // Суммарный лимит кредитных линий
+	CreditLineDebt      float64 `json:"credit_line_debt"`   // Задолженность по кредитным линиям
 	CreditUtilization   float64 `json:"credit_utilization"` // Процент использования кредитного лимита
 	PaymentToIncome     float64 `json:"payment_to_income"`  // Отношение платежей к доходу
 }
@@ -42,12 +59,103 @@ type BalanceForecast struct {
 	EndDate       time.Time         `json:"end_date"`
 	InitialBalance float64          `json:"initial_balance"`
 	ForecastDays  []ForecastDay     `json:"forecast_days"`
+	RecurringPayments       []RecurringPayment `json:"recurring_payments"`                   // регулярные поступления и платежи, учтенные в прогнозе
+	NegativeBalanceDate     *time.Time         `json:"negative_balance_date,omitempty"`      // первый день с отрицательным ожидаемым балансом
+	NegativeBalanceRiskDate *time.Time         `json:"negative_balance_risk_date,omitempty"` // первый день с отрицательной нижней границей прогноза
 }
 
 // ForecastDay представляет прогноз на конкретный день
 type ForecastDay struct {
 	Date            time.Time `json:"date"`
 	ExpectedBalance float64   `json:"expected_balance"`
+	LowerBalance    float64   `json:"lower_balance"` // нижняя граница доверительного интервала
+	UpperBalance    float64   `json:"upper_balance"` // верхняя граница доверительного интервала
 	PlannedIncome   float64   `json:"planned_income"`
 	PlannedExpenses float64   `json:"planned_expenses"`
-} 
\ No newline at end of file
+}
+
+// Периодичность регулярных операций
+const (
+	RecurrenceWeekly   = "weekly"
+	RecurrenceBiweekly = "biweekly"
+	RecurrenceMonthly  = "monthly"
+)
+
+// RecurringPayment представляет регулярное поступление (зарплата) или платеж (подписка, аренда),
+// выявленное по истории операций
+type RecurringPayment struct {
+	Direction       string    `json:"direction"`
+	Category        string    `json:"category"`
+	Counterparty    string    `json:"counterparty"`     // торговая точка или номер счета контрагента
+	Amount          float64   `json:"amount"`           // типичная (медианная) сумма
+	AmountDeviation float64   `json:"amount_deviation"` // стандартное отклонение суммы
+	Period          string    `json:"period"`
+	Occurrences     int       `json:"occurrences"`
+	LastDate        time.Time `json:"last_date"`
+	NextDate        time.Time `json:"next_date"`
+	Confidence      float64   `json:"confidence"` // от 0 до 1
+}
+
+// CounterpartyTransaction - категоризированная операция пользователя с контрагентом,
+// используемая для выявления регулярных платежей
+type CounterpartyTransaction struct {
+	Direction    string
+	Category     string
+	Counterparty string
+	Amount       float64
+	CreatedAt    time.Time
+}
+
+// Гранулярность истории аналитики
+const (
+	HistoryGranularityMonth = "month"
+	HistoryGranularityWeek  = "week"
+)
+
+// AnalyticsHistory представляет динамику доходов и расходов по периодам
+type AnalyticsHistory struct {
+	Granularity string          `json:"granularity"`
+	Timezone    string          `json:"timezone"`
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"` // не включительно
+	Periods     []HistoryPeriod `json:"periods"`
+	Averages    HistoryAverages `json:"averages"`
+}
+
+// HistoryPeriod представляет доходы и расходы за один период истории
+type HistoryPeriod struct {
+	Start         time.Time       `json:"start"`
+	End           time.Time       `json:"end"` // не включительно
+	TotalIncome   float64         `json:"total_income"`
+	TotalExpenses float64         `json:"total_expenses"`
+	NetIncome     float64         `json:"net_income"`
+	Transactions  int             `json:"transactions_count"`
+	Categories    []CategoryTotal `json:"categories"`
+	Change        *PeriodChange   `json:"change,omitempty"` // изменение к предыдущему периоду
+	Complete      bool            `json:"complete"`         // false для текущего периода
+}
+
+// PeriodChange представляет изменение показателей относительно предыдущего периода
+type PeriodChange struct {
+	Income          float64  `json:"income"`
+	Expenses        float64  `json:"expenses"`
+	NetIncome       float64  `json:"net_income"`
+	IncomePercent   *float64 `json:"income_percent,omitempty"`   // не рассчитывается при нулевом значении в предыдущем периоде
+	ExpensesPercent *float64 `json:"expenses_percent,omitempty"`
+}
+
+// HistoryAverages представляет средние значения за завершенные периоды истории
+type HistoryAverages struct {
+	Periods    int               `json:"periods"`
+	Income     float64           `json:"income"`
+	Expenses   float64           `json:"expenses"`
+	NetIncome  float64           `json:"net_income"`
+	Categories []CategoryAverage `json:"categories"`
+}
+
+// CategoryAverage представляет среднюю сумму операций по категории за период
+type CategoryAverage struct {
+	Direction string  `json:"direction"`
+	Category  string  `json:"category"`
+	Amount    float64 `json:"amount"`
+}
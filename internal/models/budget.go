@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Состояние бюджета в текущем месяце
+const (
+	BudgetStatusOK       = "ok"       // израсходовано меньше 80%
+	BudgetStatusWarning  = "warning"  // израсходовано 80% и более
+	BudgetStatusExceeded = "exceeded" // бюджет исчерпан
+)
+
+// Budget - месячный бюджет пользователя по категории расходов
+type Budget struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Category  string    `json:"category" db:"category"`
+	Amount    float64   `json:"amount" db:"amount"` // лимит расходов в месяц
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BudgetCreate представляет запрос на создание бюджета
+type BudgetCreate struct {
+	Category string  `json:"category" validate:"required"`
+	Amount   float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// BudgetUpdate представляет запрос на изменение лимита бюджета
+type BudgetUpdate struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// BudgetProgress - расходование бюджета в текущем месяце
+type BudgetProgress struct {
+	Budget
+	Month     time.Time `json:"month"`
+	Spent     float64   `json:"spent"`
+	Remaining float64   `json:"remaining"` // отрицательный при перерасходе
+	Percent   float64   `json:"percent"`
+	Status    string    `json:"status"`
+}
+
+// BudgetAlert - уведомление о достижении порога расходования бюджета
+type BudgetAlert struct {
+	BudgetID  int64     `json:"budget_id" db:"budget_id"`
+	Month     time.Time `json:"month" db:"month"`
+	Threshold int       `json:"threshold" db:"threshold"` // процент лимита: 80 или 100
+	Spent     float64   `json:"spent" db:"spent"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
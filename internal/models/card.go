@@ -4,6 +4,43 @@ import (
 	"time"
 )
 
+// Статусы карты
+const (
+	CardStatusActive  = "active"
+	CardStatusBlocked = "blocked" // временная блокировка, может быть снята
+	CardStatusLost    = "lost"
+	CardStatusStolen  = "stolen"
+	CardStatusExpired = "expired"
+	CardStatusClosed  = "closed"
+)
+
+// Типы карт
+const (
+	CardTypePhysical  = "physical"
+	CardTypeVirtual   = "virtual"
+	CardTypeSingleUse = "single_use" // закрывается после первой операции или по истечении TTL
+)
+
+// Платежные системы
+const (
+	PaymentSystemMir        = "mir"
+	PaymentSystemVisa       = "visa"
+	PaymentSystemMastercard = "mastercard"
+)
+
+// Коды причин смены статуса карты
+const (
+	CardReasonCustomerRequest = "customer_request"
+	CardReasonSuspectedFraud  = "suspected_fraud"
+	CardReasonLost            = "lost"
+	CardReasonStolen          = "stolen"
+	CardReasonDamaged         = "damaged"
+	CardReasonExpired         = "expired"
+	CardReasonUsed            = "used"
+	CardReasonTTLExpired      = "ttl_expired"
+	CardReasonPINTriesExceeded = "pin_tries_exceeded"
+)
+
 type Card struct {
 	ID            int64     `json:"id" db:"id"`
 	AccountID     int64     `json:"account_id" db:"account_id"`
@@ -12,6 +49,20 @@ type Card struct {
 	ExpiryDate    string    `json:"expiry_date" db:"expiry_date"`
 	CardholderName string   `json:"cardholder_name" db:"cardholder_name"`
 	IsActive      bool      `json:"is_active" db:"is_active"`
+	Status        string    `json:"status" db:"status"`
+	StatusReason  string    `json:"status_reason,omitempty" db:"status_reason"`
+	PreviousCardID *int64   `json:"previous_card_id,omitempty" db:"previous_card_id"`
+	// Причина перевыпуска: у старой карты - причина замены, у новой - причина выпуска взамен старой
+	ReissueReason string    `json:"reissue_reason,omitempty" db:"reissue_reason"`
+	AutoReissue   bool      `json:"auto_reissue" db:"auto_reissue"`
+	Type          string    `json:"type" db:"type"`
+	MerchantAccountID *int64 `json:"merchant_account_id,omitempty" db:"merchant_account_id"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	PaymentSystem string    `json:"payment_system" db:"payment_system"`
+	ProductCode   string    `json:"product_code" db:"product_code"`
+	ProductType   string    `json:"product_type" db:"product_type"`
+	EncryptedPINBlock string `json:"-" db:"encrypted_pin_block"`
+	PINTryCount   int       `json:"-" db:"pin_try_count"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -19,6 +70,14 @@ type Card struct {
 type CardCreate struct {
 	AccountID      int64  `json:"account_id" validate:"required"`
 	CardholderName string `json:"cardholder_name" validate:"required"`
+	AutoReissue    *bool  `json:"auto_reissue"`
+	Type           string `json:"type" validate:"omitempty,oneof=physical virtual single_use"`
+	// Привязка виртуальной или одноразовой карты к счету конкретного мерчанта
+	MerchantAccountID *int64 `json:"merchant_account_id"`
+	// Время жизни одноразовой карты в минутах (по умолчанию 24 часа)
+	TTLMinutes     int    `json:"ttl_minutes" validate:"gte=0"`
+	// Код карточного продукта из справочника BIN (по умолчанию - продукт по умолчанию)
+	Product        string `json:"product"`
 }
 
 type CardResponse struct {
@@ -28,5 +87,97 @@ type CardResponse struct {
 	ExpiryDate    string    `json:"expiry_date"`
 	CardholderName string   `json:"cardholder_name"`
 	IsActive      bool      `json:"is_active"`
+	Status        string    `json:"status"`
+	StatusReason  string    `json:"status_reason,omitempty"`
+	PreviousCardID *int64   `json:"previous_card_id,omitempty"`
+	ReissueReason string    `json:"reissue_reason,omitempty"`
+	AutoReissue   bool      `json:"auto_reissue"`
+	Type          string    `json:"type"`
+	MerchantAccountID *int64 `json:"merchant_account_id,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	PaymentSystem string    `json:"payment_system"`
+	ProductCode   string    `json:"product_code"`
+	ProductType   string    `json:"product_type"`
+	PINSet        bool      `json:"pin_set"`
 	CreatedAt     time.Time `json:"created_at"`
-} 
\ No newline at end of file
+}
+
+// CardBIN представляет BIN-диапазон, в котором выпускаются карты продукта
+type CardBIN struct {
+	ID            int64  `json:"id" db:"id"`
+	BIN           string `json:"bin" db:"bin"`
+	PaymentSystem string `json:"payment_system" db:"payment_system"`
+	ProductCode   string `json:"product_code" db:"product_code"`
+	ProductName   string `json:"product_name" db:"product_name"`
+	ProductType   string `json:"product_type" db:"product_type"`
+	IsDefault     bool   `json:"is_default" db:"is_default"`
+	IsActive      bool   `json:"is_active" db:"is_active"`
+}
+
+// CardPINSet представляет запрос на установку или смену PIN-кода карты
+type CardPINSet struct {
+	OldPIN string `json:"old_pin"`
+	PIN    string `json:"pin" validate:"required,numeric,min=4,max=12"`
+}
+
+// CardStatusChange представляет запрос на блокировку или перевыпуск карты
+type CardStatusChange struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// CardLimits представляет лимиты и ограничения по карте.
+// Нулевое значение лимита означает отсутствие ограничения.
+type CardLimits struct {
+	CardID              int64     `json:"card_id" db:"card_id"`
+	DailyLimit          float64   `json:"daily_limit" db:"daily_limit"`
+	MonthlyLimit        float64   `json:"monthly_limit" db:"monthly_limit"`
+	PerTransactionLimit float64   `json:"per_transaction_limit" db:"per_transaction_limit"`
+	OnlineEnabled       bool      `json:"online_enabled" db:"online_enabled"`
+	ContactlessEnabled  bool      `json:"contactless_enabled" db:"contactless_enabled"`
+	ATMEnabled          bool      `json:"atm_enabled" db:"atm_enabled"`
+	ForeignEnabled      bool      `json:"foreign_enabled" db:"foreign_enabled"`
+	DailySpent          float64   `json:"daily_spent" db:"-"`
+	MonthlySpent        float64   `json:"monthly_spent" db:"-"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CardLimitsUpdate struct {
+	DailyLimit          float64 `json:"daily_limit" validate:"gte=0"`
+	MonthlyLimit        float64 `json:"monthly_limit" validate:"gte=0"`
+	PerTransactionLimit float64 `json:"per_transaction_limit" validate:"gte=0"`
+	OnlineEnabled       bool    `json:"online_enabled"`
+	ContactlessEnabled  bool    `json:"contactless_enabled"`
+	ATMEnabled          bool    `json:"atm_enabled"`
+	ForeignEnabled      bool    `json:"foreign_enabled"`
+}
+
+// Каналы проведения операций по карте
+const (
+	CardChannelPOS         = "pos"
+	CardChannelOnline      = "online"
+	CardChannelContactless = "contactless"
+	CardChannelATM         = "atm"
+)
+
+// CardAuthorization представляет запрос на авторизацию операции по карте
+type CardAuthorization struct {
+	CardNumber        string  `json:"card_number" validate:"required,len=16"`
+	ExpiryDate        string  `json:"expiry_date" validate:"required"`
+	CVV               string  `json:"cvv"`
+	Amount            float64 `json:"amount" validate:"required,gt=0"`
+	Currency          string  `json:"currency" validate:"omitempty,len=3"`
+	Channel           string  `json:"channel" validate:"required,oneof=pos online contactless atm"`
+	MerchantAccountID int64   `json:"merchant_account_id" validate:"required"`
+	MerchantName      string  `json:"merchant_name"`
+	MCC               string  `json:"mcc" validate:"omitempty,len=4,numeric"` // код категории торговой точки
+	// PIN обязателен для операций в банкомате и по чипу (канал pos)
+	PIN               string  `json:"pin"`
+}
+
+// CardAuthorizationResult представляет результат авторизации операции по карте
+type CardAuthorizationResult struct {
+	Approved      bool    `json:"approved"`
+	DeclineReason string  `json:"decline_reason,omitempty"`
+	TransactionID int64   `json:"transaction_id,omitempty"`
+	Amount        float64 `json:"amount"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+)
+
+// Категории операций
+const (
+	CategoryGroceries     = "groceries"
+	CategoryRestaurants   = "restaurants"
+	CategoryTransport     = "transport"
+	CategoryFuel          = "fuel"
+	CategoryHealth        = "health"
+	CategoryEntertainment = "entertainment"
+	CategoryShopping      = "shopping"
+	CategoryUtilities     = "utilities"
+	CategoryTravel        = "travel"
+	CategoryCash          = "cash"
+	CategoryTransfers     = "transfers"
+	CategorySalary        = "salary"
+	CategoryOther         = "other"
+)
+
+// Направление операции относительно пользователя
+const (
+	DirectionIncome   = "income"
+	DirectionExpense  = "expense"
+	DirectionInternal = "internal" // перевод между собственными счетами
+)
+
+// Источник категории операции, в порядке убывания приоритета
+const (
+	CategorySourceManual       = "manual"       // категория изменена пользователем
+	CategorySourceUserRule     = "user_rule"    // правило пользователя
+	CategorySourceCounterparty = "counterparty" // правило банка по контрагенту
+	CategorySourceMCC          = "mcc"          // код категории торговой точки
+	CategorySourceDefault      = "default"      // по типу операции
+)
+
+// Category описывает категорию операций
+type Category struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// CategoryRule - правило категоризации. Правило срабатывает, если совпадают все заданные условия.
+// Правила банка по контрагентам не привязаны к пользователю.
+type CategoryRule struct {
+	ID           int64     `json:"id" db:"id"`
+	UserID       *int64    `json:"user_id,omitempty" db:"user_id"`
+	Category     string    `json:"category" db:"category"`
+	Counterparty string    `json:"counterparty,omitempty" db:"counterparty"` // номер счета контрагента
+	MCC          string    `json:"mcc,omitempty" db:"mcc"`
+	Merchant     string    `json:"merchant,omitempty" db:"merchant"` // подстрока наименования торговой точки
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CategoryRuleCreate представляет запрос на создание правила категоризации
+type CategoryRuleCreate struct {
+	Category     string `json:"category" validate:"required"`
+	Counterparty string `json:"counterparty"`
+	MCC          string `json:"mcc"`
+	Merchant     string `json:"merchant"`
+}
+
+// TransactionCategory - категория операции с точки зрения пользователя
+type TransactionCategory struct {
+	TransactionID int64     `json:"transaction_id" db:"transaction_id"`
+	UserID        int64     `json:"user_id" db:"user_id"`
+	Direction     string    `json:"direction" db:"direction"`
+	Category      string    `json:"category" db:"category"`
+	Source        string    `json:"source" db:"source"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TransactionCategoryUpdate представляет запрос на изменение категории операции
+type TransactionCategoryUpdate struct {
+	Category string `json:"category" validate:"required"`
+}
+
+// UncategorizedTransaction - операция без категории вместе с номерами счетов сторон
+type UncategorizedTransaction struct {
+	Transaction *Transaction
+	FromAccount string
+	ToAccount   string
+}
@@ -4,6 +4,13 @@ import (
 	"time"
 )
 
+// Типы погашения кредита
+const (
+	RepaymentAnnuity        = "annuity"        // равные ежемесячные платежи
+	RepaymentDifferentiated = "differentiated" // равные доли основного долга
+	RepaymentBullet         = "bullet"         // только проценты, основной долг в конце срока
+)
+
 type Credit struct {
 	ID            int64     `json:"id" db:"id"`
 	UserID        int64     `json:"user_id" db:"user_id"`
@@ -12,6 +19,13 @@ type Credit struct {
 	InterestRate  float64   `json:"interest_rate" db:"interest_rate"`
 	TermMonths    int       `json:"term_months" db:"term_months"`
 	Status        string    `json:"status" db:"status"`
+	RepaymentType string    `json:"repayment_type" db:"repayment_type"`
+	DayCount      string    `json:"day_count" db:"day_count"` // конвенция расчета дней для начисления процентов
+	PSK           float64   `json:"psk" db:"psk"`               // полная стоимость кредита, % годовых
+	PSKAmount     float64   `json:"psk_amount" db:"psk_amount"` // полная стоимость кредита в денежном выражении
+	IssueFee      float64   `json:"issue_fee" db:"issue_fee"`
+	InsurancePremium float64 `json:"insurance_premium" db:"insurance_premium"`
+	ScheduleVersion int     `json:"schedule_version" db:"schedule_version"` // действующая версия графика платежей
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -21,6 +35,31 @@ type CreditCreate struct {
 	AccountID    int64   `json:"account_id" validate:"required"`
 	Amount       float64 `json:"amount" validate:"required,gt=0"`
 	TermMonths   int     `json:"term_months" validate:"required,gt=0"`
+	WithInsurance bool   `json:"with_insurance"`
+	RepaymentType string `json:"repayment_type" validate:"omitempty,oneof=annuity differentiated bullet"`
+}
+
+// CreditQuoteRequest представляет запрос на предварительный расчет кредита
+type CreditQuoteRequest struct {
+	Amount        float64 `json:"amount" validate:"required,gt=0"`
+	TermMonths    int     `json:"term_months" validate:"required,gt=0"`
+	WithInsurance bool    `json:"with_insurance"`
+	RepaymentType string  `json:"repayment_type" validate:"omitempty,oneof=annuity differentiated bullet"`
+}
+
+// CreditQuote представляет предварительный расчет кредита: график платежей и ПСК
+type CreditQuote struct {
+	Amount           float64            `json:"amount"`
+	TermMonths       int                `json:"term_months"`
+	InterestRate     float64            `json:"interest_rate"`
+	RepaymentType    string             `json:"repayment_type"`
+	MonthlyPayment   float64            `json:"monthly_payment"` // первый платеж по графику
+	TotalPayments    float64            `json:"total_payments"`
+	IssueFee         float64            `json:"issue_fee"`
+	InsurancePremium float64            `json:"insurance_premium"`
+	PSK              float64            `json:"psk"`
+	PSKAmount        float64            `json:"psk_amount"`
+	Schedule         []*PaymentSchedule `json:"schedule"`
 }
 
 type PaymentSchedule struct {
@@ -32,6 +71,200 @@ type PaymentSchedule struct {
 	Interest      float64   `json:"interest" db:"interest"`
 	DueDate       time.Time `json:"due_date" db:"due_date"`
 	Status        string    `json:"status" db:"status"`
+	Version       int       `json:"version" db:"version"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
-} 
\ No newline at end of file
+}
+
+// InterestAccrual представляет ежедневное начисление процентов по кредиту
+type InterestAccrual struct {
+	ID           int64     `json:"id,omitempty" db:"id"`
+	CreditID     int64     `json:"credit_id" db:"credit_id"`
+	AccrualDate  time.Time `json:"accrual_date" db:"accrual_date"`
+	Principal    float64   `json:"principal" db:"principal"` // остаток основного долга на дату
+	InterestRate float64   `json:"interest_rate" db:"interest_rate"`
+	Amount       float64   `json:"amount" db:"amount"`
+	CreatedAt    time.Time `json:"created_at,omitempty" db:"created_at"`
+}
+
+// AccruedInterest представляет проценты, начисленные по кредиту на дату с начала текущего процентного периода
+type AccruedInterest struct {
+	CreditID    int64              `json:"credit_id"`
+	Date        time.Time          `json:"date"`
+	DayCount    string             `json:"day_count"`
+	PeriodStart time.Time          `json:"period_start"`
+	Principal   float64            `json:"principal"`
+	Accrued     float64            `json:"accrued"`
+	Accruals    []*InterestAccrual `json:"accruals"`
+}
+
+// CalendarDay представляет день производственного календаря, отличающийся от обычной пятидневки
+type CalendarDay struct {
+	Date        time.Time `json:"date" db:"date"`
+	IsWorking   bool      `json:"is_working" db:"is_working"` // перенесенный рабочий день
+	Description string    `json:"description" db:"description"`
+}
+
+// Режимы досрочного погашения
+const (
+	PrepaymentFull          = "full"           // полное досрочное погашение
+	PrepaymentReduceTerm    = "reduce_term"    // частичное погашение с сокращением срока
+	PrepaymentReducePayment = "reduce_payment" // частичное погашение с уменьшением платежа
+)
+
+// CreditPrepaymentRequest представляет запрос на досрочное погашение кредита
+type CreditPrepaymentRequest struct {
+	Amount float64 `json:"amount"` // не используется при полном погашении
+	Mode   string  `json:"mode" validate:"required,oneof=full reduce_term reduce_payment"`
+}
+
+// CreditPrepayment представляет проведенное досрочное погашение
+type CreditPrepayment struct {
+	ID              int64     `json:"id" db:"id"`
+	CreditID        int64     `json:"credit_id" db:"credit_id"`
+	Mode            string    `json:"mode" db:"mode"`
+	Amount          float64   `json:"amount" db:"amount"`
+	Principal       float64   `json:"principal" db:"principal"`
+	Interest        float64   `json:"interest" db:"interest"` // проценты, начисленные на дату погашения
+	PreviousVersion int       `json:"previous_version" db:"previous_version"`
+	ScheduleVersion int       `json:"schedule_version" db:"schedule_version"`
+	TransactionID   *int64    `json:"transaction_id,omitempty" db:"transaction_id"` // списание со счета на ссудный счет
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreditPrepaymentResult представляет результат досрочного погашения с пересчитанным графиком
+type CreditPrepaymentResult struct {
+	Prepayment *CreditPrepayment  `json:"prepayment"`
+	Credit     *Credit            `json:"credit"`
+	Schedule   []*PaymentSchedule `json:"schedule"`
+}
+
+// Виды реструктуризации кредита
+const (
+	RestructuringPaymentHoliday = "payment_holiday" // отсрочка N платежей
+	RestructuringTermExtension  = "term_extension"  // увеличение срока
+	RestructuringRateChange     = "rate_change"     // изменение ставки
+)
+
+// Статусы запроса на реструктуризацию
+const (
+	RestructuringStatusPending  = "pending"
+	RestructuringStatusApproved = "approved"
+	RestructuringStatusRejected = "rejected"
+)
+
+// CreditRestructuringRequest представляет запрос клиента на реструктуризацию кредита
+type CreditRestructuringRequest struct {
+	Type         string  `json:"type" validate:"required,oneof=payment_holiday term_extension rate_change"`
+	Payments     int     `json:"payments"`      // количество отсрочиваемых платежей
+	ExtraMonths  int     `json:"extra_months"`  // на сколько месяцев увеличивается срок
+	InterestRate float64 `json:"interest_rate"` // новая ставка, % годовых
+	Reason       string  `json:"reason"`
+}
+
+// CreditRestructuringDecision представляет решение оператора по реструктуризации
+type CreditRestructuringDecision struct {
+	Comment string `json:"comment"`
+}
+
+// CreditRestructuring представляет запрос на реструктуризацию и решение по нему
+type CreditRestructuring struct {
+	ID              int64      `json:"id" db:"id"`
+	CreditID        int64      `json:"credit_id" db:"credit_id"`
+	UserID          int64      `json:"user_id" db:"user_id"`
+	Type            string     `json:"type" db:"type"`
+	Payments        int        `json:"payments,omitempty" db:"payments"`
+	ExtraMonths     int        `json:"extra_months,omitempty" db:"extra_months"`
+	InterestRate    float64    `json:"interest_rate,omitempty" db:"interest_rate"`
+	Reason          string     `json:"reason" db:"reason"`
+	Status          string     `json:"status" db:"status"`
+	OperatorID      *int64     `json:"operator_id,omitempty" db:"operator_id"`
+	DecisionComment string     `json:"decision_comment,omitempty" db:"decision_comment"`
+	ScheduleVersion *int       `json:"schedule_version,omitempty" db:"schedule_version"` // версия графика после реструктуризации
+	DecidedAt       *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ScheduleVersion представляет одну из версий графика платежей кредита
+type ScheduleVersion struct {
+	Version   int                `json:"version"`
+	Current   bool               `json:"current"`
+	CreatedAt time.Time          `json:"created_at"`
+	Payments  []*PaymentSchedule `json:"payments"`
+}
+
+// Статусы заявки на кредит
+const (
+	ApplicationStatusSubmitted = "submitted"
+	ApplicationStatusScoring   = "scoring"
+	ApplicationStatusApproved  = "approved"
+	ApplicationStatusRejected  = "rejected"
+	ApplicationStatusDisbursed = "disbursed"
+)
+
+// CreditApplication представляет заявку на кредит и решение по ней
+type CreditApplication struct {
+	ID           int64      `json:"id" db:"id"`
+	UserID       int64      `json:"user_id" db:"user_id"`
+	AccountID    int64      `json:"account_id" db:"account_id"`
+	Amount       float64    `json:"amount" db:"amount"`
+	TermMonths   int        `json:"term_months" db:"term_months"`
+	RepaymentType string    `json:"repayment_type" db:"repayment_type"`
+	Status       string     `json:"status" db:"status"`
+	Score        int        `json:"score" db:"score"`
+	MaxAmount    float64    `json:"max_amount" db:"max_amount"`
+	InterestRate float64    `json:"interest_rate" db:"interest_rate"`
+	Reasons      []string   `json:"reasons" db:"reasons"`
+	PDN          float64    `json:"pdn" db:"pdn"` // показатель долговой нагрузки, %
+	PDNFlagged   bool       `json:"pdn_flagged" db:"pdn_flagged"`
+	WithInsurance bool      `json:"with_insurance" db:"with_insurance"`
+	PSK          float64    `json:"psk" db:"psk"`
+	PSKAmount    float64    `json:"psk_amount" db:"psk_amount"`
+	CreditID     *int64     `json:"credit_id,omitempty" db:"credit_id"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ScoringData содержит данные о заемщике, на основе которых принимается решение по заявке
+type ScoringData struct {
+	RequestedAmount   float64
+	TermMonths        int
+	RepaymentType     string
+	KeyRate           float64
+	MonthlyIncome     float64 // средний подтвержденный доход за последние месяцы
+	MonthlyPayments   float64 // среднемесячные платежи по всем действующим кредитам
+	ActiveCredits     int
+	OverduePayments   int
+	TransactionsCount int // количество операций за период оценки
+	AccountAgeDays    int // возраст самого старого счета клиента
+}
+
+// ScoringDecision представляет результат скоринга заявки
+type ScoringDecision struct {
+	Approved     bool
+	Score        int
+	MaxAmount    float64
+	InterestRate float64
+	Reasons      []string
+}
+
+// Виды документов по кредиту
+const (
+	DocumentTypeAgreement          = "agreement"           // кредитный договор с графиком платежей и ПСК
+	DocumentTypeClosureCertificate = "closure_certificate" // справка о полном погашении и отсутствии задолженности
+)
+
+// CreditDocument представляет сформированный PDF-документ по кредиту. Контрольная сумма
+// SHA-256 позволяет проверить, что содержимое не изменилось после формирования.
+type CreditDocument struct {
+	ID        int64     `json:"id" db:"id"`
+	CreditID  int64     `json:"credit_id" db:"credit_id"`
+	Type      string    `json:"type" db:"type"`
+	FileName  string    `json:"file_name" db:"file_name"`
+	Checksum  string    `json:"checksum" db:"checksum"`
+	Size      int       `json:"size" db:"size"`
+	Content   []byte    `json:"-" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
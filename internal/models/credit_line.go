@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Статусы кредитной линии
+const (
+	CreditLineStatusActive = "active"
+	CreditLineStatusClosed = "closed"
+)
+
+// Статусы выписки по кредитной линии
+const (
+	StatementStatusOpen    = "open"    // минимальный платеж еще не внесен
+	StatementStatusPaid    = "paid"    // минимальный платеж внесен
+	StatementStatusOverdue = "overdue" // минимальный платеж не внесен в срок
+)
+
+// CreditLine представляет возобновляемую кредитную линию (овердрафт), привязанную к счету.
+// Задолженность возникает, когда баланс счета уходит в минус в пределах лимита.
+type CreditLine struct {
+	ID                int64      `json:"id" db:"id"`
+	UserID            int64      `json:"user_id" db:"user_id"`
+	AccountID         int64      `json:"account_id" db:"account_id"`
+	CreditLimit       float64    `json:"credit_limit" db:"credit_limit"`
+	InterestRate      float64    `json:"interest_rate" db:"interest_rate"`
+	GraceDays         int        `json:"grace_days" db:"grace_days"`
+	MinPaymentPercent float64    `json:"min_payment_percent" db:"min_payment_percent"`
+	StatementDay      int        `json:"statement_day" db:"statement_day"` // день месяца формирования выписки
+	Status            string     `json:"status" db:"status"`
+	UsedAmount        float64    `json:"used_amount" db:"-"`                     // текущая задолженность по основному долгу
+	AvailableAmount   float64    `json:"available_amount" db:"-"`                // доступный остаток лимита
+	AccruedInterest   float64    `json:"accrued_interest" db:"accrued_interest"` // начисленные и еще не списанные проценты
+	GraceInterest     float64    `json:"grace_interest" db:"grace_interest"`     // проценты льготного периода, списываются при его нарушении
+	GraceStart        *time.Time `json:"grace_start,omitempty" db:"grace_start"`
+	LastAccrualDate   *time.Time `json:"-" db:"last_accrual_date"`
+	LastStatementDate *time.Time `json:"last_statement_date,omitempty" db:"last_statement_date"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreditLineCreate представляет запрос на открытие кредитной линии
+type CreditLineCreate struct {
+	UserID    int64   `json:"user_id"`
+	AccountID int64   `json:"account_id" validate:"required"`
+	Limit     float64 `json:"limit" validate:"required,gt=0"`
+}
+
+// CreditLineStatement представляет ежемесячную выписку по кредитной линии
+type CreditLineStatement struct {
+	ID              int64     `json:"id" db:"id"`
+	CreditLineID    int64     `json:"credit_line_id" db:"credit_line_id"`
+	PeriodStart     time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd       time.Time `json:"period_end" db:"period_end"`
+	OpeningDebt     float64   `json:"opening_debt" db:"opening_debt"`
+	ClosingDebt     float64   `json:"closing_debt" db:"closing_debt"`
+	InterestCharged float64   `json:"interest_charged" db:"interest_charged"`
+	MinPayment      float64   `json:"min_payment" db:"min_payment"`
+	DueDate         time.Time `json:"due_date" db:"due_date"`
+	Status          string    `json:"status" db:"status"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
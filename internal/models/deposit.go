@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// Виды вкладов
+const (
+	DepositTypeSavings = "savings" // накопительный счет: пополнение и снятие в любой момент, ставка меняется вслед за ключевой
+	DepositTypeTerm    = "term"    // срочный вклад: ставка фиксируется при открытии, средства возвращаются в дату окончания
+)
+
+// Статусы вклада
+const (
+	DepositStatusActive = "active"
+	DepositStatusClosed = "closed"
+)
+
+// Deposit представляет вклад или накопительный счет. Средства списываются со связанного
+// счета при открытии и возвращаются на него при закрытии.
+type Deposit struct {
+	ID               int64      `json:"id" db:"id"`
+	UserID           int64      `json:"user_id" db:"user_id"`
+	AccountID        int64      `json:"account_id" db:"account_id"`
+	Type             string     `json:"type" db:"type"`
+	Amount           float64    `json:"amount" db:"amount"`   // сумма при открытии
+	Balance          float64    `json:"balance" db:"balance"` // текущая сумма вклада с учетом капитализации
+	InterestRate     float64    `json:"interest_rate" db:"interest_rate"`
+	Capitalization   bool       `json:"capitalization" db:"capitalization"` // проценты причисляются к вкладу, иначе выплачиваются на счет
+	TermMonths       int        `json:"term_months,omitempty" db:"term_months"`
+	AccruedInterest  float64    `json:"accrued_interest" db:"accrued_interest"` // начисленные, но еще не выплаченные проценты
+	InterestPaid     float64    `json:"interest_paid" db:"interest_paid"`       // выплаченные и капитализированные проценты
+	Status           string     `json:"status" db:"status"`
+	NextInterestDate time.Time  `json:"next_interest_date" db:"next_interest_date"`
+	MaturityDate     *time.Time `json:"maturity_date,omitempty" db:"maturity_date"`
+	LastAccrualDate  *time.Time `json:"-" db:"last_accrual_date"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	Version          int        `json:"-" db:"version"` // увеличивается при каждом сохранении вклада
+}
+
+// DepositCreate представляет запрос на открытие вклада
+type DepositCreate struct {
+	UserID         int64   `json:"user_id"`
+	AccountID      int64   `json:"account_id" validate:"required"`
+	Type           string  `json:"type" validate:"required,oneof=savings term"`
+	Amount         float64 `json:"amount" validate:"required,gt=0"`
+	TermMonths     int     `json:"term_months"`
+	Capitalization bool    `json:"capitalization"`
+}
+
+// DepositOperation представляет запрос на пополнение или частичное снятие
+type DepositOperation struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// DepositRates представляет текущие ставки по вкладам
+type DepositRates struct {
+	KeyRate     float64 `json:"key_rate"`
+	SavingsRate float64 `json:"savings_rate"`
+	TermRate    float64 `json:"term_rate"`
+}
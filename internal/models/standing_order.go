@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Расписание постоянного поручения
+const (
+	StandingOrderOnce    = "once"    // разовый перевод в заданный момент
+	StandingOrderMonthly = "monthly" // ежемесячно в заданное число
+	StandingOrderWeekly  = "weekly"  // еженедельно в заданный день недели
+	StandingOrderCron    = "cron"    // по cron-выражению
+)
+
+// Статусы постоянного поручения
+const (
+	StandingOrderStatusActive    = "active"
+	StandingOrderStatusPaused    = "paused"
+	StandingOrderStatusCompleted = "completed" // исполнены все переводы по расписанию
+	StandingOrderStatusFailed    = "failed"    // разовый перевод не исполнен
+	StandingOrderStatusCancelled = "cancelled"
+)
+
+// Статусы попытки исполнения поручения
+const (
+	ExecutionStatusCompleted = "completed"
+	ExecutionStatusRetry     = "retry"  // недостаточно средств, попытка будет повторена
+	ExecutionStatusFailed    = "failed" // перевод по этому сроку не исполнен
+)
+
+// StandingOrder - постоянное поручение на перевод: разовый перевод в будущем или регулярные переводы.
+// Сроки исполнения ежемесячных и еженедельных поручений и cron-выражения считаются
+// в часовом поясе пользователя, переводы выполняются в начале дня.
+type StandingOrder struct {
+	ID             int64      `json:"id" db:"id"`
+	UserID         int64      `json:"user_id" db:"user_id"`
+	FromAccountID  int64      `json:"from_account_id" db:"from_account_id"`
+	ToAccountID    int64      `json:"to_account_id" db:"to_account_id"`
+	Amount         float64    `json:"amount" db:"amount"`
+	Description    string     `json:"description,omitempty" db:"description"`
+	Schedule       string     `json:"schedule" db:"schedule"`
+	ExecuteAt      *time.Time `json:"execute_at,omitempty" db:"execute_at"`     // для разового перевода
+	DayOfMonth     *int       `json:"day_of_month,omitempty" db:"day_of_month"` // 1-31, в коротких месяцах - последний день
+	DayOfWeek      *int       `json:"day_of_week,omitempty" db:"day_of_week"`   // 1 - понедельник, 7 - воскресенье
+	Cron           string     `json:"cron,omitempty" db:"cron"`                 // минуты часы дни месяцы дни_недели
+	EndDate        *time.Time `json:"end_date,omitempty" db:"end_date"`         // последний день действия поручения
+	Status         string     `json:"status" db:"status"`
+	NextOccurrence *time.Time `json:"next_occurrence,omitempty" db:"next_occurrence"` // срок ближайшего перевода
+	NextRunAt      *time.Time `json:"next_run_at,omitempty" db:"next_run_at"`         // время ближайшей попытки (с учетом повторов)
+	Attempts       int        `json:"attempts" db:"attempts"`                         // неудачные попытки по ближайшему сроку
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// StandingOrderCreate представляет запрос на создание постоянного поручения
+type StandingOrderCreate struct {
+	FromAccountID int64      `json:"from_account_id" validate:"required"`
+	ToAccountID   int64      `json:"to_account_id" validate:"required"`
+	Amount        float64    `json:"amount" validate:"required,gt=0"`
+	Description   string     `json:"description"`
+	Schedule      string     `json:"schedule" validate:"required"`
+	ExecuteAt     *time.Time `json:"execute_at"`
+	DayOfMonth    *int       `json:"day_of_month"`
+	DayOfWeek     *int       `json:"day_of_week"`
+	Cron          string     `json:"cron"`
+	EndDate       *time.Time `json:"end_date"`
+}
+
+// StandingOrderExecution - попытка исполнения постоянного поручения
+type StandingOrderExecution struct {
+	ID              int64     `json:"id" db:"id"`
+	StandingOrderID int64     `json:"standing_order_id" db:"standing_order_id"`
+	ScheduledAt     time.Time `json:"scheduled_at" db:"scheduled_at"` // срок перевода по расписанию
+	Attempt         int       `json:"attempt" db:"attempt"`
+	Status          string    `json:"status" db:"status"`
+	TransactionID   *int64    `json:"transaction_id,omitempty" db:"transaction_id"`
+	Error           string    `json:"error,omitempty" db:"error"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
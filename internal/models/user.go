@@ -4,11 +4,24 @@ import (
 	"time"
 )
 
+// DefaultTimezone - часовой пояс пользователя по умолчанию
+const DefaultTimezone = "Europe/Moscow"
+
+// Роли пользователей
+const (
+	RoleCustomer = "customer"
+	RoleOperator = "operator" // сотрудник банка: согласование реструктуризаций, кассовые операции
+	RoleAcquirer = "acquirer" // эквайер: авторизация операций по картам в пользу счетов мерчантов
+	RoleSystem   = "system"   // владелец внутренних счетов банка, вход невозможен
+)
+
 type User struct {
 	ID        int64     `json:"id" db:"id"`
 	Email     string    `json:"email" db:"email"`
 	Username  string    `json:"username" db:"username"`
 	Password  string    `json:"-" db:"password_hash"`
+	Role      string    `json:"role" db:"role"`
+	Timezone  string    `json:"timezone" db:"timezone"` // часовой пояс IANA, например Europe/Moscow
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -17,6 +30,12 @@ type UserRegistration struct {
 	Email    string `json:"email" validate:"required,email"`
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Password string `json:"password" validate:"required,min=8"`
+	Timezone string `json:"timezone"` // по умолчанию Europe/Moscow
+}
+
+// UserProfileUpdate представляет запрос на изменение настроек профиля
+type UserProfileUpdate struct {
+	Timezone string `json:"timezone" validate:"required"`
 }
 
 type UserLogin struct {
@@ -4,7 +4,19 @@ import (
 	"context"
 	"bank-api/internal/models"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTransactionReversed       = errors.New("transaction already reversed")
+	ErrDuplicateExternalPayment  = errors.New("external payment already credited")
+	ErrCompensationExceedsAmount = errors.New("compensation exceeds the remaining amount")
+	ErrInsufficientBalance       = errors.New("insufficient funds")
+	ErrWithdrawalLimitExceeded   = errors.New("withdrawal limit exceeded")
 )
 
 type PostgresAccountRepository struct {
@@ -34,7 +46,7 @@ func (r *PostgresAccountRepository) Create(ctx context.Context, account *models.
 func (r *PostgresAccountRepository) GetByID(ctx context.Context, id int64) (*models.Account, error) {
 	account := &models.Account{}
 	query := `
-		SELECT id, user_id, number, balance, currency, created_at, updated_at
+		SELECT id, user_id, number, balance, overdraft_limit, currency, created_at, updated_at
 		FROM accounts
 		WHERE id = $1`
 
@@ -43,6 +55,83 @@ func (r *PostgresAccountRepository) GetByID(ctx context.Context, id int64) (*mod
 		&account.UserID,
 		&account.Number,
 		&account.Balance,
+		&account.OverdraftLimit,
+		&account.Currency,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (r *PostgresAccountRepository) GetByNumber(ctx context.Context, number string) (*models.Account, error) {
+	account := &models.Account{}
+	query := `
+		SELECT id, user_id, number, balance, overdraft_limit, currency, created_at, updated_at
+		FROM accounts
+		WHERE number = $1`
+
+	err := r.db.QueryRowContext(ctx, query, number).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Number,
+		&account.Balance,
+		&account.OverdraftLimit,
+		&account.Currency,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// GetNumbers возвращает номера счетов по их ID
+func (r *PostgresAccountRepository) GetNumbers(ctx context.Context, ids []int64) (map[int64]string, error) {
+	query := `
+		SELECT id, number
+		FROM accounts
+		WHERE id = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	numbers := make(map[int64]string, len(ids))
+	for rows.Next() {
+		var id int64
+		var number string
+		if err := rows.Scan(&id, &number); err != nil {
+			return nil, err
+		}
+		numbers[id] = number
+	}
+
+	return numbers, rows.Err()
+}
+
+// GetSystemAccount возвращает внутренний счет банка по коду (касса, корреспондентский счет)
+func (r *PostgresAccountRepository) GetSystemAccount(ctx context.Context, code string) (*models.Account, error) {
+	account := &models.Account{}
+	query := `
+		SELECT a.id, a.user_id, a.number, a.balance, a.overdraft_limit, a.currency, a.created_at, a.updated_at
+		FROM system_accounts s
+		JOIN accounts a ON a.id = s.account_id
+		WHERE s.code = $1`
+
+	err := r.db.QueryRowContext(ctx, query, code).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Number,
+		&account.Balance,
+		&account.OverdraftLimit,
 		&account.Currency,
 		&account.CreatedAt,
 		&account.UpdatedAt,
@@ -56,7 +145,7 @@ func (r *PostgresAccountRepository) GetByID(ctx context.Context, id int64) (*mod
 
 func (r *PostgresAccountRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.Account, error) {
 	query := `
-		SELECT id, user_id, number, balance, currency, created_at, updated_at
+		SELECT id, user_id, number, balance, overdraft_limit, currency, created_at, updated_at
 		FROM accounts
 		WHERE user_id = $1`
 
@@ -74,6 +163,7 @@ func (r *PostgresAccountRepository) GetByUserID(ctx context.Context, userID int6
 			&account.UserID,
 			&account.Number,
 			&account.Balance,
+			&account.OverdraftLimit,
 			&account.Currency,
 			&account.CreatedAt,
 			&account.UpdatedAt,
@@ -106,29 +196,206 @@ func (r *PostgresAccountRepository) Delete(ctx context.Context, id int64) error
 	return err
 }
 
+const transactionColumns = `t.id, t.from_account_id, t.to_account_id, t.amount, t.type, t.status, t.mcc, t.merchant_name,
+		t.operator_id, t.original_transaction_id, t.reason, t.external_reference, t.created_at, t.updated_at`
+
+// refundedAmountColumn - сумма проведенных отмены и возвратов по операции t
+const refundedAmountColumn = `(SELECT COALESCE(SUM(r.amount), 0) FROM transactions r
+			WHERE r.original_transaction_id = t.id AND r.status = 'completed')`
+
+// scanTransaction читает транзакцию и дополнительные колонки запроса (extra) из строки результата
+func scanTransaction(row rowScanner, extra ...interface{}) (*models.Transaction, error) {
+	transaction := &models.Transaction{}
+	dest := append([]interface{}{
+		&transaction.ID,
+		&transaction.FromAccountID,
+		&transaction.ToAccountID,
+		&transaction.Amount,
+		&transaction.Type,
+		&transaction.Status,
+		&transaction.MCC,
+		&transaction.MerchantName,
+		&transaction.OperatorID,
+		&transaction.OriginalTransactionID,
+		&transaction.Reason,
+		&transaction.ExternalReference,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	}, extra...)
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
 func (r *PostgresAccountRepository) CreateTransaction(ctx context.Context, transaction *models.Transaction) error {
+	return insertTransaction(ctx, r.db, transaction)
+}
+
+// CreateCompensation сохраняет отмену или возврат по исходной операции. Исходная операция
+// блокируется до конца транзакции, поэтому параллельные возвраты не превысят ее сумму.
+func (r *PostgresAccountRepository) CreateCompensation(ctx context.Context, transaction *models.Transaction) error {
+	if transaction.OriginalTransactionID == nil {
+		return errors.New("original transaction is required")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO transactions (from_account_id, to_account_id, amount, type, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id`
+		SELECT t.amount - ` + refundedAmountColumn + ` >= $2
+		FROM transactions t
+		WHERE t.id = $1
+		FOR UPDATE`
 
-	return r.db.QueryRowContext(ctx, query,
+	var allowed bool
+	if err := tx.QueryRowContext(ctx, query, *transaction.OriginalTransactionID, transaction.Amount).Scan(&allowed); err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrCompensationExceedsAmount
+	}
+
+	if err := insertTransaction(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockBalance блокирует строку счета до конца транзакции БД tx и возвращает остаток счета
+// и лимит овердрафта
+func lockBalance(ctx context.Context, tx *sql.Tx, accountID int64) (float64, float64, error) {
+	query := `
+		SELECT balance, overdraft_limit
+		FROM accounts
+		WHERE id = $1
+		FOR UPDATE`
+
+	var balance, overdraftLimit float64
+	err := tx.QueryRowContext(ctx, query, accountID).Scan(&balance, &overdraftLimit)
+	return balance, overdraftLimit, err
+}
+
+// postTransaction переносит сумму операции между счетами и сохраняет операцию со статусом completed
+// в транзакции БД tx. Достаточность средств проверяет вызывающий.
+func postTransaction(ctx context.Context, tx *sql.Tx, transaction *models.Transaction) error {
+	query := `
+		UPDATE accounts
+		SET balance = balance + $1, updated_at = $2
+		WHERE id = $3`
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, query, -transaction.Amount, now, transaction.FromAccountID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, transaction.Amount, now, transaction.ToAccountID); err != nil {
+		return err
+	}
+
+	transaction.Status = "completed"
+	return insertTransaction(ctx, tx, transaction)
+}
+
+func insertTransaction(ctx context.Context, db execQuerier, transaction *models.Transaction) error {
+	query := `
+		INSERT INTO transactions (from_account_id, to_account_id, amount, type, status, mcc, merchant_name, operator_id,
+			original_transaction_id, reason, external_reference, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at`
+
+	err := db.QueryRowContext(ctx, query,
 		transaction.FromAccountID,
 		transaction.ToAccountID,
 		transaction.Amount,
 		transaction.Type,
 		transaction.Status,
+		transaction.MCC,
+		transaction.MerchantName,
+		transaction.OperatorID,
+		transaction.OriginalTransactionID,
+		transaction.Reason,
+		transaction.ExternalReference,
 		time.Now(),
 		time.Now(),
-	).Scan(&transaction.ID)
+	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		switch pqErr.Constraint {
+		case "idx_transactions_reversal":
+			return ErrTransactionReversed
+		case "idx_transactions_external_reference":
+			return ErrDuplicateExternalPayment
+		}
+	}
+
+	return err
 }
 
-func (r *PostgresAccountRepository) GetTransactions(ctx context.Context, accountID int64) ([]*models.Transaction, error) {
+// GetTransaction возвращает операцию вместе с суммой отмены и возвратов по ней
+func (r *PostgresAccountRepository) GetTransaction(ctx context.Context, id int64) (*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `, ` + refundedAmountColumn + `
+		FROM transactions t
+		WHERE t.id = $1`
+
+	var refunded float64
+	transaction, err := scanTransaction(r.db.QueryRowContext(ctx, query, id), &refunded)
+	if err != nil {
+		return nil, err
+	}
+	transaction.RefundedAmount = refunded
+
+	return transaction, nil
+}
+
+// CreateWithdrawal проводит списание со счета в одной транзакции БД с проверкой лимита и достаточности
+// средств. Строка счета блокируется, поэтому параллельные снятия с одного счета проверяются по очереди.
+// limit ограничивает сумму списаний операциями типов types начиная с момента from вместе с этой операцией.
+func (r *PostgresAccountRepository) CreateWithdrawal(ctx context.Context, transaction *models.Transaction, types []string, from time.Time, limit float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	balance, overdraftLimit, err := lockBalance(ctx, tx, transaction.FromAccountID)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		SELECT id, from_account_id, to_account_id, amount, type, status, created_at, updated_at
+		SELECT COALESCE(SUM(amount), 0)
 		FROM transactions
-		WHERE from_account_id = $1 OR to_account_id = $1
-		ORDER BY created_at DESC`
+		WHERE from_account_id = $1 AND type = ANY($2) AND status = 'completed' AND created_at >= $3`
+
+	var withdrawn float64
+	if err := tx.QueryRowContext(ctx, query, transaction.FromAccountID, pq.Array(types), from).Scan(&withdrawn); err != nil {
+		return err
+	}
+	if withdrawn+transaction.Amount > limit {
+		return ErrWithdrawalLimitExceeded
+	}
+	if balance+overdraftLimit < transaction.Amount {
+		return ErrInsufficientBalance
+	}
+
+	if err := postTransaction(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresAccountRepository) GetTransactions(ctx context.Context, accountID int64) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.from_account_id = $1 OR t.to_account_id = $1
+		ORDER BY t.created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, accountID)
 	if err != nil {
@@ -138,24 +405,195 @@ func (r *PostgresAccountRepository) GetTransactions(ctx context.Context, account
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		transaction := &models.Transaction{}
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.FromAccountID,
-			&transaction.ToAccountID,
-			&transaction.Amount,
-			&transaction.Type,
-			&transaction.Status,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, transaction)
 	}
 
-	return transactions, nil
+	return transactions, rows.Err()
+}
+
+// FindTransactions возвращает страницу транзакций по счетам из фильтра одним запросом.
+// Пагинация по ключу (поле сортировки, id): возвращается до Limit+1 строк, лишняя строка
+// означает наличие следующей страницы.
+func (r *PostgresAccountRepository) FindTransactions(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error) {
+	var conditions []string
+	args := []interface{}{pq.Array(filter.AccountIDs), filter.UserID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions = append(conditions, "(t.from_account_id = ANY($1) OR t.to_account_id = ANY($1))")
+	if filter.AccountID != 0 {
+		p := arg(filter.AccountID)
+		conditions = append(conditions, fmt.Sprintf("(t.from_account_id = %s OR t.to_account_id = %s)", p, p))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "t.created_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "t.created_at < "+arg(*filter.To))
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "t.amount >= "+arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "t.amount <= "+arg(*filter.MaxAmount))
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "t.type = "+arg(filter.Type))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "t.status = "+arg(filter.Status))
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "c.category = "+arg(filter.Category))
+	}
+	if filter.Counterparty != "" {
+		// Контрагент - вторая сторона операции относительно счетов пользователя
+		p := arg(filter.Counterparty)
+		conditions = append(conditions, fmt.Sprintf(`(
+			(t.from_account_id = ANY($1) AND t.to_account_id IN (SELECT id FROM accounts WHERE number = %s))
+			OR (t.to_account_id = ANY($1) AND t.from_account_id IN (SELECT id FROM accounts WHERE number = %s)))`, p, p))
+	}
+
+	sortColumn := "t.created_at"
+	if filter.Sort == models.TransactionSortAmount {
+		sortColumn = "t.amount"
+	}
+	direction, comparison := "DESC", "<"
+	if filter.Order == models.SortOrderAsc {
+		direction, comparison = "ASC", ">"
+	}
+
+	if filter.After != nil {
+		var value interface{} = filter.After.CreatedAt
+		if filter.Sort == models.TransactionSortAmount {
+			value = filter.After.Amount
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, t.id) %s (%s, %s)", sortColumn, comparison, arg(value), arg(filter.After.ID)))
+	}
+
+	query := `
+		SELECT ` + transactionColumns + `, COALESCE(c.category, ''), ` + refundedAmountColumn + `
+		FROM transactions t
+		LEFT JOIN transaction_categories c ON c.transaction_id = t.id AND c.user_id = $2
+		WHERE ` + strings.Join(conditions, "\n\t\t\tAND ") + `
+		ORDER BY ` + sortColumn + ` ` + direction + `, t.id ` + direction + `
+		LIMIT ` + arg(filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		var category string
+		var refunded float64
+		transaction, err := scanTransaction(rows, &category, &refunded)
+		if err != nil {
+			return nil, err
+		}
+		transaction.Category = category
+		transaction.RefundedAmount = refunded
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// GetTurnover возвращает суммы поступлений и списаний по проведенным операциям счета за период [from, to)
+func (r *PostgresAccountRepository) GetTurnover(ctx context.Context, accountID int64, from, to time.Time) (float64, float64, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE to_account_id = $1), 0),
+			COALESCE(SUM(amount) FILTER (WHERE from_account_id = $1), 0)
+		FROM transactions
+		WHERE (from_account_id = $1 OR to_account_id = $1)
+			AND created_at >= $2 AND created_at < $3
+			AND status = 'completed'`
+
+	var incoming, outgoing float64
+	err := r.db.QueryRowContext(ctx, query, accountID, from, to).Scan(&incoming, &outgoing)
+	return incoming, outgoing, err
+}
+
+// GetDailyNetTurnover возвращает сальдо проведенных операций по счету (поступления минус списания)
+// по дням (UTC), начиная с момента from
+func (r *PostgresAccountRepository) GetDailyNetTurnover(ctx context.Context, accountID int64, from time.Time) (map[time.Time]float64, error) {
+	query := `
+		SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+			SUM(CASE WHEN to_account_id = $1 THEN amount ELSE -amount END)
+		FROM transactions
+		WHERE (from_account_id = $1 OR to_account_id = $1)
+			AND created_at >= $2
+			AND status = 'completed'
+		GROUP BY day`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turnover := make(map[time.Time]float64)
+	for rows.Next() {
+		var day time.Time
+		var net float64
+		if err := rows.Scan(&day, &net); err != nil {
+			return nil, err
+		}
+		turnover[time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)] = net
+	}
+
+	return turnover, rows.Err()
+}
+
+// StreamStatementLines передает в fn проведенные операции по счету за период [from, to) в хронологическом
+// порядке по мере чтения из базы, не собирая их в памяти. Остаток после операции не заполняется.
+func (r *PostgresAccountRepository) StreamStatementLines(ctx context.Context, accountID int64, from, to time.Time, fn func(*models.StatementLine) error) error {
+	query := `
+		SELECT ` + transactionColumns + `,
+			COALESCE(a.number, ''), COALESCE(NULLIF(t.merchant_name, ''), u.username, '')
+		FROM transactions t
+		LEFT JOIN accounts a ON a.id = CASE WHEN t.from_account_id = $1 THEN t.to_account_id ELSE t.from_account_id END
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE (t.from_account_id = $1 OR t.to_account_id = $1)
+			AND t.created_at >= $2 AND t.created_at < $3
+			AND t.status = 'completed'
+		ORDER BY t.created_at, t.id`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		line := &models.StatementLine{}
+		transaction, err := scanTransaction(rows, &line.CounterpartyAccount, &line.CounterpartyName)
+		if err != nil {
+			return err
+		}
+		line.Transaction = transaction
+
+		if transaction.FromAccountID == accountID {
+			line.Outgoing = transaction.Amount
+		} else {
+			line.Incoming = transaction.Amount
+		}
+
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 func (r *PostgresAccountRepository) UpdateBalance(ctx context.Context, accountID int64, amount float64) error {
@@ -166,4 +604,15 @@ func (r *PostgresAccountRepository) UpdateBalance(ctx context.Context, accountID
 
 	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), accountID)
 	return err
-} 
\ No newline at end of file
+}
+
+// SetOverdraftLimit устанавливает лимит, до которого баланс счета может уходить в минус
+func (r *PostgresAccountRepository) SetOverdraftLimit(ctx context.Context, accountID int64, limit float64) error {
+	query := `
+		UPDATE accounts
+		SET overdraft_limit = $1, updated_at = $2
+		WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, limit, time.Now(), accountID)
+	return err
+}
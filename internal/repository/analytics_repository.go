@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+	"time"
+	"github.com/lib/pq"
+)
+
+// PostgresAnalyticsRepository выполняет агрегирующие запросы для аналитики, чтобы не загружать
+// все операции и графики платежей пользователя в память
+type PostgresAnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) AnalyticsRepository {
+	return &PostgresAnalyticsRepository{db: db}
+}
+
+// GetCategoryTotals возвращает суммы и количество проведенных операций пользователя за период [from, to),
+// сгруппированные по направлению и категории, по убыванию суммы внутри направления.
+// Учитываются только категоризированные операции.
+func (r *PostgresAnalyticsRepository) GetCategoryTotals(ctx context.Context, userID int64, from, to time.Time) ([]*models.CategoryTotal, error) {
+	query := `
+		SELECT c.direction, c.category, SUM(t.amount), COUNT(*)
+		FROM transaction_categories c
+		JOIN transactions t ON t.id = c.transaction_id
+		WHERE c.user_id = $1
+			AND t.created_at >= $2 AND t.created_at < $3
+			AND t.status = 'completed'
+		GROUP BY c.direction, c.category
+		ORDER BY c.direction, SUM(t.amount) DESC, c.category`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*models.CategoryTotal
+	for rows.Next() {
+		total := &models.CategoryTotal{}
+		if err := rows.Scan(&total.Direction, &total.Category, &total.Amount, &total.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+// GetPeriodTotals возвращает суммы и количество проведенных операций пользователя за период [from, to),
+// сгруппированные по периодам гранулярности granularity (month, week) в часовом поясе timezone,
+// направлению и категории
+func (r *PostgresAnalyticsRepository) GetPeriodTotals(ctx context.Context, userID int64, from, to time.Time, granularity, timezone string) ([]*models.PeriodTotal, error) {
+	query := `
+		SELECT date_trunc($4, t.created_at AT TIME ZONE $5) AS period,
+			c.direction, c.category, SUM(t.amount), COUNT(*)
+		FROM transaction_categories c
+		JOIN transactions t ON t.id = c.transaction_id
+		WHERE c.user_id = $1
+			AND t.created_at >= $2 AND t.created_at < $3
+			AND t.status = 'completed'
+		GROUP BY period, c.direction, c.category
+		ORDER BY period, c.direction, SUM(t.amount) DESC, c.category`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to, granularity, timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*models.PeriodTotal
+	for rows.Next() {
+		total := &models.PeriodTotal{}
+		if err := rows.Scan(&total.Period, &total.Direction, &total.Category, &total.Amount, &total.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+// GetCounterpartyHistory возвращает проведенные с момента from доходы и расходы пользователя
+// (без переводов между собственными счетами) в хронологическом порядке. Контрагент - торговая точка
+// или номер счета другой стороны операции.
+func (r *PostgresAnalyticsRepository) GetCounterpartyHistory(ctx context.Context, userID int64, from time.Time) ([]*models.CounterpartyTransaction, error) {
+	query := `
+		SELECT c.direction, c.category,
+			COALESCE(NULLIF(t.merchant_name, ''),
+				CASE WHEN c.direction = 'income' THEN fa.number ELSE ta.number END, ''),
+			t.amount, t.created_at
+		FROM transaction_categories c
+		JOIN transactions t ON t.id = c.transaction_id
+		LEFT JOIN accounts fa ON fa.id = t.from_account_id
+		LEFT JOIN accounts ta ON ta.id = t.to_account_id
+		WHERE c.user_id = $1
+			AND c.direction <> 'internal'
+			AND t.created_at >= $2
+			AND t.status = 'completed'
+		ORDER BY t.created_at, t.id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.CounterpartyTransaction
+	for rows.Next() {
+		transaction := &models.CounterpartyTransaction{}
+		if err := rows.Scan(&transaction.Direction, &transaction.Category, &transaction.Counterparty, &transaction.Amount, &transaction.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// GetSchedule возвращает строки действующих графиков платежей по активным кредитам пользователя
+// со сроком платежа в периоде [from, to)
+func (r *PostgresAnalyticsRepository) GetSchedule(ctx context.Context, userID int64, from, to time.Time) ([]*models.PaymentSchedule, error) {
+	query := `
+		SELECT ` + paymentScheduleColumns + `
+		FROM payment_schedules
+		WHERE (credit_id, version) IN (
+				SELECT id, schedule_version FROM credits WHERE user_id = $1 AND status = 'active'
+			)
+			AND due_date >= $2 AND due_date < $3
+		ORDER BY due_date, credit_id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedule []*models.PaymentSchedule
+	for rows.Next() {
+		payment, err := scanPaymentSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, payment)
+	}
+
+	return schedule, rows.Err()
+}
+
+// GetUnpaidPrincipal возвращает непогашенный основной долг по действующим графикам активных кредитов пользователя
+func (r *PostgresAnalyticsRepository) GetUnpaidPrincipal(ctx context.Context, userID int64) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(principal), 0)
+		FROM payment_schedules
+		WHERE (credit_id, version) IN (
+				SELECT id, schedule_version FROM credits WHERE user_id = $1 AND status = 'active'
+			)
+			AND status <> 'completed'`
+
+	var principal float64
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&principal)
+	return principal, err
+}
+
+// GetIncomeStats возвращает количество проведенных операций по счетам с момента from и сумму
+// поступлений на эти счета с чужих счетов. Зачисления с внутренних счетов банка (касса,
+// корреспондентский счет, отмены и возвраты с них) доходом не считаются.
+func (r *PostgresAnalyticsRepository) GetIncomeStats(ctx context.Context, accountIDs []int64, from time.Time) (int, float64, error) {
+	query := `
+		SELECT COUNT(*),
+			COALESCE(SUM(amount) FILTER (WHERE to_account_id = ANY($1) AND NOT from_account_id = ANY($1)
+				AND from_account_id NOT IN (SELECT account_id FROM system_accounts)), 0)
+		FROM transactions
+		WHERE (from_account_id = ANY($1) OR to_account_id = ANY($1))
+			AND status = 'completed' AND created_at >= $2`
+
+	var count int
+	var income float64
+	err := r.db.QueryRowContext(ctx, query, pq.Array(accountIDs), from).Scan(&count, &income)
+	return count, income, err
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+	"github.com/lib/pq"
+)
+
+var ErrBudgetExists = errors.New("budget for this category already exists")
+
+type PostgresBudgetRepository struct {
+	db *sql.DB
+}
+
+func NewBudgetRepository(db *sql.DB) BudgetRepository {
+	return &PostgresBudgetRepository{db: db}
+}
+
+const budgetColumns = `id, user_id, category, amount, created_at, updated_at`
+
+func scanBudget(row rowScanner) (*models.Budget, error) {
+	budget := &models.Budget{}
+
+	err := row.Scan(
+		&budget.ID,
+		&budget.UserID,
+		&budget.Category,
+		&budget.Amount,
+		&budget.CreatedAt,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (r *PostgresBudgetRepository) Create(ctx context.Context, budget *models.Budget) error {
+	query := `
+		INSERT INTO budgets (user_id, category, amount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		budget.UserID,
+		budget.Category,
+		budget.Amount,
+		time.Now(),
+		time.Now(),
+	).Scan(&budget.ID, &budget.CreatedAt, &budget.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return ErrBudgetExists
+	}
+
+	return err
+}
+
+func (r *PostgresBudgetRepository) GetByID(ctx context.Context, id int64) (*models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE id = $1`
+
+	return scanBudget(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresBudgetRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE user_id = $1
+		ORDER BY category`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []*models.Budget
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+// GetUserIDs возвращает пользователей, у которых есть бюджеты
+func (r *PostgresBudgetRepository) GetUserIDs(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT user_id FROM budgets ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+func (r *PostgresBudgetRepository) Update(ctx context.Context, budget *models.Budget) error {
+	query := `
+		UPDATE budgets
+		SET amount = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query, budget.Amount, time.Now(), budget.ID).Scan(&budget.UpdatedAt)
+}
+
+func (r *PostgresBudgetRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	return err
+}
+
+// SaveAlert сохраняет уведомление и возвращает false, если уведомление о достижении этого порога
+// в этом месяце уже было
+func (r *PostgresBudgetRepository) SaveAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error) {
+	query := `
+		INSERT INTO budget_alerts (budget_id, month, threshold, spent, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`
+
+	alert.CreatedAt = time.Now()
+	result, err := r.db.ExecContext(ctx, query, alert.BudgetID, alert.Month, alert.Threshold, alert.Spent, alert.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ResetAlerts удаляет уведомления по бюджету за месяц, чтобы пороги проверялись заново
+// (после изменения лимита)
+func (r *PostgresBudgetRepository) ResetAlerts(ctx context.Context, budgetID int64, month time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM budget_alerts WHERE budget_id = $1 AND month = $2`, budgetID, month)
+	return err
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+)
+
+type PostgresCalendarRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarRepository(db *sql.DB) CalendarRepository {
+	return &PostgresCalendarRepository{db: db}
+}
+
+func (r *PostgresCalendarRepository) GetCalendarDays(ctx context.Context) ([]*models.CalendarDay, error) {
+	query := `
+		SELECT date, is_working, description
+		FROM calendar_days
+		ORDER BY date`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*models.CalendarDay
+	for rows.Next() {
+		day := &models.CalendarDay{}
+		if err := rows.Scan(&day.Date, &day.IsWorking, &day.Description); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
@@ -3,8 +3,20 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 	"bank-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении ограничения уникальности
+const uniqueViolation = "23505"
+
+var (
+	ErrCardNumberExists        = errors.New("card number already exists")
+	ErrDailySpendingExceeded   = errors.New("daily spending limit exceeded")
+	ErrMonthlySpendingExceeded = errors.New("monthly spending limit exceeded")
+	ErrCardStatusChanged       = errors.New("card status changed")
 )
 
 type PostgresCardRepository struct {
@@ -15,32 +27,24 @@ func NewCardRepository(db *sql.DB) CardRepository {
 	return &PostgresCardRepository{db: db}
 }
 
-func (r *PostgresCardRepository) Create(ctx context.Context, card *models.Card) error {
-	query := `
-		INSERT INTO cards (account_id, number, encrypted_cvv, expiry_date, cardholder_name, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id`
+const cardColumns = `id, account_id, number, encrypted_cvv, expiry_date, cardholder_name, is_active,
+		status, status_reason, previous_card_id, reissue_reason, auto_reissue, type, merchant_account_id, valid_until,
+		payment_system, product_code, product_type, encrypted_pin_block, pin_try_count, created_at, updated_at`
 
-	return r.db.QueryRowContext(ctx, query,
-		card.AccountID,
-		card.Number,
-		card.EncryptedCVV,
-		card.ExpiryDate,
-		card.CardholderName,
-		card.IsActive,
-		time.Now(),
-		time.Now(),
-	).Scan(&card.ID)
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *PostgresCardRepository) GetByID(ctx context.Context, id int64) (*models.Card, error) {
+func scanCard(row rowScanner) (*models.Card, error) {
 	card := &models.Card{}
-	query := `
-		SELECT id, account_id, number, encrypted_cvv, expiry_date, cardholder_name, is_active, created_at, updated_at
-		FROM cards
-		WHERE id = $1`
+	var statusReason sql.NullString
+	var previousCardID sql.NullInt64
+	var reissueReason sql.NullString
+	var merchantAccountID sql.NullInt64
+	var validUntil sql.NullTime
+	var encryptedPINBlock sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&card.ID,
 		&card.AccountID,
 		&card.Number,
@@ -48,6 +52,19 @@ func (r *PostgresCardRepository) GetByID(ctx context.Context, id int64) (*models
 		&card.ExpiryDate,
 		&card.CardholderName,
 		&card.IsActive,
+		&card.Status,
+		&statusReason,
+		&previousCardID,
+		&reissueReason,
+		&card.AutoReissue,
+		&card.Type,
+		&merchantAccountID,
+		&validUntil,
+		&card.PaymentSystem,
+		&card.ProductCode,
+		&card.ProductType,
+		&encryptedPINBlock,
+		&card.PINTryCount,
 		&card.CreatedAt,
 		&card.UpdatedAt,
 	)
@@ -55,69 +72,455 @@ func (r *PostgresCardRepository) GetByID(ctx context.Context, id int64) (*models
 		return nil, err
 	}
 
+	card.StatusReason = statusReason.String
+	card.ReissueReason = reissueReason.String
+	card.EncryptedPINBlock = encryptedPINBlock.String
+	if previousCardID.Valid {
+		card.PreviousCardID = &previousCardID.Int64
+	}
+	if merchantAccountID.Valid {
+		card.MerchantAccountID = &merchantAccountID.Int64
+	}
+	if validUntil.Valid {
+		card.ValidUntil = &validUntil.Time
+	}
+
 	return card, nil
 }
 
+func (r *PostgresCardRepository) Create(ctx context.Context, card *models.Card) error {
+	return insertCard(ctx, r.db, card)
+}
+
+// Reissue сохраняет карту card, выпущенную взамен карты previous, переносит на нее лимиты и закрывает
+// карту previous с причиной перевыпуска в одной транзакции БД. Если карта previous уже не находится
+// в статусе previous.Status, возвращается ErrCardStatusChanged.
+func (r *PostgresCardRepository) Reissue(ctx context.Context, card, previous *models.Card) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE cards
+		SET is_active = false, status = $1, status_reason = $2, reissue_reason = $2, updated_at = $3
+		WHERE id = $4 AND status = $5`
+
+	result, err := tx.ExecContext(ctx, query, models.CardStatusClosed, card.ReissueReason, time.Now(), previous.ID, previous.Status)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCardStatusChanged
+	}
+
+	if err := insertCard(ctx, tx, card); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO card_limits (card_id, daily_limit, monthly_limit, per_transaction_limit,
+			online_enabled, contactless_enabled, atm_enabled, foreign_enabled, created_at, updated_at)
+		SELECT $1, daily_limit, monthly_limit, per_transaction_limit,
+			online_enabled, contactless_enabled, atm_enabled, foreign_enabled, $2, $2
+		FROM card_limits
+		WHERE card_id = $3`
+
+	if _, err := tx.ExecContext(ctx, query, card.ID, time.Now(), previous.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertCard(ctx context.Context, db execQuerier, card *models.Card) error {
+	query := `
+		INSERT INTO cards (account_id, number, encrypted_cvv, expiry_date, cardholder_name, is_active,
+			status, status_reason, previous_card_id, reissue_reason, auto_reissue, type, merchant_account_id, valid_until,
+			payment_system, product_code, product_type, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING id, created_at`
+
+	err := db.QueryRowContext(ctx, query,
+		card.AccountID,
+		card.Number,
+		card.EncryptedCVV,
+		card.ExpiryDate,
+		card.CardholderName,
+		card.IsActive,
+		card.Status,
+		card.StatusReason,
+		card.PreviousCardID,
+		card.ReissueReason,
+		card.AutoReissue,
+		card.Type,
+		card.MerchantAccountID,
+		card.ValidUntil,
+		card.PaymentSystem,
+		card.ProductCode,
+		card.ProductType,
+		time.Now(),
+		time.Now(),
+	).Scan(&card.ID, &card.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation && pqErr.Constraint == "idx_cards_number" {
+		return ErrCardNumberExists
+	}
+
+	return err
+}
+
+func (r *PostgresCardRepository) GetByID(ctx context.Context, id int64) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE id = $1`
+
+	return scanCard(r.db.QueryRowContext(ctx, query, id))
+}
+
 func (r *PostgresCardRepository) GetByAccountID(ctx context.Context, accountID int64) ([]*models.Card, error) {
 	query := `
-		SELECT id, account_id, number, encrypted_cvv, expiry_date, cardholder_name, is_active, created_at, updated_at
+		SELECT ` + cardColumns + `
 		FROM cards
 		WHERE account_id = $1`
 
-	rows, err := r.db.QueryContext(ctx, query, accountID)
+	return r.queryCards(ctx, query, accountID)
+}
+
+func (r *PostgresCardRepository) Update(ctx context.Context, card *models.Card) error {
+	query := `
+		UPDATE cards
+		SET is_active = $1, status = $2, status_reason = NULLIF($3, ''), reissue_reason = NULLIF($4, ''),
+			auto_reissue = $5, updated_at = $6
+		WHERE id = $7`
+
+	_, err := r.db.ExecContext(ctx, query,
+		card.Status == models.CardStatusActive,
+		card.Status,
+		card.StatusReason,
+		card.ReissueReason,
+		card.AutoReissue,
+		time.Now(),
+		card.ID,
+	)
+	return err
+}
+
+func (r *PostgresCardRepository) Delete(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM cards
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *PostgresCardRepository) Deactivate(ctx context.Context, id int64) error {
+	query := `
+		UPDATE cards
+		SET is_active = false, status = $1, status_reason = $2, updated_at = $3
+		WHERE id = $4`
+
+	_, err := r.db.ExecContext(ctx, query, models.CardStatusClosed, models.CardReasonCustomerRequest, time.Now(), id)
+	return err
+}
+
+// TransitionStatus атомарно переводит карту из статуса from в статус to с причиной reason
+// и возвращает false, если карта уже не находится в статусе from
+func (r *PostgresCardRepository) TransitionStatus(ctx context.Context, id int64, from, to, reason string) (bool, error) {
+	query := `
+		UPDATE cards
+		SET is_active = $1, status = $2, status_reason = NULLIF($3, ''), updated_at = $4
+		WHERE id = $5 AND status = $6`
+
+	result, err := r.db.ExecContext(ctx, query, to == models.CardStatusActive, to, reason, time.Now(), id, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *PostgresCardRepository) GetByNumber(ctx context.Context, number string) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE number = $1`
+
+	return scanCard(r.db.QueryRowContext(ctx, query, number))
+}
+
+// UpdatePIN сохраняет зашифрованный PIN-блок и сбрасывает счетчик неверных попыток
+func (r *PostgresCardRepository) UpdatePIN(ctx context.Context, cardID int64, encryptedPINBlock string) error {
+	query := `
+		UPDATE cards
+		SET encrypted_pin_block = $1, pin_try_count = 0, updated_at = $2
+		WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, encryptedPINBlock, time.Now(), cardID)
+	return err
+}
+
+// IncrementPINTries увеличивает счетчик неверных попыток ввода PIN и возвращает его новое значение
+func (r *PostgresCardRepository) IncrementPINTries(ctx context.Context, cardID int64) (int, error) {
+	query := `
+		UPDATE cards
+		SET pin_try_count = pin_try_count + 1, updated_at = $1
+		WHERE id = $2
+		RETURNING pin_try_count`
+
+	var tries int
+	err := r.db.QueryRowContext(ctx, query, time.Now(), cardID).Scan(&tries)
+	return tries, err
+}
+
+func (r *PostgresCardRepository) ResetPINTries(ctx context.Context, cardID int64) error {
+	query := `
+		UPDATE cards
+		SET pin_try_count = 0, updated_at = $1
+		WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now(), cardID)
+	return err
+}
+
+// GetExpired возвращает еще не закрытые карты, срок действия которых истек к моменту at
+func (r *PostgresCardRepository) GetExpired(ctx context.Context, at time.Time) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE status IN ('active', 'blocked')
+			AND to_date(expiry_date, 'MM/YY') + INTERVAL '1 month' <= $1`
+
+	return r.queryCards(ctx, query, at)
+}
+
+// GetExpiredSingleUse возвращает незакрытые одноразовые карты, время жизни которых истекло к моменту at
+func (r *PostgresCardRepository) GetExpiredSingleUse(ctx context.Context, at time.Time) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE type = 'single_use'
+			AND status IN ('active', 'blocked')
+			AND valid_until <= $1`
+
+	return r.queryCards(ctx, query, at)
+}
+
+// GetBINs возвращает активные BIN-диапазоны продукта, а при пустом коде - диапазоны продукта по умолчанию
+func (r *PostgresCardRepository) GetBINs(ctx context.Context, productCode string) ([]*models.CardBIN, error) {
+	query := `
+		SELECT id, bin, payment_system, product_code, product_name, product_type, is_default, is_active
+		FROM card_bins
+		WHERE is_active = true
+			AND (product_code = $1 OR ($1 = '' AND is_default = true))
+		ORDER BY bin`
+
+	rows, err := r.db.QueryContext(ctx, query, productCode)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var cards []*models.Card
+	var bins []*models.CardBIN
 	for rows.Next() {
-		card := &models.Card{}
+		bin := &models.CardBIN{}
 		err := rows.Scan(
-			&card.ID,
-			&card.AccountID,
-			&card.Number,
-			&card.EncryptedCVV,
-			&card.ExpiryDate,
-			&card.CardholderName,
-			&card.IsActive,
-			&card.CreatedAt,
-			&card.UpdatedAt,
+			&bin.ID,
+			&bin.BIN,
+			&bin.PaymentSystem,
+			&bin.ProductCode,
+			&bin.ProductName,
+			&bin.ProductType,
+			&bin.IsDefault,
+			&bin.IsActive,
 		)
 		if err != nil {
 			return nil, err
 		}
+		bins = append(bins, bin)
+	}
+
+	return bins, rows.Err()
+}
+
+func (r *PostgresCardRepository) queryCards(ctx context.Context, query string, args ...interface{}) ([]*models.Card, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*models.Card
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
 		cards = append(cards, card)
 	}
 
-	return cards, nil
+	return cards, rows.Err()
 }
 
-func (r *PostgresCardRepository) Update(ctx context.Context, card *models.Card) error {
+func (r *PostgresCardRepository) GetLimits(ctx context.Context, cardID int64) (*models.CardLimits, error) {
+	limits := &models.CardLimits{}
 	query := `
-		UPDATE cards
-		SET is_active = $1, updated_at = $2
-		WHERE id = $3`
+		SELECT card_id, daily_limit, monthly_limit, per_transaction_limit,
+			online_enabled, contactless_enabled, atm_enabled, foreign_enabled, updated_at
+		FROM card_limits
+		WHERE card_id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, card.IsActive, time.Now(), card.ID)
-	return err
+	err := r.db.QueryRowContext(ctx, query, cardID).Scan(
+		&limits.CardID,
+		&limits.DailyLimit,
+		&limits.MonthlyLimit,
+		&limits.PerTransactionLimit,
+		&limits.OnlineEnabled,
+		&limits.ContactlessEnabled,
+		&limits.ATMEnabled,
+		&limits.ForeignEnabled,
+		&limits.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return limits, nil
 }
 
-func (r *PostgresCardRepository) Delete(ctx context.Context, id int64) error {
+func (r *PostgresCardRepository) UpsertLimits(ctx context.Context, limits *models.CardLimits) error {
 	query := `
-		DELETE FROM cards
-		WHERE id = $1`
+		INSERT INTO card_limits (card_id, daily_limit, monthly_limit, per_transaction_limit,
+			online_enabled, contactless_enabled, atm_enabled, foreign_enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (card_id) DO UPDATE
+		SET daily_limit = EXCLUDED.daily_limit,
+			monthly_limit = EXCLUDED.monthly_limit,
+			per_transaction_limit = EXCLUDED.per_transaction_limit,
+			online_enabled = EXCLUDED.online_enabled,
+			contactless_enabled = EXCLUDED.contactless_enabled,
+			atm_enabled = EXCLUDED.atm_enabled,
+			foreign_enabled = EXCLUDED.foreign_enabled,
+			updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`
 
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+	return r.db.QueryRowContext(ctx, query,
+		limits.CardID,
+		limits.DailyLimit,
+		limits.MonthlyLimit,
+		limits.PerTransactionLimit,
+		limits.OnlineEnabled,
+		limits.ContactlessEnabled,
+		limits.ATMEnabled,
+		limits.ForeignEnabled,
+		time.Now(),
+	).Scan(&limits.UpdatedAt)
 }
 
-func (r *PostgresCardRepository) Deactivate(ctx context.Context, id int64) error {
+// GetSpending возвращает сумму расходов по карте за день и за месяц, в которые попадает момент at
+func (r *PostgresCardRepository) GetSpending(ctx context.Context, cardID int64, at time.Time) (float64, float64, error) {
 	query := `
-		UPDATE cards
-		SET is_active = false, updated_at = $1
-		WHERE id = $2`
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE period = 'daily' AND period_start = $2), 0),
+			COALESCE(SUM(amount) FILTER (WHERE period = 'monthly' AND period_start = $3), 0)
+		FROM card_spend_counters
+		WHERE card_id = $1`
+
+	var daily, monthly float64
+	err := r.db.QueryRowContext(ctx, query, cardID, dayStart(at), monthStart(at)).Scan(&daily, &monthly)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return daily, monthly, nil
+}
+
+// ReserveSpending увеличивает дневной и месячный счетчики расходов по карте, только если после этого
+// они не превысят лимиты (0 - без ограничения). Проверка и увеличение выполняются одним UPDATE под
+// блокировкой строк счетчиков, поэтому параллельные авторизации не могут вместе превысить лимит.
+func (r *PostgresCardRepository) ReserveSpending(ctx context.Context, cardID int64, amount, dailyLimit, monthlyLimit float64, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO card_spend_counters (card_id, period, period_start, amount, updated_at)
+		VALUES ($1, 'daily', $2, 0, $4), ($1, 'monthly', $3, 0, $4)
+		ON CONFLICT (card_id, period, period_start) DO NOTHING`,
+		cardID, dayStart(at), monthStart(at), now)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE card_spend_counters
+		SET amount = amount + $4, updated_at = $7
+		WHERE card_id = $1 AND (
+			(period = 'daily' AND period_start = $2 AND ($5::numeric = 0 OR amount + $4 <= $5::numeric))
+			OR (period = 'monthly' AND period_start = $3 AND ($6::numeric = 0 OR amount + $4 <= $6::numeric)))
+		RETURNING period`
+
+	rows, err := tx.QueryContext(ctx, query, cardID, dayStart(at), monthStart(at), amount, dailyLimit, monthlyLimit, now)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	updated := make(map[string]bool, 2)
+	for rows.Next() {
+		var period string
+		if err := rows.Scan(&period); err != nil {
+			return err
+		}
+		updated[period] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	switch {
+	case !updated["daily"]:
+		return ErrDailySpendingExceeded
+	case !updated["monthly"]:
+		return ErrMonthlySpendingExceeded
+	}
+
+	return tx.Commit()
+}
+
+// AddSpending изменяет дневной и месячный счетчики расходов по карте без проверки лимитов.
+// Отрицательная сумма освобождает резерв, сделанный ReserveSpending.
+func (r *PostgresCardRepository) AddSpending(ctx context.Context, cardID int64, amount float64, at time.Time) error {
+	query := `
+		INSERT INTO card_spend_counters (card_id, period, period_start, amount, updated_at)
+		VALUES ($1, 'daily', $2, $4, $5), ($1, 'monthly', $3, $4, $5)
+		ON CONFLICT (card_id, period, period_start) DO UPDATE
+		SET amount = card_spend_counters.amount + EXCLUDED.amount, updated_at = EXCLUDED.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query, cardID, dayStart(at), monthStart(at), amount, time.Now())
 	return err
-} 
\ No newline at end of file
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
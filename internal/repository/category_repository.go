@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+	"time"
+	"github.com/lib/pq"
+)
+
+type PostgresCategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) CategoryRepository {
+	return &PostgresCategoryRepository{db: db}
+}
+
+const categoryRuleColumns = `id, user_id, category, counterparty, mcc, merchant, created_at, updated_at`
+
+func scanCategoryRule(row rowScanner) (*models.CategoryRule, error) {
+	rule := &models.CategoryRule{}
+	var userID sql.NullInt64
+
+	err := row.Scan(
+		&rule.ID,
+		&userID,
+		&rule.Category,
+		&rule.Counterparty,
+		&rule.MCC,
+		&rule.Merchant,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		rule.UserID = &userID.Int64
+	}
+
+	return rule, nil
+}
+
+func (r *PostgresCategoryRepository) CreateRule(ctx context.Context, rule *models.CategoryRule) error {
+	query := `
+		INSERT INTO category_rules (user_id, category, counterparty, mcc, merchant, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		rule.UserID,
+		rule.Category,
+		rule.Counterparty,
+		rule.MCC,
+		rule.Merchant,
+		time.Now(),
+		time.Now(),
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+// GetRules возвращает правила пользователя или, если userID равен nil, правила банка
+// в порядке создания
+func (r *PostgresCategoryRepository) GetRules(ctx context.Context, userID *int64) ([]*models.CategoryRule, error) {
+	query := `
+		SELECT ` + categoryRuleColumns + `
+		FROM category_rules
+		WHERE user_id IS NOT DISTINCT FROM $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.CategoryRule
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteRule удаляет правило пользователя или банка и возвращает false, если правило не найдено
+func (r *PostgresCategoryRepository) DeleteRule(ctx context.Context, id int64, userID *int64) (bool, error) {
+	query := `
+		DELETE FROM category_rules
+		WHERE id = $1 AND user_id IS NOT DISTINCT FROM $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetUncategorized возвращает до limit операций по счетам пользователя, у которых еще нет
+// категории для этого пользователя
+func (r *PostgresCategoryRepository) GetUncategorized(ctx context.Context, userID int64, accountIDs []int64, limit int) ([]*models.UncategorizedTransaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `, COALESCE(fa.number, ''), COALESCE(ta.number, '')
+		FROM transactions t
+		LEFT JOIN accounts fa ON fa.id = t.from_account_id
+		LEFT JOIN accounts ta ON ta.id = t.to_account_id
+		WHERE (t.from_account_id = ANY($1) OR t.to_account_id = ANY($1))
+			AND NOT EXISTS (
+				SELECT 1 FROM transaction_categories c
+				WHERE c.transaction_id = t.id AND c.user_id = $2
+			)
+		ORDER BY t.id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(accountIDs), userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.UncategorizedTransaction
+	for rows.Next() {
+		item := &models.UncategorizedTransaction{}
+		item.Transaction, err = scanTransaction(rows, &item.FromAccount, &item.ToAccount)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveCategories сохраняет категории операций. Категории, измененные пользователем вручную, не перезаписываются.
+func (r *PostgresCategoryRepository) SaveCategories(ctx context.Context, categories []*models.TransactionCategory) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO transaction_categories (transaction_id, user_id, direction, category, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (transaction_id, user_id) DO UPDATE
+		SET direction = EXCLUDED.direction, category = EXCLUDED.category, source = EXCLUDED.source
+		WHERE transaction_categories.source <> $8`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, category := range categories {
+		_, err := stmt.ExecContext(ctx,
+			category.TransactionID,
+			category.UserID,
+			category.Direction,
+			category.Category,
+			category.Source,
+			now,
+			now,
+			models.CategorySourceManual,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetCategory изменяет категорию операции пользователя. Возвращает nil, если у пользователя нет такой операции.
+func (r *PostgresCategoryRepository) SetCategory(ctx context.Context, transactionID, userID int64, category, source string) (*models.TransactionCategory, error) {
+	query := `
+		UPDATE transaction_categories
+		SET category = $1, source = $2, updated_at = $3
+		WHERE transaction_id = $4 AND user_id = $5
+		RETURNING transaction_id, user_id, direction, category, source, updated_at`
+
+	result := &models.TransactionCategory{}
+	err := r.db.QueryRowContext(ctx, query, category, source, time.Now(), transactionID, userID).Scan(
+		&result.TransactionID,
+		&result.UserID,
+		&result.Direction,
+		&result.Category,
+		&result.Source,
+		&result.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ResetAutomatic удаляет автоматически назначенные категории пользователя (или всех пользователей,
+// если userID равен nil), чтобы они были назначены заново по измененным правилам
+func (r *PostgresCategoryRepository) ResetAutomatic(ctx context.Context, userID *int64) error {
+	query := `
+		DELETE FROM transaction_categories
+		WHERE source <> $1 AND ($2::BIGINT IS NULL OR user_id = $2)`
+
+	_, err := r.db.ExecContext(ctx, query, models.CategorySourceManual, userID)
+	return err
+}
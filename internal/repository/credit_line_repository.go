@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+	"time"
+)
+
+type PostgresCreditLineRepository struct {
+	db *sql.DB
+}
+
+func NewCreditLineRepository(db *sql.DB) CreditLineRepository {
+	return &PostgresCreditLineRepository{db: db}
+}
+
+const creditLineColumns = `id, user_id, account_id, credit_limit, interest_rate, grace_days, min_payment_percent,
+		statement_day, status, accrued_interest, grace_interest, grace_start, last_accrual_date, last_statement_date,
+		created_at, updated_at`
+
+func scanCreditLine(row rowScanner) (*models.CreditLine, error) {
+	line := &models.CreditLine{}
+	var graceStart, lastAccrualDate, lastStatementDate sql.NullTime
+
+	err := row.Scan(
+		&line.ID,
+		&line.UserID,
+		&line.AccountID,
+		&line.CreditLimit,
+		&line.InterestRate,
+		&line.GraceDays,
+		&line.MinPaymentPercent,
+		&line.StatementDay,
+		&line.Status,
+		&line.AccruedInterest,
+		&line.GraceInterest,
+		&graceStart,
+		&lastAccrualDate,
+		&lastStatementDate,
+		&line.CreatedAt,
+		&line.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if graceStart.Valid {
+		line.GraceStart = &graceStart.Time
+	}
+	if lastAccrualDate.Valid {
+		line.LastAccrualDate = &lastAccrualDate.Time
+	}
+	if lastStatementDate.Valid {
+		line.LastStatementDate = &lastStatementDate.Time
+	}
+
+	return line, nil
+}
+
+func (r *PostgresCreditLineRepository) Create(ctx context.Context, line *models.CreditLine) error {
+	query := `
+		INSERT INTO credit_lines (user_id, account_id, credit_limit, interest_rate, grace_days, min_payment_percent,
+			statement_day, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		line.UserID,
+		line.AccountID,
+		line.CreditLimit,
+		line.InterestRate,
+		line.GraceDays,
+		line.MinPaymentPercent,
+		line.StatementDay,
+		line.Status,
+		time.Now(),
+		time.Now(),
+	).Scan(&line.ID, &line.CreatedAt, &line.UpdatedAt)
+}
+
+func (r *PostgresCreditLineRepository) GetByID(ctx context.Context, id int64) (*models.CreditLine, error) {
+	query := `
+		SELECT ` + creditLineColumns + `
+		FROM credit_lines
+		WHERE id = $1`
+
+	return scanCreditLine(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetActiveByAccountID возвращает действующую кредитную линию счета или nil, если ее нет
+func (r *PostgresCreditLineRepository) GetActiveByAccountID(ctx context.Context, accountID int64) (*models.CreditLine, error) {
+	query := `
+		SELECT ` + creditLineColumns + `
+		FROM credit_lines
+		WHERE account_id = $1 AND status = $2`
+
+	line, err := scanCreditLine(r.db.QueryRowContext(ctx, query, accountID, models.CreditLineStatusActive))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return line, err
+}
+
+func (r *PostgresCreditLineRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.CreditLine, error) {
+	query := `
+		SELECT ` + creditLineColumns + `
+		FROM credit_lines
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	return r.queryCreditLines(ctx, query, userID)
+}
+
+func (r *PostgresCreditLineRepository) GetActive(ctx context.Context) ([]*models.CreditLine, error) {
+	query := `
+		SELECT ` + creditLineColumns + `
+		FROM credit_lines
+		WHERE status = $1
+		ORDER BY id`
+
+	return r.queryCreditLines(ctx, query, models.CreditLineStatusActive)
+}
+
+func (r *PostgresCreditLineRepository) Update(ctx context.Context, line *models.CreditLine) error {
+	return updateCreditLine(ctx, r.db, line)
+}
+
+// SaveStatement сохраняет выписку вместе с состоянием кредитной линии и операцией списания процентов
+// в одной транзакции БД, чтобы проценты не были списаны повторно. transaction может быть nil.
+func (r *PostgresCreditLineRepository) SaveStatement(ctx context.Context, line *models.CreditLine, statement *models.CreditLineStatement, transaction *models.Transaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertStatement(ctx, tx, statement); err != nil {
+		return err
+	}
+	if err := updateCreditLine(ctx, tx, line); err != nil {
+		return err
+	}
+	if transaction != nil {
+		if err := postTransaction(ctx, tx, transaction); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func updateCreditLine(ctx context.Context, db execQuerier, line *models.CreditLine) error {
+	query := `
+		UPDATE credit_lines
+		SET status = $1, accrued_interest = $2, grace_interest = $3, grace_start = $4, last_accrual_date = $5,
+			last_statement_date = $6, updated_at = $7
+		WHERE id = $8`
+
+	_, err := db.ExecContext(ctx, query,
+		line.Status,
+		line.AccruedInterest,
+		line.GraceInterest,
+		line.GraceStart,
+		line.LastAccrualDate,
+		line.LastStatementDate,
+		time.Now(),
+		line.ID,
+	)
+	return err
+}
+
+const statementColumns = `id, credit_line_id, period_start, period_end, opening_debt, closing_debt, interest_charged,
+		min_payment, due_date, status, created_at, updated_at`
+
+func scanStatement(row rowScanner) (*models.CreditLineStatement, error) {
+	statement := &models.CreditLineStatement{}
+	err := row.Scan(
+		&statement.ID,
+		&statement.CreditLineID,
+		&statement.PeriodStart,
+		&statement.PeriodEnd,
+		&statement.OpeningDebt,
+		&statement.ClosingDebt,
+		&statement.InterestCharged,
+		&statement.MinPayment,
+		&statement.DueDate,
+		&statement.Status,
+		&statement.CreatedAt,
+		&statement.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+func (r *PostgresCreditLineRepository) CreateStatement(ctx context.Context, statement *models.CreditLineStatement) error {
+	return insertStatement(ctx, r.db, statement)
+}
+
+func insertStatement(ctx context.Context, db execQuerier, statement *models.CreditLineStatement) error {
+	query := `
+		INSERT INTO credit_line_statements (credit_line_id, period_start, period_end, opening_debt, closing_debt,
+			interest_charged, min_payment, due_date, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at`
+
+	return db.QueryRowContext(ctx, query,
+		statement.CreditLineID,
+		statement.PeriodStart,
+		statement.PeriodEnd,
+		statement.OpeningDebt,
+		statement.ClosingDebt,
+		statement.InterestCharged,
+		statement.MinPayment,
+		statement.DueDate,
+		statement.Status,
+		time.Now(),
+		time.Now(),
+	).Scan(&statement.ID, &statement.CreatedAt, &statement.UpdatedAt)
+}
+
+func (r *PostgresCreditLineRepository) GetStatements(ctx context.Context, creditLineID int64) ([]*models.CreditLineStatement, error) {
+	query := `
+		SELECT ` + statementColumns + `
+		FROM credit_line_statements
+		WHERE credit_line_id = $1
+		ORDER BY period_end DESC`
+
+	return r.queryStatements(ctx, query, creditLineID)
+}
+
+// GetOpenStatements возвращает выписки, по которым еще не внесен минимальный платеж
+func (r *PostgresCreditLineRepository) GetOpenStatements(ctx context.Context, creditLineID int64) ([]*models.CreditLineStatement, error) {
+	query := `
+		SELECT ` + statementColumns + `
+		FROM credit_line_statements
+		WHERE credit_line_id = $1 AND status = $2
+		ORDER BY period_end`
+
+	return r.queryStatements(ctx, query, creditLineID, models.StatementStatusOpen)
+}
+
+func (r *PostgresCreditLineRepository) UpdateStatementStatus(ctx context.Context, id int64, status string) error {
+	query := `
+		UPDATE credit_line_statements
+		SET status = $1, updated_at = $2
+		WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	return err
+}
+
+func (r *PostgresCreditLineRepository) queryCreditLines(ctx context.Context, query string, args ...interface{}) ([]*models.CreditLine, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []*models.CreditLine
+	for rows.Next() {
+		line, err := scanCreditLine(rows)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r *PostgresCreditLineRepository) queryStatements(ctx context.Context, query string, args ...interface{}) ([]*models.CreditLineStatement, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []*models.CreditLineStatement
+	for rows.Next() {
+		statement, err := scanStatement(rows)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+
+	return statements, rows.Err()
+}
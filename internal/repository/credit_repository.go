@@ -5,6 +5,7 @@ import (
 	"bank-api/internal/models"
 	"database/sql"
 	"time"
+	"github.com/lib/pq"
 )
 
 type PostgresCreditRepository struct {
@@ -15,11 +16,42 @@ func NewCreditRepository(db *sql.DB) CreditRepository {
 	return &PostgresCreditRepository{db: db}
 }
 
+const creditColumns = `id, user_id, account_id, amount, interest_rate, term_months, status, repayment_type,
+		day_count, psk, psk_amount, issue_fee, insurance_premium, schedule_version, created_at, updated_at`
+
+func scanCredit(row rowScanner) (*models.Credit, error) {
+	credit := &models.Credit{}
+	err := row.Scan(
+		&credit.ID,
+		&credit.UserID,
+		&credit.AccountID,
+		&credit.Amount,
+		&credit.InterestRate,
+		&credit.TermMonths,
+		&credit.Status,
+		&credit.RepaymentType,
+		&credit.DayCount,
+		&credit.PSK,
+		&credit.PSKAmount,
+		&credit.IssueFee,
+		&credit.InsurancePremium,
+		&credit.ScheduleVersion,
+		&credit.CreatedAt,
+		&credit.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return credit, nil
+}
+
 func (r *PostgresCreditRepository) Create(ctx context.Context, credit *models.Credit) error {
 	query := `
-		INSERT INTO credits (user_id, account_id, amount, interest_rate, term_months, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id`
+		INSERT INTO credits (user_id, account_id, amount, interest_rate, term_months, status, repayment_type,
+			day_count, psk, psk_amount, issue_fee, insurance_premium, schedule_version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRowContext(ctx, query,
 		credit.UserID,
@@ -28,39 +60,30 @@ func (r *PostgresCreditRepository) Create(ctx context.Context, credit *models.Cr
 		credit.InterestRate,
 		credit.TermMonths,
 		credit.Status,
+		credit.RepaymentType,
+		credit.DayCount,
+		credit.PSK,
+		credit.PSKAmount,
+		credit.IssueFee,
+		credit.InsurancePremium,
+		credit.ScheduleVersion,
 		time.Now(),
 		time.Now(),
-	).Scan(&credit.ID)
+	).Scan(&credit.ID, &credit.CreatedAt, &credit.UpdatedAt)
 }
 
 func (r *PostgresCreditRepository) GetByID(ctx context.Context, id int64) (*models.Credit, error) {
-	credit := &models.Credit{}
 	query := `
-		SELECT id, user_id, account_id, amount, interest_rate, term_months, status, created_at, updated_at
+		SELECT ` + creditColumns + `
 		FROM credits
 		WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&credit.ID,
-		&credit.UserID,
-		&credit.AccountID,
-		&credit.Amount,
-		&credit.InterestRate,
-		&credit.TermMonths,
-		&credit.Status,
-		&credit.CreatedAt,
-		&credit.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return credit, nil
+	return scanCredit(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *PostgresCreditRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.Credit, error) {
 	query := `
-		SELECT id, user_id, account_id, amount, interest_rate, term_months, status, created_at, updated_at
+		SELECT ` + creditColumns + `
 		FROM credits
 		WHERE user_id = $1`
 
@@ -72,18 +95,7 @@ func (r *PostgresCreditRepository) GetByUserID(ctx context.Context, userID int64
 
 	var credits []*models.Credit
 	for rows.Next() {
-		credit := &models.Credit{}
-		err := rows.Scan(
-			&credit.ID,
-			&credit.UserID,
-			&credit.AccountID,
-			&credit.Amount,
-			&credit.InterestRate,
-			&credit.TermMonths,
-			&credit.Status,
-			&credit.CreatedAt,
-			&credit.UpdatedAt,
-		)
+		credit, err := scanCredit(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -93,6 +105,32 @@ func (r *PostgresCreditRepository) GetByUserID(ctx context.Context, userID int64
 	return credits, nil
 }
 
+// GetActive возвращает все действующие кредиты
+func (r *PostgresCreditRepository) GetActive(ctx context.Context) ([]*models.Credit, error) {
+	query := `
+		SELECT ` + creditColumns + `
+		FROM credits
+		WHERE status = 'active'
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credits []*models.Credit
+	for rows.Next() {
+		credit, err := scanCredit(rows)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, credit)
+	}
+
+	return credits, rows.Err()
+}
+
 func (r *PostgresCreditRepository) Update(ctx context.Context, credit *models.Credit) error {
 	query := `
 		UPDATE credits
@@ -113,12 +151,529 @@ func (r *PostgresCreditRepository) Delete(ctx context.Context, id int64) error {
 }
 
 func (r *PostgresCreditRepository) CreatePaymentSchedule(ctx context.Context, schedule *models.PaymentSchedule) error {
+	return insertPaymentSchedule(ctx, r.db, schedule)
+}
+
+const paymentScheduleColumns = `id, credit_id, payment_number, amount, principal, interest, due_date, status, version,
+		created_at, updated_at`
+
+func scanPaymentSchedule(row rowScanner) (*models.PaymentSchedule, error) {
+	schedule := &models.PaymentSchedule{}
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.CreditID,
+		&schedule.PaymentNumber,
+		&schedule.Amount,
+		&schedule.Principal,
+		&schedule.Interest,
+		&schedule.DueDate,
+		&schedule.Status,
+		&schedule.Version,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetPaymentSchedule возвращает действующую версию графика платежей
+func (r *PostgresCreditRepository) GetPaymentSchedule(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error) {
 	query := `
-		INSERT INTO payment_schedules (credit_id, payment_number, amount, principal, interest, due_date, status, created_at, updated_at)
+		SELECT ` + paymentScheduleColumns + `
+		FROM payment_schedules
+		WHERE credit_id = $1
+			AND version = (SELECT schedule_version FROM credits WHERE id = $1)
+		ORDER BY payment_number`
+
+	return r.queryPaymentSchedules(ctx, query, creditID)
+}
+
+// GetPaymentScheduleHistory возвращает строки всех версий графика платежей
+func (r *PostgresCreditRepository) GetPaymentScheduleHistory(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error) {
+	query := `
+		SELECT ` + paymentScheduleColumns + `
+		FROM payment_schedules
+		WHERE credit_id = $1
+		ORDER BY version, payment_number`
+
+	return r.queryPaymentSchedules(ctx, query, creditID)
+}
+
+// CreatePrepayment сохраняет досрочное погашение вместе с новой версией графика платежей
+// в одной транзакции
+func (r *PostgresCreditRepository) CreatePrepayment(ctx context.Context, prepayment *models.CreditPrepayment, credit *models.Credit, schedule []*models.PaymentSchedule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertScheduleVersion(ctx, tx, credit, schedule); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO credit_prepayments (credit_id, mode, amount, principal, interest, previous_version,
+			schedule_version, transaction_id, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id`
+		RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		prepayment.CreditID,
+		prepayment.Mode,
+		prepayment.Amount,
+		prepayment.Principal,
+		prepayment.Interest,
+		prepayment.PreviousVersion,
+		prepayment.ScheduleVersion,
+		prepayment.TransactionID,
+		time.Now(),
+	).Scan(&prepayment.ID, &prepayment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertScheduleVersion добавляет строки новой версии графика и переключает на нее кредит
+func insertScheduleVersion(ctx context.Context, tx *sql.Tx, credit *models.Credit, schedule []*models.PaymentSchedule) error {
+	for _, payment := range schedule {
+		payment.CreditID = credit.ID
+		payment.Version = credit.ScheduleVersion
+		if err := insertPaymentSchedule(ctx, tx, payment); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE credits
+		SET schedule_version = $1, status = $2, interest_rate = $3, term_months = $4, updated_at = $5
+		WHERE id = $6`
+
+	_, err := tx.ExecContext(ctx, query,
+		credit.ScheduleVersion,
+		credit.Status,
+		credit.InterestRate,
+		credit.TermMonths,
+		time.Now(),
+		credit.ID,
+	)
+	return err
+}
+
+// TransitionPaymentStatus атомарно переводит платеж из статуса from в статус to и возвращает false,
+// если платеж уже не находится в статусе from
+func (r *PostgresCreditRepository) TransitionPaymentStatus(ctx context.Context, paymentID int64, from, to string) (bool, error) {
+	query := `
+		UPDATE payment_schedules
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	result, err := r.db.ExecContext(ctx, query, to, time.Now(), paymentID, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+const applicationColumns = `id, user_id, account_id, amount, term_months, repayment_type, status, score, max_amount,
+		interest_rate, reasons, pdn, pdn_flagged, with_insurance, psk, psk_amount, credit_id, decided_at,
+		created_at, updated_at`
+
+func scanApplication(row rowScanner) (*models.CreditApplication, error) {
+	application := &models.CreditApplication{}
+	var creditID sql.NullInt64
+	var decidedAt sql.NullTime
+
+	err := row.Scan(
+		&application.ID,
+		&application.UserID,
+		&application.AccountID,
+		&application.Amount,
+		&application.TermMonths,
+		&application.RepaymentType,
+		&application.Status,
+		&application.Score,
+		&application.MaxAmount,
+		&application.InterestRate,
+		pq.Array(&application.Reasons),
+		&application.PDN,
+		&application.PDNFlagged,
+		&application.WithInsurance,
+		&application.PSK,
+		&application.PSKAmount,
+		&creditID,
+		&decidedAt,
+		&application.CreatedAt,
+		&application.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if creditID.Valid {
+		application.CreditID = &creditID.Int64
+	}
+	if decidedAt.Valid {
+		application.DecidedAt = &decidedAt.Time
+	}
+
+	return application, nil
+}
+
+func (r *PostgresCreditRepository) CreateApplication(ctx context.Context, application *models.CreditApplication) error {
+	query := `
+		INSERT INTO credit_applications (user_id, account_id, amount, term_months, repayment_type, status,
+			with_insurance, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		application.UserID,
+		application.AccountID,
+		application.Amount,
+		application.TermMonths,
+		application.RepaymentType,
+		application.Status,
+		application.WithInsurance,
+		time.Now(),
+		time.Now(),
+	).Scan(&application.ID, &application.CreatedAt, &application.UpdatedAt)
+}
+
+func (r *PostgresCreditRepository) GetApplicationByID(ctx context.Context, id int64) (*models.CreditApplication, error) {
+	query := `
+		SELECT ` + applicationColumns + `
+		FROM credit_applications
+		WHERE id = $1`
+
+	return scanApplication(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresCreditRepository) GetApplicationsByUserID(ctx context.Context, userID int64) ([]*models.CreditApplication, error) {
+	query := `
+		SELECT ` + applicationColumns + `
+		FROM credit_applications
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applications []*models.CreditApplication
+	for rows.Next() {
+		application, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, application)
+	}
+
+	return applications, rows.Err()
+}
+
+func (r *PostgresCreditRepository) UpdateApplication(ctx context.Context, application *models.CreditApplication) error {
+	query := `
+		UPDATE credit_applications
+		SET status = $1, score = $2, max_amount = $3, interest_rate = $4, reasons = $5,
+			pdn = $6, pdn_flagged = $7, psk = $8, psk_amount = $9, credit_id = $10, decided_at = $11, updated_at = $12
+		WHERE id = $13
+		RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		application.Status,
+		application.Score,
+		application.MaxAmount,
+		application.InterestRate,
+		pq.Array(application.Reasons),
+		application.PDN,
+		application.PDNFlagged,
+		application.PSK,
+		application.PSKAmount,
+		application.CreditID,
+		application.DecidedAt,
+		time.Now(),
+		application.ID,
+	).Scan(&application.UpdatedAt)
+}
+
+// TransitionApplication атомарно переводит заявку из статуса from в статус to и возвращает false,
+// если заявка уже не находится в статусе from
+func (r *PostgresCreditRepository) TransitionApplication(ctx context.Context, id int64, from, to string) (bool, error) {
+	query := `
+		UPDATE credit_applications
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	result, err := r.db.ExecContext(ctx, query, to, time.Now(), id, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+const restructuringColumns = `id, credit_id, user_id, type, payments, extra_months, interest_rate, reason, status,
+		operator_id, decision_comment, schedule_version, decided_at, created_at, updated_at`
+
+func scanRestructuring(row rowScanner) (*models.CreditRestructuring, error) {
+	restructuring := &models.CreditRestructuring{}
+	var operatorID sql.NullInt64
+	var scheduleVersion sql.NullInt32
+	var decidedAt sql.NullTime
+
+	err := row.Scan(
+		&restructuring.ID,
+		&restructuring.CreditID,
+		&restructuring.UserID,
+		&restructuring.Type,
+		&restructuring.Payments,
+		&restructuring.ExtraMonths,
+		&restructuring.InterestRate,
+		&restructuring.Reason,
+		&restructuring.Status,
+		&operatorID,
+		&restructuring.DecisionComment,
+		&scheduleVersion,
+		&decidedAt,
+		&restructuring.CreatedAt,
+		&restructuring.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if operatorID.Valid {
+		restructuring.OperatorID = &operatorID.Int64
+	}
+	if scheduleVersion.Valid {
+		version := int(scheduleVersion.Int32)
+		restructuring.ScheduleVersion = &version
+	}
+	if decidedAt.Valid {
+		restructuring.DecidedAt = &decidedAt.Time
+	}
+
+	return restructuring, nil
+}
+
+func (r *PostgresCreditRepository) CreateRestructuring(ctx context.Context, restructuring *models.CreditRestructuring) error {
+	query := `
+		INSERT INTO credit_restructurings (credit_id, user_id, type, payments, extra_months, interest_rate, reason,
+			status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRowContext(ctx, query,
+		restructuring.CreditID,
+		restructuring.UserID,
+		restructuring.Type,
+		restructuring.Payments,
+		restructuring.ExtraMonths,
+		restructuring.InterestRate,
+		restructuring.Reason,
+		restructuring.Status,
+		time.Now(),
+		time.Now(),
+	).Scan(&restructuring.ID, &restructuring.CreatedAt, &restructuring.UpdatedAt)
+}
+
+func (r *PostgresCreditRepository) GetRestructuringByID(ctx context.Context, id int64) (*models.CreditRestructuring, error) {
+	query := `
+		SELECT ` + restructuringColumns + `
+		FROM credit_restructurings
+		WHERE id = $1`
+
+	return scanRestructuring(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresCreditRepository) GetRestructuringsByCreditID(ctx context.Context, creditID int64) ([]*models.CreditRestructuring, error) {
+	query := `
+		SELECT ` + restructuringColumns + `
+		FROM credit_restructurings
+		WHERE credit_id = $1
+		ORDER BY created_at DESC`
+
+	return r.queryRestructurings(ctx, query, creditID)
+}
+
+func (r *PostgresCreditRepository) GetRestructuringsByStatus(ctx context.Context, status string) ([]*models.CreditRestructuring, error) {
+	query := `
+		SELECT ` + restructuringColumns + `
+		FROM credit_restructurings
+		WHERE status = $1
+		ORDER BY created_at`
+
+	return r.queryRestructurings(ctx, query, status)
+}
+
+// DecideRestructuring сохраняет решение по запросу реструктуризации, только если запрос еще ожидает
+// решения, и возвращает false, если решение уже принято. При согласовании новая версия графика
+// платежей сохраняется в той же транзакции; при отказе credit и schedule равны nil.
+func (r *PostgresCreditRepository) DecideRestructuring(ctx context.Context, restructuring *models.CreditRestructuring, credit *models.Credit, schedule []*models.PaymentSchedule) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE credit_restructurings
+		SET status = $1, operator_id = $2, decision_comment = $3, schedule_version = $4, decided_at = $5,
+			updated_at = $6
+		WHERE id = $7 AND status = $8`
+
+	result, err := tx.ExecContext(ctx, query,
+		restructuring.Status,
+		restructuring.OperatorID,
+		restructuring.DecisionComment,
+		restructuring.ScheduleVersion,
+		restructuring.DecidedAt,
+		time.Now(),
+		restructuring.ID,
+		models.RestructuringStatusPending,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if credit != nil {
+		if err := insertScheduleVersion(ctx, tx, credit, schedule); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresCreditRepository) queryRestructurings(ctx context.Context, query string, args ...interface{}) ([]*models.CreditRestructuring, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restructurings []*models.CreditRestructuring
+	for rows.Next() {
+		restructuring, err := scanRestructuring(rows)
+		if err != nil {
+			return nil, err
+		}
+		restructurings = append(restructurings, restructuring)
+	}
+
+	return restructurings, rows.Err()
+}
+
+// CreateAccrual сохраняет ежедневное начисление процентов; повторное начисление за ту же дату игнорируется
+func (r *PostgresCreditRepository) CreateAccrual(ctx context.Context, accrual *models.InterestAccrual) error {
+	query := `
+		INSERT INTO interest_accruals (credit_id, accrual_date, principal, interest_rate, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (credit_id, accrual_date) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query,
+		accrual.CreditID,
+		accrual.AccrualDate,
+		accrual.Principal,
+		accrual.InterestRate,
+		accrual.Amount,
+		time.Now(),
+	)
+	return err
+}
+
+// GetAccruals возвращает начисления процентов за даты с from по to (не включая to)
+func (r *PostgresCreditRepository) GetAccruals(ctx context.Context, creditID int64, from, to time.Time) ([]*models.InterestAccrual, error) {
+	query := `
+		SELECT id, credit_id, accrual_date, principal, interest_rate, amount, created_at
+		FROM interest_accruals
+		WHERE credit_id = $1 AND accrual_date >= $2 AND accrual_date < $3
+		ORDER BY accrual_date`
+
+	rows, err := r.db.QueryContext(ctx, query, creditID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accruals []*models.InterestAccrual
+	for rows.Next() {
+		accrual := &models.InterestAccrual{}
+		err := rows.Scan(
+			&accrual.ID,
+			&accrual.CreditID,
+			&accrual.AccrualDate,
+			&accrual.Principal,
+			&accrual.InterestRate,
+			&accrual.Amount,
+			&accrual.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		accruals = append(accruals, accrual)
+	}
+
+	return accruals, rows.Err()
+}
+
+// GetLastAccrualDate возвращает дату последнего начисления процентов или nil, если начислений не было
+func (r *PostgresCreditRepository) GetLastAccrualDate(ctx context.Context, creditID int64) (*time.Time, error) {
+	var last sql.NullTime
+	query := `
+		SELECT MAX(accrual_date)
+		FROM interest_accruals
+		WHERE credit_id = $1`
+
+	if err := r.db.QueryRowContext(ctx, query, creditID).Scan(&last); err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+
+	return &last.Time, nil
+}
+
+// execQuerier - общий интерфейс *sql.DB и *sql.Tx
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func insertPaymentSchedule(ctx context.Context, db execQuerier, schedule *models.PaymentSchedule) error {
+	query := `
+		INSERT INTO payment_schedules (credit_id, payment_number, amount, principal, interest, due_date, status,
+			version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	return db.QueryRowContext(ctx, query,
 		schedule.CreditID,
 		schedule.PaymentNumber,
 		schedule.Amount,
@@ -126,17 +681,55 @@ func (r *PostgresCreditRepository) CreatePaymentSchedule(ctx context.Context, sc
 		schedule.Interest,
 		schedule.DueDate,
 		schedule.Status,
+		schedule.Version,
 		time.Now(),
 		time.Now(),
 	).Scan(&schedule.ID)
 }
 
-func (r *PostgresCreditRepository) GetPaymentSchedule(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error) {
+func (r *PostgresCreditRepository) queryPaymentSchedules(ctx context.Context, query string, args ...interface{}) ([]*models.PaymentSchedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*models.PaymentSchedule
+	for rows.Next() {
+		schedule, err := scanPaymentSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (r *PostgresCreditRepository) CreateDocument(ctx context.Context, document *models.CreditDocument) error {
 	query := `
-		SELECT id, credit_id, payment_number, amount, principal, interest, due_date, status, created_at, updated_at
-		FROM payment_schedules
+		INSERT INTO credit_documents (credit_id, type, file_name, checksum, size, content, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		document.CreditID,
+		document.Type,
+		document.FileName,
+		document.Checksum,
+		document.Size,
+		document.Content,
+		time.Now(),
+	).Scan(&document.ID, &document.CreatedAt)
+}
+
+// GetDocuments возвращает документы по кредиту без содержимого
+func (r *PostgresCreditRepository) GetDocuments(ctx context.Context, creditID int64) ([]*models.CreditDocument, error) {
+	query := `
+		SELECT id, credit_id, type, file_name, checksum, size, created_at
+		FROM credit_documents
 		WHERE credit_id = $1
-		ORDER BY payment_number`
+		ORDER BY created_at`
 
 	rows, err := r.db.QueryContext(ctx, query, creditID)
 	if err != nil {
@@ -144,36 +737,47 @@ func (r *PostgresCreditRepository) GetPaymentSchedule(ctx context.Context, credi
 	}
 	defer rows.Close()
 
-	var schedules []*models.PaymentSchedule
+	var documents []*models.CreditDocument
 	for rows.Next() {
-		schedule := &models.PaymentSchedule{}
+		document := &models.CreditDocument{}
 		err := rows.Scan(
-			&schedule.ID,
-			&schedule.CreditID,
-			&schedule.PaymentNumber,
-			&schedule.Amount,
-			&schedule.Principal,
-			&schedule.Interest,
-			&schedule.DueDate,
-			&schedule.Status,
-			&schedule.CreatedAt,
-			&schedule.UpdatedAt,
+			&document.ID,
+			&document.CreditID,
+			&document.Type,
+			&document.FileName,
+			&document.Checksum,
+			&document.Size,
+			&document.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		schedules = append(schedules, schedule)
+		documents = append(documents, document)
 	}
 
-	return schedules, nil
+	return documents, rows.Err()
 }
 
-func (r *PostgresCreditRepository) UpdatePaymentStatus(ctx context.Context, paymentID int64, status string) error {
+func (r *PostgresCreditRepository) GetDocument(ctx context.Context, id int64) (*models.CreditDocument, error) {
 	query := `
-		UPDATE payment_schedules
-		SET status = $1, updated_at = $2
-		WHERE id = $3`
+		SELECT id, credit_id, type, file_name, checksum, size, content, created_at
+		FROM credit_documents
+		WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, status, time.Now(), paymentID)
-	return err
-} 
\ No newline at end of file
+	document := &models.CreditDocument{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&document.ID,
+		&document.CreditID,
+		&document.Type,
+		&document.FileName,
+		&document.Checksum,
+		&document.Size,
+		&document.Content,
+		&document.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
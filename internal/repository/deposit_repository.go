@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrDepositChanged = errors.New("deposit was changed by another operation")
+
+type PostgresDepositRepository struct {
+	db *sql.DB
+}
+
+func NewDepositRepository(db *sql.DB) DepositRepository {
+	return &PostgresDepositRepository{db: db}
+}
+
+const depositColumns = `id, user_id, account_id, type, amount, balance, interest_rate, capitalization, term_months,
+		accrued_interest, interest_paid, status, next_interest_date, maturity_date, last_accrual_date, closed_at,
+		created_at, updated_at, version`
+
+func scanDeposit(row rowScanner) (*models.Deposit, error) {
+	deposit := &models.Deposit{}
+	var maturityDate, lastAccrualDate, closedAt sql.NullTime
+
+	err := row.Scan(
+		&deposit.ID,
+		&deposit.UserID,
+		&deposit.AccountID,
+		&deposit.Type,
+		&deposit.Amount,
+		&deposit.Balance,
+		&deposit.InterestRate,
+		&deposit.Capitalization,
+		&deposit.TermMonths,
+		&deposit.AccruedInterest,
+		&deposit.InterestPaid,
+		&deposit.Status,
+		&deposit.NextInterestDate,
+		&maturityDate,
+		&lastAccrualDate,
+		&closedAt,
+		&deposit.CreatedAt,
+		&deposit.UpdatedAt,
+		&deposit.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if maturityDate.Valid {
+		deposit.MaturityDate = &maturityDate.Time
+	}
+	if lastAccrualDate.Valid {
+		deposit.LastAccrualDate = &lastAccrualDate.Time
+	}
+	if closedAt.Valid {
+		deposit.ClosedAt = &closedAt.Time
+	}
+
+	return deposit, nil
+}
+
+// Create сохраняет вклад вместе с операцией списания его суммы со связанного счета
+// в одной транзакции БД
+func (r *PostgresDepositRepository) Create(ctx context.Context, deposit *models.Deposit, transaction *models.Transaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := postDepositTransaction(ctx, tx, deposit, transaction); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO deposits (user_id, account_id, type, amount, balance, interest_rate, capitalization, term_months,
+			status, next_interest_date, maturity_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		deposit.UserID,
+		deposit.AccountID,
+		deposit.Type,
+		deposit.Amount,
+		deposit.Balance,
+		deposit.InterestRate,
+		deposit.Capitalization,
+		deposit.TermMonths,
+		deposit.Status,
+		deposit.NextInterestDate,
+		deposit.MaturityDate,
+		time.Now(),
+		time.Now(),
+	).Scan(&deposit.ID, &deposit.CreatedAt, &deposit.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresDepositRepository) GetByID(ctx context.Context, id int64) (*models.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE id = $1`
+
+	return scanDeposit(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresDepositRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	return r.queryDeposits(ctx, query, userID)
+}
+
+func (r *PostgresDepositRepository) GetActive(ctx context.Context) ([]*models.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE status = $1
+		ORDER BY id`
+
+	return r.queryDeposits(ctx, query, models.DepositStatusActive)
+}
+
+// Update сохраняет вклад вместе с операциями по нему (пополнение, выплата процентов, возврат средств)
+// в одной транзакции БД. Если после чтения вклад изменила другая операция, ничего не сохраняется
+// и возвращается ErrDepositChanged.
+func (r *PostgresDepositRepository) Update(ctx context.Context, deposit *models.Deposit, transactions ...*models.Transaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE deposits
+		SET balance = $1, interest_rate = $2, accrued_interest = $3, interest_paid = $4, status = $5,
+			next_interest_date = $6, last_accrual_date = $7, closed_at = $8, updated_at = $9, version = version + 1
+		WHERE id = $10 AND version = $11`
+
+	result, err := tx.ExecContext(ctx, query,
+		deposit.Balance,
+		deposit.InterestRate,
+		deposit.AccruedInterest,
+		deposit.InterestPaid,
+		deposit.Status,
+		deposit.NextInterestDate,
+		deposit.LastAccrualDate,
+		deposit.ClosedAt,
+		time.Now(),
+		deposit.ID,
+		deposit.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrDepositChanged
+	}
+
+	for _, transaction := range transactions {
+		if err := postDepositTransaction(ctx, tx, deposit, transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	deposit.Version++
+	return nil
+}
+
+// postDepositTransaction проводит операцию по вкладу. Со связанного счета списываются
+// только собственные средства, без овердрафта.
+func postDepositTransaction(ctx context.Context, tx *sql.Tx, deposit *models.Deposit, transaction *models.Transaction) error {
+	if transaction.FromAccountID == deposit.AccountID {
+		balance, _, err := lockBalance(ctx, tx, deposit.AccountID)
+		if err != nil {
+			return err
+		}
+		if balance < transaction.Amount {
+			return ErrInsufficientBalance
+		}
+	}
+
+	return postTransaction(ctx, tx, transaction)
+}
+
+func (r *PostgresDepositRepository) queryDeposits(ctx context.Context, query string, args ...interface{}) ([]*models.Deposit, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []*models.Deposit
+	for rows.Next() {
+		deposit, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	return deposits, rows.Err()
+}
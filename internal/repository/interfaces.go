@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"bank-api/internal/models"
+	"time"
 )
 
 type UserRepository interface {
@@ -17,21 +18,46 @@ type UserRepository interface {
 type AccountRepository interface {
 	Create(ctx context.Context, account *models.Account) error
 	GetByID(ctx context.Context, id int64) (*models.Account, error)
+	GetByNumber(ctx context.Context, number string) (*models.Account, error)
+	GetNumbers(ctx context.Context, ids []int64) (map[int64]string, error)
+	GetSystemAccount(ctx context.Context, code string) (*models.Account, error)
 	GetByUserID(ctx context.Context, userID int64) ([]*models.Account, error)
 	Update(ctx context.Context, account *models.Account) error
 	Delete(ctx context.Context, id int64) error
 	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
+	CreateCompensation(ctx context.Context, transaction *models.Transaction) error
+	GetTransaction(ctx context.Context, id int64) (*models.Transaction, error)
 	GetTransactions(ctx context.Context, accountID int64) ([]*models.Transaction, error)
+	CreateWithdrawal(ctx context.Context, transaction *models.Transaction, types []string, from time.Time, limit float64) error
+	FindTransactions(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error)
+	GetTurnover(ctx context.Context, accountID int64, from, to time.Time) (float64, float64, error)
+	GetDailyNetTurnover(ctx context.Context, accountID int64, from time.Time) (map[time.Time]float64, error)
+	StreamStatementLines(ctx context.Context, accountID int64, from, to time.Time, fn func(*models.StatementLine) error) error
 	UpdateBalance(ctx context.Context, accountID int64, amount float64) error
+	SetOverdraftLimit(ctx context.Context, accountID int64, limit float64) error
 }
 
 type CardRepository interface {
 	Create(ctx context.Context, card *models.Card) error
+	Reissue(ctx context.Context, card, previous *models.Card) error
 	GetByID(ctx context.Context, id int64) (*models.Card, error)
 	GetByAccountID(ctx context.Context, accountID int64) ([]*models.Card, error)
 	Update(ctx context.Context, card *models.Card) error
 	Delete(ctx context.Context, id int64) error
 	Deactivate(ctx context.Context, id int64) error
+	TransitionStatus(ctx context.Context, id int64, from, to, reason string) (bool, error)
+	GetByNumber(ctx context.Context, number string) (*models.Card, error)
+	GetExpired(ctx context.Context, at time.Time) ([]*models.Card, error)
+	GetExpiredSingleUse(ctx context.Context, at time.Time) ([]*models.Card, error)
+	GetBINs(ctx context.Context, productCode string) ([]*models.CardBIN, error)
+	UpdatePIN(ctx context.Context, cardID int64, encryptedPINBlock string) error
+	IncrementPINTries(ctx context.Context, cardID int64) (int, error)
+	ResetPINTries(ctx context.Context, cardID int64) error
+	GetLimits(ctx context.Context, cardID int64) (*models.CardLimits, error)
+	UpsertLimits(ctx context.Context, limits *models.CardLimits) error
+	GetSpending(ctx context.Context, cardID int64, at time.Time) (daily float64, monthly float64, err error)
+	ReserveSpending(ctx context.Context, cardID int64, amount, dailyLimit, monthlyLimit float64, at time.Time) error
+	AddSpending(ctx context.Context, cardID int64, amount float64, at time.Time) error
 }
 
 type CreditRepository interface {
@@ -42,5 +68,92 @@ type CreditRepository interface {
 	Delete(ctx context.Context, id int64) error
 	CreatePaymentSchedule(ctx context.Context, schedule *models.PaymentSchedule) error
 	GetPaymentSchedule(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error)
-	UpdatePaymentStatus(ctx context.Context, paymentID int64, status string) error
-} 
\ No newline at end of file
+	TransitionPaymentStatus(ctx context.Context, paymentID int64, from, to string) (bool, error)
+	GetPaymentScheduleHistory(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error)
+	CreatePrepayment(ctx context.Context, prepayment *models.CreditPrepayment, credit *models.Credit, schedule []*models.PaymentSchedule) error
+	GetActive(ctx context.Context) ([]*models.Credit, error)
+	CreateAccrual(ctx context.Context, accrual *models.InterestAccrual) error
+	GetAccruals(ctx context.Context, creditID int64, from, to time.Time) ([]*models.InterestAccrual, error)
+	GetLastAccrualDate(ctx context.Context, creditID int64) (*time.Time, error)
+	CreateRestructuring(ctx context.Context, restructuring *models.CreditRestructuring) error
+	GetRestructuringByID(ctx context.Context, id int64) (*models.CreditRestructuring, error)
+	GetRestructuringsByCreditID(ctx context.Context, creditID int64) ([]*models.CreditRestructuring, error)
+	GetRestructuringsByStatus(ctx context.Context, status string) ([]*models.CreditRestructuring, error)
+	DecideRestructuring(ctx context.Context, restructuring *models.CreditRestructuring, credit *models.Credit, schedule []*models.PaymentSchedule) (bool, error)
+	CreateApplication(ctx context.Context, application *models.CreditApplication) error
+	GetApplicationByID(ctx context.Context, id int64) (*models.CreditApplication, error)
+	GetApplicationsByUserID(ctx context.Context, userID int64) ([]*models.CreditApplication, error)
+	UpdateApplication(ctx context.Context, application *models.CreditApplication) error
+	TransitionApplication(ctx context.Context, id int64, from, to string) (bool, error)
+	CreateDocument(ctx context.Context, document *models.CreditDocument) error
+	GetDocuments(ctx context.Context, creditID int64) ([]*models.CreditDocument, error)
+	GetDocument(ctx context.Context, id int64) (*models.CreditDocument, error)
+}
+
+type CalendarRepository interface {
+	GetCalendarDays(ctx context.Context) ([]*models.CalendarDay, error)
+}
+
+type CreditLineRepository interface {
+	Create(ctx context.Context, line *models.CreditLine) error
+	GetByID(ctx context.Context, id int64) (*models.CreditLine, error)
+	GetActiveByAccountID(ctx context.Context, accountID int64) (*models.CreditLine, error)
+	GetByUserID(ctx context.Context, userID int64) ([]*models.CreditLine, error)
+	GetActive(ctx context.Context) ([]*models.CreditLine, error)
+	Update(ctx context.Context, line *models.CreditLine) error
+	CreateStatement(ctx context.Context, statement *models.CreditLineStatement) error
+	SaveStatement(ctx context.Context, line *models.CreditLine, statement *models.CreditLineStatement, transaction *models.Transaction) error
+	GetStatements(ctx context.Context, creditLineID int64) ([]*models.CreditLineStatement, error)
+	GetOpenStatements(ctx context.Context, creditLineID int64) ([]*models.CreditLineStatement, error)
+	UpdateStatementStatus(ctx context.Context, id int64, status string) error
+}
+
+type DepositRepository interface {
+	Create(ctx context.Context, deposit *models.Deposit, transaction *models.Transaction) error
+	GetByID(ctx context.Context, id int64) (*models.Deposit, error)
+	GetByUserID(ctx context.Context, userID int64) ([]*models.Deposit, error)
+	GetActive(ctx context.Context) ([]*models.Deposit, error)
+	Update(ctx context.Context, deposit *models.Deposit, transactions ...*models.Transaction) error
+}
+
+type CategoryRepository interface {
+	CreateRule(ctx context.Context, rule *models.CategoryRule) error
+	GetRules(ctx context.Context, userID *int64) ([]*models.CategoryRule, error)
+	DeleteRule(ctx context.Context, id int64, userID *int64) (bool, error)
+	GetUncategorized(ctx context.Context, userID int64, accountIDs []int64, limit int) ([]*models.UncategorizedTransaction, error)
+	SaveCategories(ctx context.Context, categories []*models.TransactionCategory) error
+	SetCategory(ctx context.Context, transactionID, userID int64, category, source string) (*models.TransactionCategory, error)
+	ResetAutomatic(ctx context.Context, userID *int64) error
+}
+
+type BudgetRepository interface {
+	Create(ctx context.Context, budget *models.Budget) error
+	GetByID(ctx context.Context, id int64) (*models.Budget, error)
+	GetByUserID(ctx context.Context, userID int64) ([]*models.Budget, error)
+	GetUserIDs(ctx context.Context) ([]int64, error)
+	Update(ctx context.Context, budget *models.Budget) error
+	Delete(ctx context.Context, id int64) error
+	SaveAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error)
+	ResetAlerts(ctx context.Context, budgetID int64, month time.Time) error
+}
+
+type StandingOrderRepository interface {
+	Create(ctx context.Context, order *models.StandingOrder) error
+	GetByID(ctx context.Context, id int64) (*models.StandingOrder, error)
+	GetByUserID(ctx context.Context, userID int64) ([]*models.StandingOrder, error)
+	GetDue(ctx context.Context, now time.Time) ([]*models.StandingOrder, error)
+	GetActiveByAccounts(ctx context.Context, accountIDs []int64) ([]*models.StandingOrder, error)
+	Update(ctx context.Context, order *models.StandingOrder) error
+	Advance(ctx context.Context, order *models.StandingOrder, status string, runAt *time.Time) (bool, error)
+	CreateExecution(ctx context.Context, execution *models.StandingOrderExecution) error
+	GetExecutions(ctx context.Context, orderID int64) ([]*models.StandingOrderExecution, error)
+}
+
+type AnalyticsRepository interface {
+	GetCategoryTotals(ctx context.Context, userID int64, from, to time.Time) ([]*models.CategoryTotal, error)
+	GetPeriodTotals(ctx context.Context, userID int64, from, to time.Time, granularity, timezone string) ([]*models.PeriodTotal, error)
+	GetCounterpartyHistory(ctx context.Context, userID int64, from time.Time) ([]*models.CounterpartyTransaction, error)
+	GetSchedule(ctx context.Context, userID int64, from, to time.Time) ([]*models.PaymentSchedule, error)
+	GetUnpaidPrincipal(ctx context.Context, userID int64) (float64, error)
+	GetIncomeStats(ctx context.Context, accountIDs []int64, from time.Time) (int, float64, error)
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"database/sql"
+	"time"
+	"github.com/lib/pq"
+)
+
+type PostgresStandingOrderRepository struct {
+	db *sql.DB
+}
+
+func NewStandingOrderRepository(db *sql.DB) StandingOrderRepository {
+	return &PostgresStandingOrderRepository{db: db}
+}
+
+const standingOrderColumns = `id, user_id, from_account_id, to_account_id, amount, description, schedule, execute_at,
+		day_of_month, day_of_week, cron, end_date, status, next_occurrence, next_run_at, attempts, created_at, updated_at`
+
+func scanStandingOrder(row rowScanner) (*models.StandingOrder, error) {
+	order := &models.StandingOrder{}
+	var executeAt, endDate, nextOccurrence, nextRunAt sql.NullTime
+	var dayOfMonth, dayOfWeek sql.NullInt64
+
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.FromAccountID,
+		&order.ToAccountID,
+		&order.Amount,
+		&order.Description,
+		&order.Schedule,
+		&executeAt,
+		&dayOfMonth,
+		&dayOfWeek,
+		&order.Cron,
+		&endDate,
+		&order.Status,
+		&nextOccurrence,
+		&nextRunAt,
+		&order.Attempts,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if executeAt.Valid {
+		order.ExecuteAt = &executeAt.Time
+	}
+	if dayOfMonth.Valid {
+		day := int(dayOfMonth.Int64)
+		order.DayOfMonth = &day
+	}
+	if dayOfWeek.Valid {
+		day := int(dayOfWeek.Int64)
+		order.DayOfWeek = &day
+	}
+	if endDate.Valid {
+		order.EndDate = &endDate.Time
+	}
+	if nextOccurrence.Valid {
+		order.NextOccurrence = &nextOccurrence.Time
+	}
+	if nextRunAt.Valid {
+		order.NextRunAt = &nextRunAt.Time
+	}
+
+	return order, nil
+}
+
+func (r *PostgresStandingOrderRepository) Create(ctx context.Context, order *models.StandingOrder) error {
+	query := `
+		INSERT INTO standing_orders (user_id, from_account_id, to_account_id, amount, description, schedule,
+			execute_at, day_of_month, day_of_week, cron, end_date, status, next_occurrence, next_run_at, attempts,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		order.UserID,
+		order.FromAccountID,
+		order.ToAccountID,
+		order.Amount,
+		order.Description,
+		order.Schedule,
+		order.ExecuteAt,
+		order.DayOfMonth,
+		order.DayOfWeek,
+		order.Cron,
+		order.EndDate,
+		order.Status,
+		order.NextOccurrence,
+		order.NextRunAt,
+		order.Attempts,
+		time.Now(),
+		time.Now(),
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+}
+
+func (r *PostgresStandingOrderRepository) GetByID(ctx context.Context, id int64) (*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE id = $1`
+
+	return scanStandingOrder(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresStandingOrderRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE user_id = $1
+		ORDER BY id DESC`
+
+	return r.queryStandingOrders(ctx, query, userID)
+}
+
+// GetDue возвращает активные поручения, время исполнения которых наступило к моменту now
+func (r *PostgresStandingOrderRepository) GetDue(ctx context.Context, now time.Time) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE status = 'active' AND next_run_at <= $1
+		ORDER BY next_run_at, id`
+
+	return r.queryStandingOrders(ctx, query, now)
+}
+
+// GetActiveByAccounts возвращает активные поручения, списывающие средства со счетов или зачисляющие на них
+func (r *PostgresStandingOrderRepository) GetActiveByAccounts(ctx context.Context, accountIDs []int64) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE status = 'active' AND (from_account_id = ANY($1) OR to_account_id = ANY($1))
+		ORDER BY id`
+
+	return r.queryStandingOrders(ctx, query, pq.Array(accountIDs))
+}
+
+func (r *PostgresStandingOrderRepository) Update(ctx context.Context, order *models.StandingOrder) error {
+	query := `
+		UPDATE standing_orders
+		SET status = $1, next_occurrence = $2, next_run_at = $3, attempts = $4, updated_at = $5
+		WHERE id = $6
+		RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		order.Status,
+		order.NextOccurrence,
+		order.NextRunAt,
+		order.Attempts,
+		time.Now(),
+		order.ID,
+	).Scan(&order.UpdatedAt)
+}
+
+// Advance сохраняет новое состояние поручения, только если поручение все еще находится в статусе status
+// со временем исполнения runAt. Возвращает false, если состояние уже изменено параллельно.
+func (r *PostgresStandingOrderRepository) Advance(ctx context.Context, order *models.StandingOrder, status string, runAt *time.Time) (bool, error) {
+	query := `
+		UPDATE standing_orders
+		SET status = $1, next_occurrence = $2, next_run_at = $3, attempts = $4, updated_at = $5
+		WHERE id = $6 AND status = $7 AND next_run_at IS NOT DISTINCT FROM $8
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		order.Status,
+		order.NextOccurrence,
+		order.NextRunAt,
+		order.Attempts,
+		time.Now(),
+		order.ID,
+		status,
+		runAt,
+	).Scan(&order.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PostgresStandingOrderRepository) queryStandingOrders(ctx context.Context, query string, args ...interface{}) ([]*models.StandingOrder, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.StandingOrder
+	for rows.Next() {
+		order, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (r *PostgresStandingOrderRepository) CreateExecution(ctx context.Context, execution *models.StandingOrderExecution) error {
+	query := `
+		INSERT INTO standing_order_executions (standing_order_id, scheduled_at, attempt, status, transaction_id, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		execution.StandingOrderID,
+		execution.ScheduledAt,
+		execution.Attempt,
+		execution.Status,
+		execution.TransactionID,
+		execution.Error,
+		time.Now(),
+	).Scan(&execution.ID, &execution.CreatedAt)
+}
+
+// GetExecutions возвращает историю исполнения поручения, начиная с последних попыток
+func (r *PostgresStandingOrderRepository) GetExecutions(ctx context.Context, orderID int64) ([]*models.StandingOrderExecution, error) {
+	query := `
+		SELECT id, standing_order_id, scheduled_at, attempt, status, transaction_id, error, created_at
+		FROM standing_order_executions
+		WHERE standing_order_id = $1
+		ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []*models.StandingOrderExecution
+	for rows.Next() {
+		execution := &models.StandingOrderExecution{}
+		var transactionID sql.NullInt64
+		err := rows.Scan(
+			&execution.ID,
+			&execution.StandingOrderID,
+			&execution.ScheduledAt,
+			&execution.Attempt,
+			&execution.Status,
+			&transactionID,
+			&execution.Error,
+			&execution.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if transactionID.Valid {
+			execution.TransactionID = &transactionID.Int64
+		}
+		executions = append(executions, execution)
+	}
+
+	return executions, rows.Err()
+}
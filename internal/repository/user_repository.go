@@ -4,6 +4,7 @@ import (
 	"context"
 	"bank-api/internal/models"
 	"database/sql"
+	"time"
 )
 
 type PostgresUserRepository struct {
@@ -16,21 +17,23 @@ func NewUserRepository(db *sql.DB) UserRepository {
 
 func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (email, username, password_hash)
-		VALUES ($1, $2, $3)
+		INSERT INTO users (email, username, password_hash, role, timezone)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRowContext(ctx, query,
 		user.Email,
 		user.Username,
 		user.Password,
+		user.Role,
+		user.Timezone,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, username, password_hash, created_at, updated_at
+		SELECT id, email, username, password_hash, role, timezone, created_at, updated_at
 		FROM users
 		WHERE id = $1`
 
@@ -39,6 +42,8 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id int64) (*models
 		&user.Email,
 		&user.Username,
 		&user.Password,
+		&user.Role,
+		&user.Timezone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -54,7 +59,7 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id int64) (*models
 func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, username, password_hash, created_at, updated_at
+		SELECT id, email, username, password_hash, role, timezone, created_at, updated_at
 		FROM users
 		WHERE email = $1`
 
@@ -63,6 +68,8 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 		&user.Email,
 		&user.Username,
 		&user.Password,
+		&user.Role,
+		&user.Timezone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -78,7 +85,7 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, username, password_hash, created_at, updated_at
+		SELECT id, email, username, password_hash, role, timezone, created_at, updated_at
 		FROM users
 		WHERE username = $1`
 
@@ -87,6 +94,8 @@ func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username str
 		&user.Email,
 		&user.Username,
 		&user.Password,
+		&user.Role,
+		&user.Timezone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -102,13 +111,15 @@ func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username str
 func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET email = $1, username = $2, password_hash = $3
-		WHERE id = $4`
+		SET email = $1, username = $2, password_hash = $3, timezone = $4, updated_at = $5
+		WHERE id = $6`
 
 	_, err := r.db.ExecContext(ctx, query,
 		user.Email,
 		user.Username,
 		user.Password,
+		user.Timezone,
+		time.Now(),
 		user.ID,
 	)
 	return err
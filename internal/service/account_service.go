@@ -4,16 +4,34 @@ import (
 	"context"
 	"bank-api/internal/models"
 	"bank-api/internal/repository"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"math/rand"
 )
 
+var (
+	ErrInsufficientFunds        = errors.New("insufficient funds")
+	ErrAccountNotFound          = errors.New("account not found")
+	ErrInvalidTransactionFilter = errors.New("invalid transaction filter")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+)
+
+// Размер страницы истории транзакций
+const (
+	defaultTransactionPageSize = 50
+	maxTransactionPageSize     = 200
+)
+
 type AccountService struct {
-	repo repository.AccountRepository
+	repo       repository.AccountRepository
+	userRepo   repository.UserRepository
+	categories *CategoryService
+	cashLimits CashLimits
 }
 
-func NewAccountService(repo repository.AccountRepository) *AccountService {
-	return &AccountService{repo: repo}
+func NewAccountService(repo repository.AccountRepository, userRepo repository.UserRepository, categories *CategoryService, cashLimits CashLimits) *AccountService {
+	return &AccountService{repo: repo, userRepo: userRepo, categories: categories, cashLimits: cashLimits}
 }
 
 func (s *AccountService) Create(ctx context.Context, input models.AccountCreate) (*models.Account, error) {
@@ -39,9 +57,30 @@ func (s *AccountService) GetByUserID(ctx context.Context, userID int64) ([]*mode
 	return s.repo.GetByUserID(ctx, userID)
 }
 
-func (s *AccountService) Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) error {
+func (s *AccountService) Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) (*models.Transaction, error) {
+	return s.transfer(ctx, fromAccountID, toAccountID, amount, "transfer")
+}
+
+// transfer переводит средства между счетами и создает транзакцию указанного типа
+func (s *AccountService) transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64, transactionType string) (*models.Transaction, error) {
+	transaction := &models.Transaction{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		Type:          transactionType,
+	}
+
+	if err := s.execute(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// execute проводит перевод, описанный транзакцией, и сохраняет ее со статусом completed
+func (s *AccountService) execute(ctx context.Context, transaction *models.Transaction) error {
+	fromAccountID, amount := transaction.FromAccountID, transaction.Amount
 	if amount <= 0 {
-		return errors.New("invalid amount")
+		return ErrInvalidAmount
 	}
 
 	fromAccount, err := s.repo.GetByID(ctx, fromAccountID)
@@ -49,10 +88,19 @@ func (s *AccountService) Transfer(ctx context.Context, fromAccountID, toAccountI
 		return err
 	}
 
-	if fromAccount.Balance < amount {
-		return errors.New("insufficient funds")
+	// При наличии кредитной линии баланс может уходить в минус в пределах лимита овердрафта
+	if fromAccount.Balance+fromAccount.OverdraftLimit < amount {
+		return ErrInsufficientFunds
 	}
 
+	return s.post(ctx, transaction)
+}
+
+// post проверяет счет получателя, переносит сумму между счетами и сохраняет транзакцию
+// со статусом completed. Достаточность средств на счете отправителя не проверяется.
+func (s *AccountService) post(ctx context.Context, transaction *models.Transaction) error {
+	fromAccountID, toAccountID, amount := transaction.FromAccountID, transaction.ToAccountID, transaction.Amount
+
 	// Проверяем существование счета получателя
 	if _, err := s.repo.GetByID(ctx, toAccountID); err != nil {
 		return errors.New("recipient account not found")
@@ -69,26 +117,129 @@ func (s *AccountService) Transfer(ctx context.Context, fromAccountID, toAccountI
 		return err
 	}
 
-	// Создаем транзакцию
-	transaction := &models.Transaction{
-		FromAccountID: fromAccountID,
-		ToAccountID:   toAccountID,
-		Amount:        amount,
-		Type:          "transfer",
-		Status:        "completed",
+	// Создаем транзакцию. Отмены и возвраты сохраняются с проверкой остатка исходной операции.
+	// Если транзакция не сохранена (например, операция уже отменена параллельным запросом),
+	// возвращаем балансы
+	save := s.repo.CreateTransaction
+	if transaction.OriginalTransactionID != nil {
+		save = s.repo.CreateCompensation
 	}
-
-	return s.repo.CreateTransaction(ctx, transaction)
+	transaction.Status = "completed"
+	if err := save(ctx, transaction); err != nil {
+		_ = s.repo.UpdateBalance(ctx, toAccountID, -amount)
+		_ = s.repo.UpdateBalance(ctx, fromAccountID, amount)
+		return err
+	}
+	return nil
 }
 
 func (s *AccountService) GetTransactions(ctx context.Context, accountID int64) ([]*models.Transaction, error) {
 	return s.repo.GetTransactions(ctx, accountID)
 }
 
+// FindTransactions возвращает страницу истории транзакций пользователя по всем его счетам
+// или по одному счету. cursor - значение next_cursor предыдущей страницы.
+func (s *AccountService) FindTransactions(ctx context.Context, userID int64, filter models.TransactionFilter, cursor string) (*models.TransactionPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = models.TransactionSortCreatedAt
+	}
+	if filter.Order == "" {
+		filter.Order = models.SortOrderDesc
+	}
+	if filter.Sort != models.TransactionSortCreatedAt && filter.Sort != models.TransactionSortAmount {
+		return nil, ErrInvalidTransactionFilter
+	}
+	if filter.Order != models.SortOrderAsc && filter.Order != models.SortOrderDesc {
+		return nil, ErrInvalidTransactionFilter
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidTransactionFilter
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, ErrInvalidTransactionFilter
+	}
+	if filter.Limit < 0 {
+		return nil, ErrInvalidTransactionFilter
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultTransactionPageSize
+	}
+	if filter.Limit > maxTransactionPageSize {
+		filter.Limit = maxTransactionPageSize
+	}
+
+	if cursor != "" {
+		after, err := decodeTransactionCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		// Курсор действителен только для той же сортировки, в которой он был выдан
+		if after.Sort != filter.Sort || after.Order != filter.Order {
+			return nil, ErrInvalidCursor
+		}
+		filter.After = after
+	}
+
+	accounts, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.AccountIDs = make([]int64, 0, len(accounts))
+	owned := false
+	for _, account := range accounts {
+		filter.AccountIDs = append(filter.AccountIDs, account.ID)
+		if account.ID == filter.AccountID {
+			owned = true
+		}
+	}
+	if filter.AccountID != 0 && !owned {
+		return nil, ErrAccountNotFound
+	}
+
+	page := &models.TransactionPage{Transactions: []*models.Transaction{}}
+	if len(filter.AccountIDs) == 0 {
+		return page, nil
+	}
+
+	// Категории новых операций назначаются перед выборкой, чтобы по ним можно было фильтровать
+	if err := s.categories.Categorize(ctx, userID); err != nil {
+		return nil, err
+	}
+	filter.UserID = userID
+
+	transactions, err := s.repo.FindTransactions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+		last := transactions[len(transactions)-1]
+		page.HasMore = true
+		page.NextCursor = encodeTransactionCursor(&models.TransactionCursor{
+			Sort:      filter.Sort,
+			Order:     filter.Order,
+			CreatedAt: last.CreatedAt,
+			Amount:    last.Amount,
+			ID:        last.ID,
+		})
+	}
+	if transactions != nil {
+		page.Transactions = transactions
+	}
+
+	return page, nil
+}
+
 func (s *AccountService) UpdateBalance(ctx context.Context, accountID int64, amount float64) error {
 	return s.repo.UpdateBalance(ctx, accountID, amount)
 }
 
+func (s *AccountService) SetOverdraftLimit(ctx context.Context, accountID int64, limit float64) error {
+	return s.repo.SetOverdraftLimit(ctx, accountID, limit)
+}
+
 // Вспомогательные функции
 
 func generateAccountNumber() string {
@@ -98,4 +249,23 @@ func generateAccountNumber() string {
 		number[i] = byte(rand.Intn(10)) + '0'
 	}
 	return string(number)
+}
+
+func encodeTransactionCursor(cursor *models.TransactionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTransactionCursor(value string) (*models.TransactionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &models.TransactionCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
 } 
\ No newline at end of file
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"bank-api/pkg/daycount"
+	"time"
+)
+
+// InterestEngine начисляет проценты по конвенции расчета дней и определяет даты платежей
+// с переносом на рабочие дни по производственному календарю
+type InterestEngine struct {
+	Convention daycount.Convention
+	Calendar   *daycount.Calendar
+}
+
+// Interest рассчитывает проценты на остаток principal за период с from по to
+func (e InterestEngine) Interest(principal, annualRate float64, from, to time.Time) float64 {
+	return principal * annualRate / 100 * e.Convention.YearFraction(from, to)
+}
+
+// DueDates возвращает даты termMonths ежемесячных платежей, отсчитываемые от даты выдачи.
+// Число месяца сохраняется (с поправкой на короткие месяцы), дата переносится на следующий рабочий день.
+func (e InterestEngine) DueDates(issueDate time.Time, termMonths int) []time.Time {
+	dates := make([]time.Time, 0, termMonths)
+	for i := 1; i <= termMonths; i++ {
+		dates = append(dates, e.Calendar.Following(daycount.AddMonths(issueDate, i)))
+	}
+	return dates
+}
+
+// DueDatesFrom возвращает count дат платежей по сетке кредита, выданного issueDate,
+// начиная с первой даты не раньше from
+func (e InterestEngine) DueDatesFrom(issueDate, from time.Time, count int) []time.Time {
+	from = daycount.Date(from)
+	dates := make([]time.Time, 0, count)
+	for i := 1; len(dates) < count; i++ {
+		dueDate := e.Calendar.Following(daycount.AddMonths(issueDate, i))
+		if !daycount.Date(dueDate).Before(from) {
+			dates = append(dates, dueDate)
+		}
+	}
+	return dates
+}
+
+// DailyAccruals рассчитывает ежедневные начисления процентов по кредиту за дни с from по to
+// (не включая to) на остаток основного долга по графику платежей
+func (e InterestEngine) DailyAccruals(credit *models.Credit, schedule []*models.PaymentSchedule, from, to time.Time) []*models.InterestAccrual {
+	var accruals []*models.InterestAccrual
+	for day := daycount.Date(from); day.Before(daycount.Date(to)); day = day.AddDate(0, 0, 1) {
+		principal := outstandingPrincipal(schedule, day)
+		if principal <= 0 {
+			continue
+		}
+
+		accruals = append(accruals, &models.InterestAccrual{
+			CreditID:     credit.ID,
+			AccrualDate:  day,
+			Principal:    principal,
+			InterestRate: credit.InterestRate,
+			Amount:       e.Interest(principal, credit.InterestRate, day, day.AddDate(0, 0, 1)),
+		})
+	}
+	return accruals
+}
+
+// outstandingPrincipal возвращает остаток основного долга на конец дня day: сумму основного
+// долга по платежам графика с более поздней датой
+func outstandingPrincipal(schedule []*models.PaymentSchedule, day time.Time) float64 {
+	var principal float64
+	for _, payment := range schedule {
+		if daycount.Date(payment.DueDate).After(day) {
+			principal += payment.Principal
+		}
+	}
+	return roundMoney(principal)
+}
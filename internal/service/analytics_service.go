@@ -4,22 +4,53 @@ import (
 	"context"
 	"bank-api/internal/models"
 	"bank-api/internal/repository"
+	"errors"
+	"math"
+	"sort"
 	"time"
 )
 
+var (
+	ErrInvalidHistoryParams = errors.New("invalid history parameters")
+)
+
+// Ограничения глубины истории аналитики в месяцах
+const (
+	defaultHistoryMonths = 12
+	maxHistoryMonths     = 36
+)
+
 type AnalyticsService struct {
-	accountRepo repository.AccountRepository
-	creditRepo  repository.CreditRepository
+	repo            repository.AnalyticsRepository
+	userRepo        repository.UserRepository
+	accountRepo     repository.AccountRepository
+	creditRepo      repository.CreditRepository
+	creditLineRepo  repository.CreditLineRepository
+	categoryService *CategoryService
+	budgetService   *BudgetService
+	standingOrders  *StandingOrderService
 }
 
-func NewAnalyticsService(accountRepo repository.AccountRepository, creditRepo repository.CreditRepository) *AnalyticsService {
+func NewAnalyticsService(repo repository.AnalyticsRepository, userRepo repository.UserRepository, accountRepo repository.AccountRepository, creditRepo repository.CreditRepository, creditLineRepo repository.CreditLineRepository, categoryService *CategoryService, budgetService *BudgetService, standingOrders *StandingOrderService) *AnalyticsService {
 	return &AnalyticsService{
-		accountRepo: accountRepo,
-		creditRepo:  creditRepo,
+		repo:            repo,
+		userRepo:        userRepo,
+		accountRepo:     accountRepo,
+		creditRepo:      creditRepo,
+		creditLineRepo:  creditLineRepo,
+		categoryService: categoryService,
+		budgetService:   budgetService,
+		standingOrders:  standingOrders,
 	}
 }
 
 func (s *AnalyticsService) GetAnalytics(ctx context.Context, userID int64, forecastDays int) (*models.Analytics, error) {
+	// Календарные периоды считаются в часовом поясе пользователя
+	loc, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Получаем все счета пользователя
 	accounts, err := s.accountRepo.GetByUserID(ctx, userID)
 	if err != nil {
@@ -32,20 +63,32 @@ func (s *AnalyticsService) GetAnalytics(ctx context.Context, userID int64, forec
 		return nil, err
 	}
 
+	// Получаем кредитные линии пользователя
+	creditLines, err := s.creditLineRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Получаем статистику за текущий месяц
-	monthlyStats, err := s.getMonthlyStats(ctx, accounts)
+	monthlyStats, err := s.getMonthlyStats(ctx, userID, loc)
 	if err != nil {
 		return nil, err
 	}
 
 	// Получаем кредитную нагрузку
-	creditLoad, err := s.getCreditLoad(ctx, credits, monthlyStats.TotalIncome)
+	creditLoad, err := s.getCreditLoad(ctx, userID, loc, credits, creditLines, accounts, monthlyStats.TotalIncome)
 	if err != nil {
 		return nil, err
 	}
 
 	// Получаем прогноз баланса
-	balanceForecast, err := s.getBalanceForecast(ctx, accounts, credits, forecastDays)
+	balanceForecast, err := s.getBalanceForecast(ctx, userID, loc, accounts, forecastDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// Расходование бюджетов по категориям
+	budgets, err := s.budgetService.getBudgets(ctx, userID, loc)
 	if err != nil {
 		return nil, err
 	}
@@ -54,88 +97,108 @@ func (s *AnalyticsService) GetAnalytics(ctx context.Context, userID int64, forec
 		MonthlyStats:    *monthlyStats,
 		CreditLoad:      *creditLoad,
 		BalanceForecast: *balanceForecast,
+		Budgets:         budgets,
 	}, nil
 }
 
-func (s *AnalyticsService) getMonthlyStats(ctx context.Context, accounts []*models.Account) (*models.MonthlyStats, error) {
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Second)
-
-	var totalIncome, totalExpenses float64
-	var transactionsCount int
-	categoryStats := make(map[string]*models.CategoryStats)
-
-	for _, account := range accounts {
-		transactions, err := s.accountRepo.GetTransactions(ctx, account.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, t := range transactions {
-			if t.CreatedAt.Before(startOfMonth) || t.CreatedAt.After(endOfMonth) {
-				continue
-			}
+// getMonthlyStats считает доходы и расходы за текущий месяц по категориям операций.
+// Переводы между собственными счетами не считаются ни доходом, ни расходом.
+func (s *AnalyticsService) getMonthlyStats(ctx context.Context, userID int64, loc *time.Location) (*models.MonthlyStats, error) {
+	startOfMonth := periodStart(time.Now().In(loc), models.HistoryGranularityMonth)
 
-			transactionsCount++
-			if t.FromAccountID == account.ID {
-				totalExpenses += t.Amount
-				updateCategoryStats(categoryStats, "expense", t.Amount)
-			} else {
-				totalIncome += t.Amount
-				updateCategoryStats(categoryStats, "income", t.Amount)
-			}
-		}
+	if err := s.categoryService.Categorize(ctx, userID); err != nil {
+		return nil, err
 	}
 
-	// Преобразуем map категорий в слайс
-	topCategories := make([]models.CategoryStats, 0, len(categoryStats))
-	for _, stats := range categoryStats {
-		topCategories = append(topCategories, *stats)
+	totals, err := s.repo.GetCategoryTotals(ctx, userID, startOfMonth, startOfMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
 	}
 
-	return &models.MonthlyStats{
+	stats := &models.MonthlyStats{
 		Month:         startOfMonth,
-		TotalIncome:   totalIncome,
-		TotalExpenses: totalExpenses,
-		NetIncome:     totalIncome - totalExpenses,
-		Transactions:  transactionsCount,
-		TopCategories: topCategories,
-	}, nil
+		TopCategories: []models.CategoryStats{},
+	}
+	for _, total := range totals {
+		stats.Transactions += total.Count
+		switch total.Direction {
+		case models.DirectionIncome:
+			stats.TotalIncome += total.Amount
+		case models.DirectionExpense:
+			stats.TotalExpenses += total.Amount
+			// Суммы расходов уже упорядочены по убыванию
+			stats.TopCategories = append(stats.TopCategories, models.CategoryStats{
+				Category: total.Category,
+				Amount:   total.Amount,
+				Count:    total.Count,
+			})
+		}
+	}
+	stats.NetIncome = stats.TotalIncome - stats.TotalExpenses
+
+	return stats, nil
 }
 
-func (s *AnalyticsService) getCreditLoad(ctx context.Context, credits []*models.Credit, monthlyIncome float64) (*models.CreditLoad, error) {
-	var totalCredits, totalDebt, monthlyPayments float64
+func (s *AnalyticsService) getCreditLoad(ctx context.Context, userID int64, loc *time.Location, credits []*models.Credit, creditLines []*models.CreditLine, accounts []*models.Account, monthlyIncome float64) (*models.CreditLoad, error) {
+	var totalCredits, monthlyPayments float64
 	activeCredits := 0
 
 	for _, credit := range credits {
 		if credit.Status == "active" {
 			activeCredits++
 			totalCredits += credit.Amount
-			totalDebt += credit.Amount // В реальном приложении нужно учитывать уже выплаченную часть
+		}
+	}
 
-			// Получаем график платежей
-			schedule, err := s.creditRepo.GetPaymentSchedule(ctx, credit.ID)
-			if err != nil {
-				return nil, err
-			}
+	// Остаток долга - непогашенная часть основного долга по графику, поэтому
+	// учитывается тип погашения (при bullet-погашении долг не уменьшается до конца срока)
+	totalDebt, err := s.repo.GetUnpaidPrincipal(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 
-			// Считаем платежи за текущий месяц
-			now := time.Now()
-			startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-			endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Second)
+	// Считаем платежи за текущий месяц
+	startOfMonth := periodStart(time.Now().In(loc), models.HistoryGranularityMonth)
+	schedule, err := s.repo.GetSchedule(ctx, userID, startOfMonth, startOfMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range schedule {
+		monthlyPayments += payment.Amount
+	}
 
-			for _, payment := range schedule {
-				if payment.DueDate.After(startOfMonth) && payment.DueDate.Before(endOfMonth) {
-					monthlyPayments += payment.Amount
-				}
-			}
+	// Задолженность по кредитным линиям - отрицательный баланс счета и начисленные проценты
+	balances := make(map[int64]float64, len(accounts))
+	for _, account := range accounts {
+		balances[account.ID] = account.Balance
+	}
+
+	var creditLimit, creditLineDebt float64
+	for _, line := range creditLines {
+		if line.Status != models.CreditLineStatusActive {
+			continue
+		}
+		creditLimit += line.CreditLimit
+		creditLineDebt += line.AccruedInterest
+		if balance := balances[line.AccountID]; balance < 0 {
+			creditLineDebt -= balance
+		}
+
+		// Минимальные платежи по невнесенным выпискам
+		statements, err := s.creditLineRepo.GetOpenStatements(ctx, line.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, statement := range statements {
+			monthlyPayments += statement.MinPayment
 		}
 	}
+	totalDebt += creditLineDebt
 
+	// Использование кредитного лимита по возобновляемым линиям
 	creditUtilization := 0.0
-	if totalCredits > 0 {
-		creditUtilization = (totalDebt / totalCredits) * 100
+	if creditLimit > 0 {
+		creditUtilization = (creditLineDebt / creditLimit) * 100
 	}
 
 	paymentToIncome := 0.0
@@ -148,14 +211,16 @@ func (s *AnalyticsService) getCreditLoad(ctx context.Context, credits []*models.
 		ActiveCredits:     activeCredits,
 		MonthlyPayments:   monthlyPayments,
 		TotalDebt:         totalDebt,
+		CreditLimit:       creditLimit,
+		CreditLineDebt:    creditLineDebt,
 		CreditUtilization: creditUtilization,
 		PaymentToIncome:   paymentToIncome,
 	}, nil
 }
 
-func (s *AnalyticsService) getBalanceForecast(ctx context.Context, accounts []*models.Account, credits []*models.Credit, days int) (*models.BalanceForecast, error) {
-	now := time.Now()
-	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+func (s *AnalyticsService) getBalanceForecast(ctx context.Context, userID int64, loc *time.Location, accounts []*models.Account, days int) (*models.BalanceForecast, error) {
+	now := time.Now().In(loc)
+	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	endDate := startDate.AddDate(0, 0, days)
 
 	// Считаем начальный баланс
@@ -164,60 +229,424 @@ func (s *AnalyticsService) getBalanceForecast(ctx context.Context, accounts []*m
 		initialBalance += account.Balance
 	}
 
-	// Создаем прогноз по дням
-	forecastDays := make([]models.ForecastDay, days)
-	currentBalance := initialBalance
+	// Запланированные платежи по кредитам за весь период загружаются одним запросом
+	schedule, err := s.repo.GetSchedule(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < days; i++ {
-		currentDate := startDate.AddDate(0, 0, i)
-		var plannedIncome, plannedExpenses float64
+	plannedIncome := make(map[string]float64)
+	plannedExpenses := make(map[string]float64)
+	uncertainty := make(map[string]float64)
+	for _, payment := range schedule {
+		if payment.Status != "completed" {
+			// Дата платежа - календарная дата графика, в часовой пояс пользователя она не переводится
+			plannedExpenses[dayKey(payment.DueDate)] += payment.Amount
+		}
+	}
 
-		// Считаем запланированные платежи по кредитам
-		for _, credit := range credits {
-			if credit.Status != "active" {
-				continue
+	// Регулярные поступления и платежи выявляются по истории операций
+	// (категории уже обновлены при расчете статистики за месяц)
+	history, err := s.repo.GetCounterpartyHistory(ctx, userID, startDate.AddDate(0, -recurringLookbackMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+	recurring := detectRecurring(history, now)
+
+	// Переводы по постоянным поручениям известны точно. Между собственными счетами они не меняют
+	// общий баланс, а регулярные операции с теми же контрагентами исключаются, чтобы не учитывать их дважды.
+	accountIDs := make([]int64, 0, len(accounts))
+	ownAccounts := make(map[int64]bool, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+		ownAccounts[account.ID] = true
+	}
+	transfers, err := s.standingOrders.scheduledTransfers(ctx, accountIDs, startDate, endDate, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Номера счетов контрагентов загружаются одним запросом, а не для каждого перевода
+	var counterpartyIDs []int64
+	requested := make(map[int64]bool)
+	for _, transfer := range transfers {
+		for _, id := range []int64{transfer.Order.FromAccountID, transfer.Order.ToAccountID} {
+			if !ownAccounts[id] && !requested[id] {
+				requested[id] = true
+				counterpartyIDs = append(counterpartyIDs, id)
 			}
+		}
+	}
+	numbers := make(map[int64]string)
+	if len(counterpartyIDs) > 0 {
+		numbers, err = s.accountRepo.GetNumbers(ctx, counterpartyIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	counterparties := make(map[[2]string]bool)
+	for _, transfer := range transfers {
+		fromOwn, toOwn := ownAccounts[transfer.Order.FromAccountID], ownAccounts[transfer.Order.ToAccountID]
+		if fromOwn && toOwn {
+			continue
+		}
 
-			schedule, err := s.creditRepo.GetPaymentSchedule(ctx, credit.ID)
-			if err != nil {
-				return nil, err
+		direction, counterpartyID := models.DirectionExpense, transfer.Order.ToAccountID
+		if !fromOwn {
+			direction, counterpartyID = models.DirectionIncome, transfer.Order.FromAccountID
+		}
+		key := dayKey(transfer.Date.In(loc))
+		if direction == models.DirectionIncome {
+			plannedIncome[key] += transfer.Order.Amount
+		} else {
+			plannedExpenses[key] += transfer.Order.Amount
+		}
+
+		counterparties[[2]string{direction, numbers[counterpartyID]}] = true
+	}
+	if len(counterparties) > 0 {
+		filtered := recurring[:0]
+		for _, payment := range recurring {
+			if !counterparties[[2]string{payment.Direction, payment.Counterparty}] {
+				filtered = append(filtered, payment)
 			}
+		}
+		recurring = filtered
+	}
 
-			for _, payment := range schedule {
-				if payment.DueDate.Equal(currentDate) {
-					plannedExpenses += payment.Amount
-				}
+	for _, payment := range recurring {
+		for k := 1; ; k++ {
+			date := nextOccurrence(payment, k)
+			if date.Before(startDate) {
+				continue
+			}
+			if !date.Before(endDate) {
+				break
+			}
+			key := dayKey(date)
+			if payment.Direction == models.DirectionIncome {
+				plannedIncome[key] += payment.Amount
+			} else {
+				plannedExpenses[key] += payment.Amount
 			}
+			uncertainty[key] += occurrenceUncertainty(payment)
 		}
+	}
+
+	forecast := &models.BalanceForecast{
+		StartDate:         startDate,
+		EndDate:           endDate,
+		InitialBalance:    initialBalance,
+		ForecastDays:      make([]models.ForecastDay, days),
+		RecurringPayments: recurring,
+	}
 
-		// В реальном приложении здесь можно добавить учет других запланированных доходов/расходов
+	// Создаем прогноз по дням. Доверительный интервал расширяется на неопределенность
+	// каждого регулярного поступления или платежа; платежи по графику кредитов и поручениям считаются точными.
+	currentBalance := initialBalance
+	var spread float64
+	for i := 0; i < days; i++ {
+		currentDate := startDate.AddDate(0, 0, i)
+		key := dayKey(currentDate)
 
-		currentBalance += plannedIncome - plannedExpenses
+		currentBalance += plannedIncome[key] - plannedExpenses[key]
+		spread += uncertainty[key]
 
-		forecastDays[i] = models.ForecastDay{
+		day := models.ForecastDay{
 			Date:            currentDate,
-			ExpectedBalance: currentBalance,
-			PlannedIncome:   plannedIncome,
-			PlannedExpenses: plannedExpenses,
+			ExpectedBalance: roundMoney(currentBalance),
+			LowerBalance:    roundMoney(currentBalance - spread),
+			UpperBalance:    roundMoney(currentBalance + spread),
+			PlannedIncome:   roundMoney(plannedIncome[key]),
+			PlannedExpenses: roundMoney(plannedExpenses[key]),
+		}
+		forecast.ForecastDays[i] = day
+
+		if day.ExpectedBalance < 0 && forecast.NegativeBalanceDate == nil {
+			forecast.NegativeBalanceDate = &forecast.ForecastDays[i].Date
+		}
+		if day.LowerBalance < 0 && forecast.NegativeBalanceRiskDate == nil {
+			forecast.NegativeBalanceRiskDate = &forecast.ForecastDays[i].Date
 		}
 	}
 
-	return &models.BalanceForecast{
-		StartDate:      startDate,
-		EndDate:        endDate,
-		InitialBalance: initialBalance,
-		ForecastDays:   forecastDays,
-	}, nil
+	return forecast, nil
+}
+
+// GetHistory возвращает доходы, расходы и разбивку по категориям за последние months месяцев
+// с гранулярностью granularity (month, week). Периоды считаются в часовом поясе пользователя,
+// последний (текущий) период не завершен и не учитывается в средних значениях.
+func (s *AnalyticsService) GetHistory(ctx context.Context, userID int64, months int, granularity string) (*models.AnalyticsHistory, error) {
+	if months == 0 {
+		months = defaultHistoryMonths
+	}
+	if granularity == "" {
+		granularity = models.HistoryGranularityMonth
+	}
+	if months < 1 || months > maxHistoryMonths {
+		return nil, ErrInvalidHistoryParams
+	}
+	if granularity != models.HistoryGranularityMonth && granularity != models.HistoryGranularityWeek {
+		return nil, ErrInvalidHistoryParams
+	}
+
+	loc, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Начало истории - первый день месяца months-1 месяцев назад (для недель - начало его недели),
+	// конец - окончание текущего периода
+	now := time.Now().In(loc)
+	from := periodStart(periodStart(now, models.HistoryGranularityMonth).AddDate(0, 1-months, 0), granularity)
+	current := periodStart(now, granularity)
+	to := nextPeriod(current, granularity)
+
+	if err := s.categoryService.Categorize(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.GetPeriodTotals(ctx, userID, from, to, granularity, loc.String())
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.AnalyticsHistory{
+		Granularity: granularity,
+		Timezone:    loc.String(),
+		From:        from,
+		To:          to,
+		Periods:     []models.HistoryPeriod{},
+	}
+	index := make(map[string]int)
+	for start := from; start.Before(to); start = nextPeriod(start, granularity) {
+		index[dayKey(start)] = len(history.Periods)
+		history.Periods = append(history.Periods, models.HistoryPeriod{
+			Start:      start,
+			End:        nextPeriod(start, granularity),
+			Categories: []models.CategoryTotal{},
+			Complete:   start.Before(current),
+		})
+	}
+
+	// Начало периода возвращается как время по часам пользователя, поэтому сравнивается только дата
+	for _, total := range totals {
+		i, ok := index[dayKey(total.Period)]
+		if !ok {
+			continue
+		}
+		period := &history.Periods[i]
+		period.Transactions += total.Count
+		switch total.Direction {
+		case models.DirectionIncome:
+			period.TotalIncome += total.Amount
+		case models.DirectionExpense:
+			period.TotalExpenses += total.Amount
+		default:
+			// Переводы между собственными счетами не входят в разбивку по категориям
+			continue
+		}
+		period.Categories = append(period.Categories, total.CategoryTotal)
+	}
+
+	for i := range history.Periods {
+		period := &history.Periods[i]
+		period.TotalIncome = roundMoney(period.TotalIncome)
+		period.TotalExpenses = roundMoney(period.TotalExpenses)
+		period.NetIncome = roundMoney(period.TotalIncome - period.TotalExpenses)
+		if i > 0 {
+			period.Change = periodChange(&history.Periods[i-1], period)
+		}
+	}
+
+	history.Averages = historyAverages(history.Periods)
+
+	return history, nil
 }
 
-func updateCategoryStats(stats map[string]*models.CategoryStats, category string, amount float64) {
-	if _, exists := stats[category]; !exists {
-		stats[category] = &models.CategoryStats{
-			Category: category,
-			Amount:   0,
-			Count:    0,
+// GetScoringData собирает данные о доходах, кредитной нагрузке и активности клиента
+// для скоринга кредитной заявки за последние months месяцев
+func (s *AnalyticsService) GetScoringData(ctx context.Context, userID int64, months int) (*models.ScoringData, error) {
+	accounts, err := s.accountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := s.creditRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	periodStart := now.AddDate(0, -months, 0)
+	data := &models.ScoringData{}
+
+	accountIDs := make([]int64, 0, len(accounts))
+	oldestAccount := now
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+		if account.CreatedAt.Before(oldestAccount) {
+			oldestAccount = account.CreatedAt
 		}
 	}
-	stats[category].Amount += amount
-	stats[category].Count++
-} 
\ No newline at end of file
+	data.AccountAgeDays = int(now.Sub(oldestAccount).Hours() / 24)
+
+	// Подтвержденным доходом считаются поступления со счетов других клиентов
+	var totalIncome float64
+	if len(accountIDs) > 0 {
+		data.TransactionsCount, totalIncome, err = s.repo.GetIncomeStats(ctx, accountIDs, periodStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Доход усредняется по фактическому сроку обслуживания, но не более чем за months месяцев
+	incomeMonths := data.AccountAgeDays / 30
+	if incomeMonths > months {
+		incomeMonths = months
+	}
+	if incomeMonths < 1 {
+		incomeMonths = 1
+	}
+	data.MonthlyIncome = totalIncome / float64(incomeMonths)
+
+	// Среднемесячный платеж по кредиту - среднее по неоплаченным платежам ближайших 12 месяцев
+	paymentsHorizon := now.AddDate(1, 0, 0)
+	for _, credit := range credits {
+		if credit.Status != "active" {
+			continue
+		}
+		data.ActiveCredits++
+
+		schedule, err := s.creditRepo.GetPaymentSchedule(ctx, credit.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		var upcomingTotal float64
+		var upcomingCount int
+		for _, payment := range schedule {
+			if payment.Status == "overdue" {
+				data.OverduePayments++
+			}
+			if payment.Status != "completed" && payment.DueDate.Before(paymentsHorizon) {
+				upcomingTotal += payment.Amount
+				upcomingCount++
+			}
+		}
+		if upcomingCount > 0 {
+			data.MonthlyPayments += upcomingTotal / float64(upcomingCount)
+		}
+	}
+
+	return data, nil
+}
+
+// dayKey возвращает календарную дату для группировки по дням
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// userLocation возвращает часовой пояс пользователя
+func (s *AnalyticsService) userLocation(ctx context.Context, userID int64) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return userTimezone(user)
+}
+
+// userTimezone возвращает часовой пояс, в котором считаются календарные периоды пользователя
+func userTimezone(user *models.User) (*time.Location, error) {
+	timezone := user.Timezone
+	if timezone == "" {
+		timezone = models.DefaultTimezone
+	}
+	return time.LoadLocation(timezone)
+}
+
+// periodStart возвращает начало месяца или недели (с понедельника), которым принадлежит t
+func periodStart(t time.Time, granularity string) time.Time {
+	if granularity == models.HistoryGranularityWeek {
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-weekday, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// nextPeriod возвращает начало периода, следующего за периодом с началом start
+func nextPeriod(start time.Time, granularity string) time.Time {
+	if granularity == models.HistoryGranularityWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// periodChange считает изменение показателей периода относительно предыдущего
+func periodChange(previous, period *models.HistoryPeriod) *models.PeriodChange {
+	change := &models.PeriodChange{
+		Income:    roundMoney(period.TotalIncome - previous.TotalIncome),
+		Expenses:  roundMoney(period.TotalExpenses - previous.TotalExpenses),
+		NetIncome: roundMoney(period.NetIncome - previous.NetIncome),
+	}
+	if previous.TotalIncome != 0 {
+		percent := math.Round(change.Income/previous.TotalIncome*10000) / 100
+		change.IncomePercent = &percent
+	}
+	if previous.TotalExpenses != 0 {
+		percent := math.Round(change.Expenses/previous.TotalExpenses*10000) / 100
+		change.ExpensesPercent = &percent
+	}
+	return change
+}
+
+// historyAverages считает средние значения по завершенным периодам
+func historyAverages(periods []models.HistoryPeriod) models.HistoryAverages {
+	averages := models.HistoryAverages{Categories: []models.CategoryAverage{}}
+
+	var income, expenses float64
+	categoryTotals := make(map[[2]string]float64)
+	for _, period := range periods {
+		if !period.Complete {
+			continue
+		}
+		averages.Periods++
+		income += period.TotalIncome
+		expenses += period.TotalExpenses
+		for _, category := range period.Categories {
+			categoryTotals[[2]string{category.Direction, category.Category}] += category.Amount
+		}
+	}
+	if averages.Periods == 0 {
+		return averages
+	}
+
+	n := float64(averages.Periods)
+	averages.Income = roundMoney(income / n)
+	averages.Expenses = roundMoney(expenses / n)
+	averages.NetIncome = roundMoney((income - expenses) / n)
+	for key, amount := range categoryTotals {
+		averages.Categories = append(averages.Categories, models.CategoryAverage{
+			Direction: key[0],
+			Category:  key[1],
+			Amount:    roundMoney(amount / n),
+		})
+	}
+	sort.Slice(averages.Categories, func(i, j int) bool {
+		a, b := averages.Categories[i], averages.Categories[j]
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		return a.Category < b.Category
+	})
+
+	return averages
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Бенчмарки агрегатов аналитики на данных в памяти. Репозитории только отдают заранее
+// подготовленные строки и считают обращения, поэтому измеряется обработка на стороне сервиса,
+// а метрика queries/op показывает число запросов к базе на один расчет.
+
+const (
+	benchUserID   = 1
+	benchAccounts = 5
+	benchCredits  = 10
+)
+
+// benchQueries - счетчик обращений к репозиториям
+type benchQueries struct {
+	count int
+}
+
+func (q *benchQueries) inc() {
+	q.count++
+}
+
+type benchUserRepo struct {
+	repository.UserRepository
+	queries *benchQueries
+}
+
+func (r *benchUserRepo) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	r.queries.inc()
+	return &models.User{ID: id, Timezone: models.DefaultTimezone}, nil
+}
+
+type benchAccountRepo struct {
+	repository.AccountRepository
+	queries  *benchQueries
+	accounts []*models.Account
+}
+
+func (r *benchAccountRepo) GetNumbers(ctx context.Context, ids []int64) (map[int64]string, error) {
+	r.queries.inc()
+	numbers := make(map[int64]string, len(ids))
+	for _, id := range ids {
+		numbers[id] = fmt.Sprintf("40817810%012d", id)
+	}
+	return numbers, nil
+}
+
+func (r *benchAccountRepo) GetByUserID(ctx context.Context, userID int64) ([]*models.Account, error) {
+	r.queries.inc()
+	return r.accounts, nil
+}
+
+type benchCreditRepo struct {
+	repository.CreditRepository
+	queries  *benchQueries
+	credits  []*models.Credit
+	schedule []*models.PaymentSchedule
+}
+
+func (r *benchCreditRepo) GetByUserID(ctx context.Context, userID int64) ([]*models.Credit, error) {
+	r.queries.inc()
+	return r.credits, nil
+}
+
+func (r *benchCreditRepo) GetPaymentSchedule(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error) {
+	r.queries.inc()
+	return r.schedule, nil
+}
+
+type benchCreditLineRepo struct {
+	repository.CreditLineRepository
+	queries *benchQueries
+}
+
+func (r *benchCreditLineRepo) GetByUserID(ctx context.Context, userID int64) ([]*models.CreditLine, error) {
+	r.queries.inc()
+	return []*models.CreditLine{{ID: 1, AccountID: 1, CreditLimit: 100000, Status: models.CreditLineStatusActive}}, nil
+}
+
+func (r *benchCreditLineRepo) GetOpenStatements(ctx context.Context, creditLineID int64) ([]*models.CreditLineStatement, error) {
+	r.queries.inc()
+	return []*models.CreditLineStatement{{CreditLineID: creditLineID, MinPayment: 1500}}, nil
+}
+
+type benchCategoryRepo struct {
+	repository.CategoryRepository
+	queries *benchQueries
+}
+
+func (r *benchCategoryRepo) GetRules(ctx context.Context, userID *int64) ([]*models.CategoryRule, error) {
+	r.queries.inc()
+	return nil, nil
+}
+
+func (r *benchCategoryRepo) GetUncategorized(ctx context.Context, userID int64, accountIDs []int64, limit int) ([]*models.UncategorizedTransaction, error) {
+	r.queries.inc()
+	return nil, nil
+}
+
+type benchStandingOrderRepo struct {
+	repository.StandingOrderRepository
+	queries *benchQueries
+	orders  []*models.StandingOrder
+}
+
+func (r *benchStandingOrderRepo) GetActiveByAccounts(ctx context.Context, accountIDs []int64) ([]*models.StandingOrder, error) {
+	r.queries.inc()
+	return r.orders, nil
+}
+
+type benchAnalyticsRepo struct {
+	queries  *benchQueries
+	totals   []*models.CategoryTotal
+	periods  []*models.PeriodTotal
+	history  []*models.CounterpartyTransaction
+	schedule []*models.PaymentSchedule
+}
+
+func (r *benchAnalyticsRepo) GetCategoryTotals(ctx context.Context, userID int64, from, to time.Time) ([]*models.CategoryTotal, error) {
+	r.queries.inc()
+	return r.totals, nil
+}
+
+func (r *benchAnalyticsRepo) GetPeriodTotals(ctx context.Context, userID int64, from, to time.Time, granularity, timezone string) ([]*models.PeriodTotal, error) {
+	r.queries.inc()
+	return r.periods, nil
+}
+
+func (r *benchAnalyticsRepo) GetCounterpartyHistory(ctx context.Context, userID int64, from time.Time) ([]*models.CounterpartyTransaction, error) {
+	r.queries.inc()
+	return r.history, nil
+}
+
+func (r *benchAnalyticsRepo) GetSchedule(ctx context.Context, userID int64, from, to time.Time) ([]*models.PaymentSchedule, error) {
+	r.queries.inc()
+	return r.schedule, nil
+}
+
+func (r *benchAnalyticsRepo) GetUnpaidPrincipal(ctx context.Context, userID int64) (float64, error) {
+	r.queries.inc()
+	return 500000, nil
+}
+
+func (r *benchAnalyticsRepo) GetIncomeStats(ctx context.Context, accountIDs []int64, from time.Time) (int, float64, error) {
+	r.queries.inc()
+	return 120, 900000, nil
+}
+
+// newBenchAnalyticsService собирает сервис аналитики с типичным для активного клиента объемом данных:
+// несколько счетов и кредитов, год истории операций и еженедельные постоянные поручения
+func newBenchAnalyticsService(queries *benchQueries) *AnalyticsService {
+	now := time.Now()
+
+	accounts := make([]*models.Account, benchAccounts)
+	for i := range accounts {
+		accounts[i] = &models.Account{ID: int64(i + 1), UserID: benchUserID, Balance: 50000, CreatedAt: now.AddDate(-2, 0, 0)}
+	}
+
+	credits := make([]*models.Credit, benchCredits)
+	var schedule []*models.PaymentSchedule
+	for i := range credits {
+		credits[i] = &models.Credit{ID: int64(i + 1), UserID: benchUserID, Amount: 300000, Status: "active"}
+		for month := 0; month < 36; month++ {
+			schedule = append(schedule, &models.PaymentSchedule{
+				CreditID: credits[i].ID,
+				Amount:   9500,
+				DueDate:  now.AddDate(0, month, 0),
+				Status:   "pending",
+			})
+		}
+	}
+
+	categories := []string{"groceries", "restaurants", "transport", "utilities", "entertainment", "health"}
+	var totals []*models.CategoryTotal
+	for _, category := range categories {
+		totals = append(totals, &models.CategoryTotal{Direction: models.DirectionExpense, Category: category, Amount: 12000, Count: 30})
+	}
+	totals = append(totals, &models.CategoryTotal{Direction: models.DirectionIncome, Category: "salary", Amount: 150000, Count: 2})
+
+	var periods []*models.PeriodTotal
+	for week := 0; week < maxHistoryMonths*5; week++ {
+		start := periodStart(now.AddDate(0, 0, -7*week), models.HistoryGranularityWeek)
+		for _, total := range totals {
+			periods = append(periods, &models.PeriodTotal{Period: start, CategoryTotal: *total})
+		}
+	}
+
+	// Год истории: зарплата дважды в месяц, ежемесячные платежи и случайные покупки
+	var history []*models.CounterpartyTransaction
+	for day := 0; day < 365; day++ {
+		date := now.AddDate(0, 0, -day)
+		if date.Day() == 5 || date.Day() == 20 {
+			history = append(history, &models.CounterpartyTransaction{Direction: models.DirectionIncome, Category: "salary", Counterparty: "40702810000000000001", Amount: 75000, CreatedAt: date})
+		}
+		if date.Day() == 10 {
+			history = append(history, &models.CounterpartyTransaction{Direction: models.DirectionExpense, Category: "utilities", Counterparty: "40702810000000000002", Amount: 6500, CreatedAt: date})
+		}
+		history = append(history, &models.CounterpartyTransaction{Direction: models.DirectionExpense, Category: "groceries", Counterparty: fmt.Sprintf("40702810%012d", 100+day), Amount: float64(300 + day%700), CreatedAt: date})
+	}
+
+	var orders []*models.StandingOrder
+	for i := 0; i < 10; i++ {
+		dayOfWeek := i%7 + 1
+		next := now.AddDate(0, 0, 1)
+		orders = append(orders, &models.StandingOrder{
+			ID:             int64(i + 1),
+			UserID:         benchUserID,
+			FromAccountID:  1,
+			ToAccountID:    int64(1000 + i),
+			Amount:         2500,
+			Schedule:       models.StandingOrderWeekly,
+			DayOfWeek:      &dayOfWeek,
+			Status:         models.StandingOrderStatusActive,
+			NextOccurrence: &next,
+			NextRunAt:      &next,
+		})
+	}
+
+	userRepo := &benchUserRepo{queries: queries}
+	accountRepo := &benchAccountRepo{queries: queries, accounts: accounts}
+	creditRepo := &benchCreditRepo{queries: queries, credits: credits, schedule: schedule[:36]}
+	analyticsRepo := &benchAnalyticsRepo{
+		queries:  queries,
+		totals:   totals,
+		periods:  periods,
+		history:  history,
+		schedule: schedule,
+	}
+
+	categoryService := NewCategoryService(&benchCategoryRepo{queries: queries}, accountRepo)
+	standingOrders := NewStandingOrderService(&benchStandingOrderRepo{queries: queries, orders: orders}, nil, userRepo, StandingOrderTerms{})
+
+	return NewAnalyticsService(analyticsRepo, userRepo, accountRepo, creditRepo, &benchCreditLineRepo{queries: queries}, categoryService, nil, standingOrders)
+}
+
+func reportQueries(b *testing.B, queries *benchQueries) {
+	b.ReportMetric(float64(queries.count)/float64(b.N), "queries/op")
+}
+
+func BenchmarkMonthlyStats(b *testing.B) {
+	queries := &benchQueries{}
+	s := newBenchAnalyticsService(queries)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation(models.DefaultTimezone)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.getMonthlyStats(ctx, benchUserID, loc); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportQueries(b, queries)
+}
+
+func BenchmarkCreditLoad(b *testing.B) {
+	queries := &benchQueries{}
+	s := newBenchAnalyticsService(queries)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation(models.DefaultTimezone)
+
+	accounts, _ := s.accountRepo.GetByUserID(ctx, benchUserID)
+	credits, _ := s.creditRepo.GetByUserID(ctx, benchUserID)
+	creditLines, _ := s.creditLineRepo.GetByUserID(ctx, benchUserID)
+	queries.count = 0
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.getCreditLoad(ctx, benchUserID, loc, credits, creditLines, accounts, 150000); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportQueries(b, queries)
+}
+
+func BenchmarkBalanceForecast(b *testing.B) {
+	for _, days := range []int{30, 365} {
+		b.Run(fmt.Sprintf("days=%d", days), func(b *testing.B) {
+			queries := &benchQueries{}
+			s := newBenchAnalyticsService(queries)
+			ctx := context.Background()
+			loc, _ := time.LoadLocation(models.DefaultTimezone)
+
+			accounts, _ := s.accountRepo.GetByUserID(ctx, benchUserID)
+			queries.count = 0
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.getBalanceForecast(ctx, benchUserID, loc, accounts, days); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportQueries(b, queries)
+		})
+	}
+}
+
+func BenchmarkHistory(b *testing.B) {
+	for _, granularity := range []string{models.HistoryGranularityMonth, models.HistoryGranularityWeek} {
+		b.Run(granularity, func(b *testing.B) {
+			queries := &benchQueries{}
+			s := newBenchAnalyticsService(queries)
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.GetHistory(ctx, benchUserID, maxHistoryMonths, granularity); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportQueries(b, queries)
+		})
+	}
+}
+
+func BenchmarkScoringData(b *testing.B) {
+	queries := &benchQueries{}
+	s := newBenchAnalyticsService(queries)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetScoringData(ctx, benchUserID, scoringPeriodMonths); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportQueries(b, queries)
+}
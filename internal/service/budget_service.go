@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"bank-api/pkg/email"
+	"errors"
+	"log"
+	"math"
+	"time"
+)
+
+var (
+	ErrBudgetNotFound      = errors.New("budget not found")
+	ErrBudgetExists        = errors.New("budget for this category already exists")
+	ErrInvalidBudgetAmount = errors.New("budget amount must be positive")
+)
+
+// budgetAlertThresholds - пороги расходования бюджета (в процентах) для уведомлений, по убыванию
+var budgetAlertThresholds = []int{100, 80}
+
+type BudgetService struct {
+	repo            repository.BudgetRepository
+	analyticsRepo   repository.AnalyticsRepository
+	userRepo        repository.UserRepository
+	categoryService *CategoryService
+	emailClient     *email.Client
+}
+
+func NewBudgetService(repo repository.BudgetRepository, analyticsRepo repository.AnalyticsRepository, userRepo repository.UserRepository, categoryService *CategoryService, emailClient *email.Client) *BudgetService {
+	return &BudgetService{
+		repo:            repo,
+		analyticsRepo:   analyticsRepo,
+		userRepo:        userRepo,
+		categoryService: categoryService,
+		emailClient:     emailClient,
+	}
+}
+
+// Create создает месячный бюджет по категории расходов. На категорию допускается один бюджет.
+func (s *BudgetService) Create(ctx context.Context, userID int64, input models.BudgetCreate) (*models.BudgetProgress, error) {
+	// Зарплата - категория поступлений, бюджет на нее не имеет смысла
+	if !validCategory(input.Category) || input.Category == models.CategorySalary {
+		return nil, ErrInvalidCategory
+	}
+	if input.Amount <= 0 {
+		return nil, ErrInvalidBudgetAmount
+	}
+
+	budget := &models.Budget{
+		UserID:   userID,
+		Category: input.Category,
+		Amount:   roundMoney(input.Amount),
+	}
+	if err := s.repo.Create(ctx, budget); err != nil {
+		if err == repository.ErrBudgetExists {
+			return nil, ErrBudgetExists
+		}
+		return nil, err
+	}
+
+	return s.getProgress(ctx, userID, budget)
+}
+
+// GetBudgets возвращает бюджеты пользователя с расходованием в текущем месяце
+func (s *BudgetService) GetBudgets(ctx context.Context, userID int64) ([]*models.BudgetProgress, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getBudgets(ctx, userID, loc)
+}
+
+func (s *BudgetService) GetByID(ctx context.Context, userID, id int64) (*models.BudgetProgress, error) {
+	budget, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getProgress(ctx, userID, budget)
+}
+
+// Update изменяет лимит бюджета. Уведомления за текущий месяц сбрасываются,
+// чтобы пороги проверялись относительно нового лимита.
+func (s *BudgetService) Update(ctx context.Context, userID, id int64, input models.BudgetUpdate) (*models.BudgetProgress, error) {
+	if input.Amount <= 0 {
+		return nil, ErrInvalidBudgetAmount
+	}
+
+	budget, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.Amount = roundMoney(input.Amount)
+	if err := s.repo.Update(ctx, budget); err != nil {
+		return nil, err
+	}
+
+	progress, err := s.getProgress(ctx, userID, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ResetAlerts(ctx, budget.ID, progress.Month); err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func (s *BudgetService) Delete(ctx context.Context, userID, id int64) error {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+// CheckBudgets проверяет расходование бюджетов всех пользователей и отправляет email-уведомления
+// о достижении 80% и 100% лимита. О каждом пороге уведомление отправляется один раз в месяц;
+// если пройдены сразу оба порога, отправляется только уведомление об исчерпании бюджета.
+func (s *BudgetService) CheckBudgets(ctx context.Context) error {
+	userIDs, err := s.repo.GetUserIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			continue
+		}
+
+		loc, err := userTimezone(user)
+		if err != nil {
+			return err
+		}
+
+		budgets, err := s.getBudgets(ctx, userID, loc)
+		if err != nil {
+			return err
+		}
+
+		for _, progress := range budgets {
+			if err := s.alert(ctx, user, progress); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Вспомогательные функции
+
+// location возвращает часовой пояс пользователя, в котором определяется текущий месяц
+func (s *BudgetService) location(ctx context.Context, userID int64) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return userTimezone(user)
+}
+
+func (s *BudgetService) getOwned(ctx context.Context, userID, id int64) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(ctx, id)
+	if err != nil || budget.UserID != userID {
+		return nil, ErrBudgetNotFound
+	}
+
+	return budget, nil
+}
+
+func (s *BudgetService) getProgress(ctx context.Context, userID int64, budget *models.Budget) (*models.BudgetProgress, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	spent, err := s.getSpending(ctx, userID, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return budgetProgress(budget, currentMonth(loc), spent[budget.Category]), nil
+}
+
+// getBudgets возвращает бюджеты пользователя с расходованием в текущем месяце в часовом поясе loc
+func (s *BudgetService) getBudgets(ctx context.Context, userID int64, loc *time.Location) ([]*models.BudgetProgress, error) {
+	budgets, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]*models.BudgetProgress, 0, len(budgets))
+	if len(budgets) == 0 {
+		return progress, nil
+	}
+
+	spent, err := s.getSpending(ctx, userID, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	month := currentMonth(loc)
+	for _, budget := range budgets {
+		progress = append(progress, budgetProgress(budget, month, spent[budget.Category]))
+	}
+
+	return progress, nil
+}
+
+// getSpending возвращает расходы пользователя за текущий месяц по категориям
+func (s *BudgetService) getSpending(ctx context.Context, userID int64, loc *time.Location) (map[string]float64, error) {
+	if err := s.categoryService.Categorize(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	month := currentMonth(loc)
+	totals, err := s.analyticsRepo.GetCategoryTotals(ctx, userID, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make(map[string]float64)
+	for _, total := range totals {
+		if total.Direction == models.DirectionExpense {
+			spent[total.Category] += total.Amount
+		}
+	}
+
+	return spent, nil
+}
+
+// alert сохраняет достигнутые пороги бюджета и уведомляет пользователя о наибольшем из новых.
+// Ошибка отправки письма не прерывает проверку остальных бюджетов.
+func (s *BudgetService) alert(ctx context.Context, user *models.User, progress *models.BudgetProgress) error {
+	notify := 0
+	for _, threshold := range budgetAlertThresholds {
+		if progress.Percent < float64(threshold) {
+			continue
+		}
+
+		created, err := s.repo.SaveAlert(ctx, &models.BudgetAlert{
+			BudgetID:  progress.ID,
+			Month:     progress.Month,
+			Threshold: threshold,
+			Spent:     progress.Spent,
+		})
+		if err != nil {
+			return err
+		}
+		if created && notify == 0 {
+			notify = threshold
+		}
+	}
+
+	if notify == 0 || s.emailClient == nil {
+		return nil
+	}
+
+	if err := s.emailClient.SendBudgetAlert(user.Email, categoryName(progress.Category), progress.Amount, progress.Spent, notify); err != nil {
+		log.Printf("budget %d: failed to send alert: %v", progress.ID, err)
+	}
+
+	return nil
+}
+
+func budgetProgress(budget *models.Budget, month time.Time, spent float64) *models.BudgetProgress {
+	progress := &models.BudgetProgress{
+		Budget:    *budget,
+		Month:     month,
+		Spent:     roundMoney(spent),
+		Remaining: roundMoney(budget.Amount - spent),
+		Status:    models.BudgetStatusOK,
+	}
+	if budget.Amount > 0 {
+		progress.Percent = math.Round(spent/budget.Amount*10000) / 100
+	}
+
+	switch {
+	case progress.Percent >= float64(budgetAlertThresholds[0]):
+		progress.Status = models.BudgetStatusExceeded
+	case progress.Percent >= float64(budgetAlertThresholds[1]):
+		progress.Status = models.BudgetStatusWarning
+	}
+
+	return progress
+}
+
+// currentMonth возвращает начало текущего месяца в часовом поясе loc
+func currentMonth(loc *time.Location) time.Time {
+	return periodStart(time.Now().In(loc), models.HistoryGranularityMonth)
+}
@@ -4,27 +4,585 @@ import (
 	"context"
 	"bank-api/internal/models"
 	"bank-api/internal/repository"
+	"bank-api/pkg/pinblock"
 	"crypto/hmac"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"log"
 	"math/rand"
 	"time"
 )
 
+var (
+	ErrCardNotFound            = errors.New("card not found")
+	ErrCardInactive            = errors.New("card is not active")
+	ErrCardExpired             = errors.New("card is expired")
+	ErrInvalidCardData         = errors.New("invalid card data")
+	ErrChannelDisabled         = errors.New("operation channel is disabled for this card")
+	ErrForeignCurrencyBlocked  = errors.New("foreign currency operations are disabled for this card")
+	ErrTransactionLimit        = errors.New("per-transaction limit exceeded")
+	ErrDailyLimit              = errors.New("daily limit exceeded")
+	ErrMonthlyLimit            = errors.New("monthly limit exceeded")
+	ErrInvalidCardStatus       = errors.New("operation is not allowed in the current card status")
+	ErrInvalidReason           = errors.New("invalid reason code")
+	ErrMerchantNotAllowed      = errors.New("card is locked to another merchant")
+	ErrUnknownProduct          = errors.New("unknown card product")
+	ErrPINNotSet               = errors.New("PIN is not set")
+	ErrPINAlreadySet           = errors.New("PIN is already set")
+	ErrIncorrectPIN            = errors.New("incorrect PIN")
+	ErrPINTriesExceeded        = errors.New("PIN tries exceeded, card is blocked")
+	ErrMerchantAccountNotFound = errors.New("merchant account not found")
+	ErrUnblockNotAllowed       = errors.New("card block can be lifted only by a bank operator")
+)
+
+const (
+	// defaultSingleUseTTL - время жизни одноразовой карты по умолчанию
+	defaultSingleUseTTL = 24 * time.Hour
+	// maxCardNumberAttempts - число попыток сгенерировать уникальный номер карты
+	maxCardNumberAttempts = 5
+	// maxPINTries - число неверных попыток ввода PIN до автоматической блокировки карты
+	maxPINTries = 3
+)
+
 type CardService struct {
-	repo repository.CardRepository
+	repo           repository.CardRepository
+	accountService *AccountService
+	pinEncryptor   *pinblock.Encryptor
 }
 
-func NewCardService(repo repository.CardRepository) *CardService {
-	return &CardService{repo: repo}
+func NewCardService(repo repository.CardRepository, accountService *AccountService, pinEncryptor *pinblock.Encryptor) *CardService {
+	return &CardService{
+		repo:           repo,
+		accountService: accountService,
+		pinEncryptor:   pinEncryptor,
+	}
 }
 
-func (s *CardService) Create(ctx context.Context, input models.CardCreate) (*models.Card, error) {
-	// Генерация номера карты по алгоритму Луна
-	cardNumber := generateCardNumber()
-	
+// Create выпускает карту к счету пользователя
+func (s *CardService) Create(ctx context.Context, userID int64, input models.CardCreate) (*models.Card, error) {
+	account, err := s.accountService.GetByID(ctx, input.AccountID)
+	if err != nil || account.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	card := &models.Card{
+		AccountID:      input.AccountID,
+		CardholderName: input.CardholderName,
+		Type:           input.Type,
+		ProductCode:    input.Product,
+		AutoReissue:    true,
+	}
+
+	if card.Type == "" {
+		card.Type = models.CardTypePhysical
+	}
+
+	switch card.Type {
+	case models.CardTypePhysical:
+		if input.MerchantAccountID != nil {
+			return nil, errors.New("merchant lock is available only for virtual and single-use cards")
+		}
+	case models.CardTypeVirtual:
+		card.MerchantAccountID = input.MerchantAccountID
+	case models.CardTypeSingleUse:
+		ttl := defaultSingleUseTTL
+		if input.TTLMinutes > 0 {
+			ttl = time.Duration(input.TTLMinutes) * time.Minute
+		}
+		validUntil := time.Now().Add(ttl)
+		card.MerchantAccountID = input.MerchantAccountID
+		card.ValidUntil = &validUntil
+		// Одноразовые карты не перевыпускаются
+		card.AutoReissue = false
+	default:
+		return nil, errors.New("invalid card type")
+	}
+
+	if input.AutoReissue != nil && card.Type != models.CardTypeSingleUse {
+		card.AutoReissue = *input.AutoReissue
+	}
+
+	return s.issueCard(ctx, card, s.repo.Create)
+}
+
+func (s *CardService) GetByID(ctx context.Context, userID, id int64) (*models.Card, error) {
+	return s.getOwned(ctx, userID, id)
+}
+
+func (s *CardService) GetByAccountID(ctx context.Context, userID, accountID int64) ([]*models.Card, error) {
+	account, err := s.accountService.GetByID(ctx, accountID)
+	if err != nil || account.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	return s.repo.GetByAccountID(ctx, accountID)
+}
+
+// Deactivate закрывает карту по инициативе клиента
+func (s *CardService) Deactivate(ctx context.Context, userID, id int64) error {
+	card, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.changeStatus(ctx, card, models.CardStatusClosed, models.CardReasonCustomerRequest)
+}
+
+// Block блокирует карту. Утеря и кража блокируют карту безвозвратно,
+// остальные причины - временно, с возможностью разблокировки.
+func (s *CardService) Block(ctx context.Context, userID, id int64, reason string) (*models.Card, error) {
+	card, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var status string
+	switch reason {
+	case models.CardReasonLost:
+		status = models.CardStatusLost
+	case models.CardReasonStolen:
+		status = models.CardStatusStolen
+	case models.CardReasonCustomerRequest, models.CardReasonSuspectedFraud:
+		status = models.CardStatusBlocked
+	default:
+		return nil, ErrInvalidReason
+	}
+
+	if err := s.changeStatus(ctx, card, status, reason); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// Unblock снимает временную блокировку карты по запросу клиента. Клиент может снять
+// только блокировку, установленную по его собственному запросу.
+func (s *CardService) Unblock(ctx context.Context, userID, id int64) (*models.Card, error) {
+	card, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.Status != models.CardStatusBlocked {
+		return nil, ErrInvalidCardStatus
+	}
+
+	if card.StatusReason != models.CardReasonCustomerRequest {
+		return nil, ErrUnblockNotAllowed
+	}
+
+	if err := s.changeStatus(ctx, card, models.CardStatusActive, ""); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// OperatorUnblock снимает временную блокировку карты оператором банка, в том числе
+// блокировку по подозрению в мошенничестве и превышению попыток ввода PIN.
+// Счетчик неверных попыток ввода PIN сбрасывается.
+func (s *CardService) OperatorUnblock(ctx context.Context, id int64) (*models.Card, error) {
+	card, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.Status != models.CardStatusBlocked {
+		return nil, ErrInvalidCardStatus
+	}
+
+	if err := s.changeStatus(ctx, card, models.CardStatusActive, ""); err != nil {
+		return nil, err
+	}
+
+	if card.PINTryCount > 0 {
+		if err := s.repo.ResetPINTries(ctx, card.ID); err != nil {
+			return nil, err
+		}
+		card.PINTryCount = 0
+	}
+
+	return card, nil
+}
+
+// SetPIN устанавливает PIN-код карты, если он еще не задан
+func (s *CardService) SetPIN(ctx context.Context, userID, id int64, pin string) error {
+	card, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if card.Status != models.CardStatusActive {
+		return ErrInvalidCardStatus
+	}
+
+	if card.EncryptedPINBlock != "" {
+		return ErrPINAlreadySet
+	}
+
+	return s.storePIN(ctx, card, pin)
+}
+
+// ChangePIN меняет PIN-код карты после проверки текущего
+func (s *CardService) ChangePIN(ctx context.Context, userID, id int64, oldPIN, newPIN string) error {
+	card, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if card.Status != models.CardStatusActive {
+		return ErrInvalidCardStatus
+	}
+
+	if err := s.verifyPIN(ctx, card, oldPIN); err != nil {
+		return err
+	}
+
+	return s.storePIN(ctx, card, newPIN)
+}
+
+// Reissue выпускает новую карту с новым номером и сроком действия взамен старой,
+// старая карта закрывается. Причина перевыпуска сохраняется на обеих картах.
+func (s *CardService) Reissue(ctx context.Context, userID, id int64, reason string) (*models.Card, error) {
+	switch reason {
+	case models.CardReasonCustomerRequest, models.CardReasonDamaged, models.CardReasonLost,
+		models.CardReasonStolen, models.CardReasonExpired, models.CardReasonSuspectedFraud:
+	default:
+		return nil, ErrInvalidReason
+	}
+
+	card, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reissue(ctx, card, reason)
+}
+
+// ExpireCards переводит карты с истекшим сроком действия в статус expired
+// и перевыпускает те из них, для которых включен автоматический перевыпуск.
+// Предназначена для ежедневного запуска планировщиком. Ошибка по отдельной карте
+// логируется и не прерывает обработку остальных.
+func (s *CardService) ExpireCards(ctx context.Context) error {
+	cards, err := s.repo.GetExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, card := range cards {
+		// Заблокированные карты не перевыпускаются автоматически
+		reissue := card.AutoReissue && card.Status == models.CardStatusActive
+
+		if err := s.changeStatus(ctx, card, models.CardStatusExpired, models.CardReasonExpired); err != nil {
+			log.Printf("expire card %d: %v", card.ID, err)
+			failed++
+			continue
+		}
+
+		if reissue {
+			if _, err := s.reissue(ctx, card, models.CardReasonExpired); err != nil {
+				log.Printf("reissue expired card %d: %v", card.ID, err)
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to process %d of %d expired cards", failed, len(cards))
+	}
+	return nil
+}
+
+// CloseExpiredSingleUse закрывает одноразовые карты с истекшим временем жизни.
+// Как и в ExpireCards, ошибка по отдельной карте не прерывает обработку остальных.
+func (s *CardService) CloseExpiredSingleUse(ctx context.Context) error {
+	cards, err := s.repo.GetExpiredSingleUse(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, card := range cards {
+		if err := s.changeStatus(ctx, card, models.CardStatusClosed, models.CardReasonTTLExpired); err != nil {
+			log.Printf("close single-use card %d: %v", card.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to close %d of %d single-use cards", failed, len(cards))
+	}
+	return nil
+}
+
+func (s *CardService) GetLimits(ctx context.Context, userID, cardID int64) (*models.CardLimits, error) {
+	if _, err := s.getOwned(ctx, userID, cardID); err != nil {
+		return nil, err
+	}
+
+	limits, err := s.getLimits(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits.DailySpent, limits.MonthlySpent, err = s.repo.GetSpending(ctx, cardID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+func (s *CardService) UpdateLimits(ctx context.Context, userID, cardID int64, input models.CardLimitsUpdate) (*models.CardLimits, error) {
+	if input.DailyLimit < 0 || input.MonthlyLimit < 0 || input.PerTransactionLimit < 0 {
+		return nil, errors.New("limits must not be negative")
+	}
+
+	if _, err := s.getOwned(ctx, userID, cardID); err != nil {
+		return nil, err
+	}
+
+	limits := &models.CardLimits{
+		CardID:              cardID,
+		DailyLimit:          input.DailyLimit,
+		MonthlyLimit:        input.MonthlyLimit,
+		PerTransactionLimit: input.PerTransactionLimit,
+		OnlineEnabled:       input.OnlineEnabled,
+		ContactlessEnabled:  input.ContactlessEnabled,
+		ATMEnabled:          input.ATMEnabled,
+		ForeignEnabled:      input.ForeignEnabled,
+	}
+
+	if err := s.repo.UpsertLimits(ctx, limits); err != nil {
+		return nil, err
+	}
+
+	var err error
+	limits.DailySpent, limits.MonthlySpent, err = s.repo.GetSpending(ctx, cardID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// Authorize проводит авторизацию операции по карте: проверяет реквизиты,
+// ограничения и лимиты карты и при успехе списывает средства в пользу счета мерчанта.
+// Зачисление возможно только на счет, принадлежащий эквайеру acquirerID.
+// Отказ по бизнес-причинам возвращается в результате, ошибка - только при сбое.
+func (s *CardService) Authorize(ctx context.Context, acquirerID int64, input models.CardAuthorization) (*models.CardAuthorizationResult, error) {
+	merchantAccount, err := s.accountService.GetByID(ctx, input.MerchantAccountID)
+	if err != nil || merchantAccount.UserID != acquirerID {
+		return nil, ErrMerchantAccountNotFound
+	}
+
+	result := &models.CardAuthorizationResult{Amount: input.Amount}
+
+	if input.Amount <= 0 {
+		return decline(result, errors.New("invalid amount")), nil
+	}
+
+	card, err := s.repo.GetByNumber(ctx, input.CardNumber)
+	if err == sql.ErrNoRows {
+		return decline(result, ErrCardNotFound), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCard(card, input); err != nil {
+		return decline(result, err), nil
+	}
+
+	// Проверка PIN для операций в банкомате и по чипу
+	if input.Channel == models.CardChannelATM || input.Channel == models.CardChannelPOS {
+		err := s.verifyPIN(ctx, card, input.PIN)
+		switch err {
+		case nil:
+		case ErrPINNotSet, ErrIncorrectPIN, ErrPINTriesExceeded, pinblock.ErrInvalidPIN:
+			return decline(result, err), nil
+		default:
+			return nil, err
+		}
+	}
+
+	account, err := s.accountService.GetByID(ctx, card.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := s.getLimits(ctx, card.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	foreign := input.Currency != "" && input.Currency != account.Currency
+	if err := checkLimits(limits, input.Channel, foreign, input.Amount); err != nil {
+		return decline(result, err), nil
+	}
+
+	// Дневной и месячный лимиты проверяются вместе с резервированием суммы в счетчиках расходов.
+	// Если списание не состоится, резерв освобождается.
+	now := time.Now()
+	err = s.repo.ReserveSpending(ctx, card.ID, input.Amount, limits.DailyLimit, limits.MonthlyLimit, now)
+	switch err {
+	case nil:
+	case repository.ErrDailySpendingExceeded:
+		return decline(result, ErrDailyLimit), nil
+	case repository.ErrMonthlySpendingExceeded:
+		return decline(result, ErrMonthlyLimit), nil
+	default:
+		return nil, err
+	}
+
+	// Одноразовая карта закрывается до списания: из параллельных операций по ней
+	// проходит только первая. Если списание не состоится, карта снова становится активной.
+	if card.Type == models.CardTypeSingleUse {
+		claimed, err := s.repo.TransitionStatus(ctx, card.ID, models.CardStatusActive, models.CardStatusClosed, models.CardReasonUsed)
+		if err == nil && !claimed {
+			err = ErrCardInactive
+		}
+		if err != nil {
+			if releaseErr := s.repo.AddSpending(ctx, card.ID, -input.Amount, now); releaseErr != nil {
+				return nil, releaseErr
+			}
+			if err == ErrCardInactive {
+				return decline(result, err), nil
+			}
+			return nil, err
+		}
+	}
+
+	// Сумма операции считается уже сконвертированной эквайером в валюту счета
+	transaction := &models.Transaction{
+		FromAccountID: card.AccountID,
+		ToAccountID:   input.MerchantAccountID,
+		Amount:        input.Amount,
+		Type:          cardTransactionType(input.Channel),
+		MCC:           input.MCC,
+		MerchantName:  input.MerchantName,
+	}
+	if err := s.accountService.execute(ctx, transaction); err != nil {
+		if releaseErr := s.repo.AddSpending(ctx, card.ID, -input.Amount, now); releaseErr != nil {
+			return nil, releaseErr
+		}
+		if card.Type == models.CardTypeSingleUse {
+			if _, restoreErr := s.repo.TransitionStatus(ctx, card.ID, models.CardStatusClosed, models.CardStatusActive, card.StatusReason); restoreErr != nil {
+				return nil, restoreErr
+			}
+		}
+		if err == ErrInsufficientFunds {
+			return decline(result, err), nil
+		}
+		return nil, err
+	}
+
+	result.Approved = true
+	result.TransactionID = transaction.ID
+	return result, nil
+}
+
+// Вспомогательные функции
+
+// getOwned возвращает карту, если она выпущена к счету пользователя
+func (s *CardService) getOwned(ctx context.Context, userID, id int64) (*models.Card, error) {
+	card, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrCardNotFound
+	}
+
+	account, err := s.accountService.GetByID(ctx, card.AccountID)
+	if err != nil || account.UserID != userID {
+		return nil, ErrCardNotFound
+	}
+
+	return card, nil
+}
+
+// cardTransitions описывает допустимые переходы между статусами карты
+var cardTransitions = map[string][]string{
+	models.CardStatusActive:  {models.CardStatusBlocked, models.CardStatusLost, models.CardStatusStolen, models.CardStatusExpired, models.CardStatusClosed},
+	models.CardStatusBlocked: {models.CardStatusActive, models.CardStatusLost, models.CardStatusStolen, models.CardStatusExpired, models.CardStatusClosed},
+	models.CardStatusLost:    {models.CardStatusClosed},
+	models.CardStatusStolen:  {models.CardStatusClosed},
+	models.CardStatusExpired: {models.CardStatusClosed},
+}
+
+func (s *CardService) changeStatus(ctx context.Context, card *models.Card, status, reason string) error {
+	allowed := false
+	for _, next := range cardTransitions[card.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidCardStatus
+	}
+
+	card.Status = status
+	card.StatusReason = reason
+	card.IsActive = status == models.CardStatusActive
+
+	return s.repo.Update(ctx, card)
+}
+
+// reissue выпускает карту взамен старой и закрывает старую карту с причиной перевыпуска.
+// Новая карта, перенос лимитов и закрытие старой карты сохраняются в одной транзакции БД.
+func (s *CardService) reissue(ctx context.Context, card *models.Card, reason string) (*models.Card, error) {
+	if card.Status == models.CardStatusClosed || card.Type == models.CardTypeSingleUse {
+		return nil, ErrInvalidCardStatus
+	}
+
+	save := func(ctx context.Context, newCard *models.Card) error {
+		return s.repo.Reissue(ctx, newCard, card)
+	}
+	newCard, err := s.issueCard(ctx, &models.Card{
+		AccountID:         card.AccountID,
+		CardholderName:    card.CardholderName,
+		AutoReissue:       card.AutoReissue,
+		PreviousCardID:    &card.ID,
+		ReissueReason:     reason,
+		Type:              card.Type,
+		MerchantAccountID: card.MerchantAccountID,
+		ProductCode:       card.ProductCode,
+	}, save)
+	if err == repository.ErrCardStatusChanged {
+		return nil, ErrInvalidCardStatus
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	card.Status = models.CardStatusClosed
+	card.StatusReason = reason
+	card.ReissueReason = reason
+	card.IsActive = false
+
+	return newCard, nil
+}
+
+// issueCard выпускает карту по шаблону: подбирает BIN-диапазон продукта,
+// генерирует уникальный номер, CVV и срок действия. Карта сохраняется функцией save.
+func (s *CardService) issueCard(ctx context.Context, card *models.Card, save func(context.Context, *models.Card) error) (*models.Card, error) {
+	bins, err := s.repo.GetBINs(ctx, card.ProductCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(bins) == 0 {
+		return nil, ErrUnknownProduct
+	}
+
+	bin := bins[rand.Intn(len(bins))]
+	card.PaymentSystem = bin.PaymentSystem
+	card.ProductCode = bin.ProductCode
+	card.ProductType = bin.ProductType
+
 	// Генерация CVV
 	cvv := fmt.Sprintf("%03d", rand.Intn(1000))
 	
@@ -37,48 +595,213 @@ func (s *CardService) Create(ctx context.Context, input models.CardCreate) (*mod
 	// Генерация срока действия (текущая дата + 4 года)
 	expiryDate := time.Now().AddDate(4, 0, 0).Format("01/06")
 
-	card := &models.Card{
-		AccountID:      input.AccountID,
-		Number:         cardNumber,
-		EncryptedCVV:   string(hashedCVV),
-		ExpiryDate:     expiryDate,
-		CardholderName: input.CardholderName,
-		IsActive:       true,
-	}
+	card.EncryptedCVV = string(hashedCVV)
+	card.ExpiryDate = expiryDate
+	card.IsActive = true
+	card.Status = models.CardStatusActive
 
-	if err := s.repo.Create(ctx, card); err != nil {
+	// Уникальность номера гарантируется индексом, при коллизии генерируем номер заново
+	for attempt := 0; attempt < maxCardNumberAttempts; attempt++ {
+		card.Number = generateCardNumber(bin.BIN)
+
+		err = save(ctx, card)
+		if err != repository.ErrCardNumberExists {
+			break
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	return card, nil
 }
 
-func (s *CardService) GetByID(ctx context.Context, id int64) (*models.Card, error) {
-	return s.repo.GetByID(ctx, id)
+func (s *CardService) storePIN(ctx context.Context, card *models.Card, pin string) error {
+	encryptedPINBlock, err := s.pinEncryptor.Encrypt(pin, card.Number)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePIN(ctx, card.ID, encryptedPINBlock); err != nil {
+		return err
+	}
+
+	card.EncryptedPINBlock = encryptedPINBlock
+	card.PINTryCount = 0
+	return nil
 }
 
-func (s *CardService) GetByAccountID(ctx context.Context, accountID int64) ([]*models.Card, error) {
-	return s.repo.GetByAccountID(ctx, accountID)
+// verifyPIN проверяет PIN-код карты. Неверный ввод увеличивает счетчик попыток,
+// после maxPINTries неверных попыток карта блокируется.
+func (s *CardService) verifyPIN(ctx context.Context, card *models.Card, pin string) error {
+	if card.EncryptedPINBlock == "" {
+		return ErrPINNotSet
+	}
+
+	if card.PINTryCount >= maxPINTries {
+		return ErrPINTriesExceeded
+	}
+
+	ok, err := s.pinEncryptor.Verify(pin, card.Number, card.EncryptedPINBlock)
+	if err != nil && err != pinblock.ErrInvalidPIN {
+		return err
+	}
+
+	if !ok {
+		tries, err := s.repo.IncrementPINTries(ctx, card.ID)
+		if err != nil {
+			return err
+		}
+		card.PINTryCount = tries
+
+		if tries >= maxPINTries {
+			if err := s.changeStatus(ctx, card, models.CardStatusBlocked, models.CardReasonPINTriesExceeded); err != nil {
+				return err
+			}
+			return ErrPINTriesExceeded
+		}
+
+		return ErrIncorrectPIN
+	}
+
+	if card.PINTryCount > 0 {
+		if err := s.repo.ResetPINTries(ctx, card.ID); err != nil {
+			return err
+		}
+		card.PINTryCount = 0
+	}
+
+	return nil
 }
 
-func (s *CardService) Deactivate(ctx context.Context, id int64) error {
-	return s.repo.Deactivate(ctx, id)
+// getLimits возвращает лимиты карты или лимиты по умолчанию, если они не настраивались
+func (s *CardService) getLimits(ctx context.Context, cardID int64) (*models.CardLimits, error) {
+	limits, err := s.repo.GetLimits(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits == nil {
+		limits = &models.CardLimits{
+			CardID:             cardID,
+			OnlineEnabled:      true,
+			ContactlessEnabled: true,
+			ATMEnabled:         true,
+			ForeignEnabled:     true,
+		}
+	}
+
+	return limits, nil
 }
 
-// Вспомогательные функции
+func (s *CardService) checkCard(card *models.Card, input models.CardAuthorization) error {
+	if card.Status == models.CardStatusExpired {
+		return ErrCardExpired
+	}
+
+	if card.Status != models.CardStatusActive {
+		return ErrCardInactive
+	}
+
+	if input.ExpiryDate != card.ExpiryDate {
+		return ErrInvalidCardData
+	}
+
+	if isExpired(card.ExpiryDate, time.Now()) {
+		return ErrCardExpired
+	}
+
+	if card.ValidUntil != nil && !time.Now().Before(*card.ValidUntil) {
+		return ErrCardExpired
+	}
+
+	if card.MerchantAccountID != nil && *card.MerchantAccountID != input.MerchantAccountID {
+		return ErrMerchantNotAllowed
+	}
+
+	// CVV обязателен для операций без присутствия карты
+	if input.Channel == models.CardChannelOnline && input.CVV == "" {
+		return ErrInvalidCardData
+	}
+
+	if input.CVV != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(card.EncryptedCVV), []byte(input.CVV)); err != nil {
+			return ErrInvalidCardData
+		}
+	}
+
+	return nil
+}
+
+// checkLimits проверяет ограничения канала, валюты и лимит на операцию. Дневной и месячный
+// лимиты проверяются при резервировании суммы в счетчиках расходов.
+func checkLimits(limits *models.CardLimits, channel string, foreign bool, amount float64) error {
+	switch channel {
+	case models.CardChannelOnline:
+		if !limits.OnlineEnabled {
+			return ErrChannelDisabled
+		}
+	case models.CardChannelContactless:
+		if !limits.ContactlessEnabled {
+			return ErrChannelDisabled
+		}
+	case models.CardChannelATM:
+		if !limits.ATMEnabled {
+			return ErrChannelDisabled
+		}
+	case models.CardChannelPOS:
+	default:
+		return errors.New("unknown operation channel")
+	}
+
+	if foreign && !limits.ForeignEnabled {
+		return ErrForeignCurrencyBlocked
+	}
+
+	if limits.PerTransactionLimit > 0 && amount > limits.PerTransactionLimit {
+		return ErrTransactionLimit
+	}
+
+	return nil
+}
+
+func decline(result *models.CardAuthorizationResult, reason error) *models.CardAuthorizationResult {
+	result.Approved = false
+	result.DeclineReason = reason.Error()
+	return result
+}
+
+func cardTransactionType(channel string) string {
+	if channel == models.CardChannelATM {
+		return "card_withdrawal"
+	}
+	return "card_payment"
+}
+
+// isExpired проверяет срок действия карты в формате MM/YY (карта действует до конца месяца)
+func isExpired(expiryDate string, now time.Time) bool {
+	expiry, err := time.Parse("01/06", expiryDate)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expiry.AddDate(0, 1, 0))
+}
 
-func generateCardNumber() string {
-	// Генерация 16-значного номера карты
+// generateCardNumber генерирует 16-значный номер карты в диапазоне BIN
+// с контрольной цифрой по алгоритму Луна
+func generateCardNumber(bin string) string {
 	number := make([]byte, 16)
-	for i := 0; i < 16; i++ {
+	copy(number, bin)
+	for i := len(bin); i < 15; i++ {
 		number[i] = byte(rand.Intn(10)) + '0'
 	}
 
-	// Применение алгоритма Луна
+	// Применение алгоритма Луна: удваивается каждая вторая цифра справа,
+	// начиная с цифры перед контрольной
 	sum := 0
-	for i := len(number) - 1; i >= 0; i-- {
+	for i := 14; i >= 0; i-- {
 		digit := int(number[i] - '0')
-		if (len(number)-i)%2 == 0 {
+		if (15-i)%2 == 1 {
 			digit *= 2
 			if digit > 9 {
 				digit -= 9
@@ -87,7 +810,7 @@ func generateCardNumber() string {
 		sum += digit
 	}
 
-	// Корректировка последней цифры
+	// Контрольная цифра
 	checkDigit := (10 - (sum % 10)) % 10
 	number[15] = byte(checkDigit) + '0'
 
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAmount             = errors.New("invalid amount")
+	ErrOperationLimitExceeded    = errors.New("operation limit exceeded")
+	ErrDailyLimitExceeded        = errors.New("daily withdrawal limit exceeded")
+	ErrExternalReferenceRequired = errors.New("external payment reference is required")
+	ErrExternalPaymentCredited   = errors.New("external payment already credited")
+)
+
+// withdrawalTypes - типы операций, учитываемые в дневном лимите снятия
+var withdrawalTypes = []string{"cash_withdrawal", "withdrawal"}
+
+// CashLimits - лимиты внесения и снятия средств через кассу и переводами из других банков
+type CashLimits struct {
+	MaxDeposit      float64 // максимальная сумма одного внесения (пополнения), руб.
+	MaxWithdrawal   float64 // максимальная сумма одного снятия, руб.
+	DailyWithdrawal float64 // сумма снятий со счета за календарный день владельца счета, руб.
+}
+
+func DefaultCashLimits() CashLimits {
+	return CashLimits{
+		MaxDeposit:      1000000,
+		MaxWithdrawal:   300000,
+		DailyWithdrawal: 500000,
+	}
+}
+
+// TopUp зачисляет на счет клиента подтвержденный входящий платеж из другого банка через
+// корреспондентский счет. Операция проводится оператором; платеж с одним и тем же внешним
+// идентификатором зачисляется только один раз.
+func (s *AccountService) TopUp(ctx context.Context, operatorID int64, input models.ExternalTopUp) (*models.Transaction, error) {
+	reference := strings.TrimSpace(input.ExternalReference)
+	if reference == "" {
+		return nil, ErrExternalReferenceRequired
+	}
+
+	account, err := s.getCustomerAccount(ctx, input.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.deposit(ctx, account, models.SystemAccountClearing, input.Amount, "top_up", &operatorID, reference)
+	if err == repository.ErrDuplicateExternalPayment {
+		return nil, ErrExternalPaymentCredited
+	}
+	return transaction, err
+}
+
+// Withdraw выводит средства со счета пользователя в другой банк через корреспондентский счет
+func (s *AccountService) Withdraw(ctx context.Context, userID, accountID int64, amount float64) (*models.Transaction, error) {
+	account, err := s.repo.GetByID(ctx, accountID)
+	if err != nil || account.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	return s.withdraw(ctx, account, models.SystemAccountClearing, amount, "withdrawal", nil)
+}
+
+// CashDeposit вносит наличные на счет клиента в кассе банка. Операция проводится оператором.
+func (s *AccountService) CashDeposit(ctx context.Context, operatorID int64, input models.CashOperation) (*models.Transaction, error) {
+	account, err := s.getCustomerAccount(ctx, input.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.deposit(ctx, account, models.SystemAccountCash, input.Amount, "cash_deposit", &operatorID, "")
+}
+
+// CashWithdrawal выдает наличные со счета клиента в кассе банка. Операция проводится оператором.
+func (s *AccountService) CashWithdrawal(ctx context.Context, operatorID int64, input models.CashOperation) (*models.Transaction, error) {
+	account, err := s.getCustomerAccount(ctx, input.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withdraw(ctx, account, models.SystemAccountCash, input.Amount, "cash_withdrawal", &operatorID)
+}
+
+// Вспомогательные функции
+
+// getCustomerAccount возвращает клиентский счет по номеру. Внутренние счета банка
+// недоступны для кассовых операций.
+func (s *AccountService) getCustomerAccount(ctx context.Context, number string) (*models.Account, error) {
+	account, err := s.repo.GetByNumber(ctx, number)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, account.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil || owner.Role == models.RoleSystem {
+		return nil, ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+// deposit зачисляет средства на счет с внутреннего счета банка. Баланс внутреннего счета не проверяется.
+// reference - идентификатор платежа в другом банке для пополнений через корреспондентский счет.
+func (s *AccountService) deposit(ctx context.Context, account *models.Account, systemAccount string, amount float64, transactionType string, operatorID *int64, reference string) (*models.Transaction, error) {
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if amount > s.cashLimits.MaxDeposit {
+		return nil, ErrOperationLimitExceeded
+	}
+
+	source, err := s.repo.GetSystemAccount(ctx, systemAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		FromAccountID:     source.ID,
+		ToAccountID:       account.ID,
+		Amount:            amount,
+		Type:              transactionType,
+		OperatorID:        operatorID,
+		ExternalReference: reference,
+	}
+	if err := s.post(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// withdraw списывает средства со счета на внутренний счет банка с проверкой лимитов снятия.
+// Дневной лимит и остаток проверяются вместе со списанием под блокировкой счета.
+func (s *AccountService) withdraw(ctx context.Context, account *models.Account, systemAccount string, amount float64, transactionType string, operatorID *int64) (*models.Transaction, error) {
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if amount > s.cashLimits.MaxWithdrawal {
+		return nil, ErrOperationLimitExceeded
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, account.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, ErrAccountNotFound
+	}
+	loc, err := userTimezone(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Дневной лимит считается с начала дня в часовом поясе владельца счета
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	destination, err := s.repo.GetSystemAccount(ctx, systemAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		FromAccountID: account.ID,
+		ToAccountID:   destination.ID,
+		Amount:        amount,
+		Type:          transactionType,
+		OperatorID:    operatorID,
+	}
+	switch err := s.repo.CreateWithdrawal(ctx, transaction, withdrawalTypes, today, s.cashLimits.DailyWithdrawal); err {
+	case nil:
+		return transaction, nil
+	case repository.ErrWithdrawalLimitExceeded:
+		return nil, ErrDailyLimitExceeded
+	case repository.ErrInsufficientBalance:
+		return nil, ErrInsufficientFunds
+	default:
+		return nil, err
+	}
+}
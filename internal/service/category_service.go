@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidCategory      = errors.New("invalid category")
+	ErrInvalidCategoryRule  = errors.New("invalid category rule")
+	ErrCategoryRuleNotFound = errors.New("category rule not found")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+)
+
+// categorizeBatchSize - число операций, категоризируемых за один запрос к базе
+const categorizeBatchSize = 500
+
+// categories - справочник категорий операций
+var categories = []models.Category{
+	{Code: models.CategoryGroceries, Name: "Продукты"},
+	{Code: models.CategoryRestaurants, Name: "Кафе и рестораны"},
+	{Code: models.CategoryTransport, Name: "Транспорт"},
+	{Code: models.CategoryFuel, Name: "Топливо"},
+	{Code: models.CategoryHealth, Name: "Здоровье"},
+	{Code: models.CategoryEntertainment, Name: "Развлечения"},
+	{Code: models.CategoryShopping, Name: "Покупки"},
+	{Code: models.CategoryUtilities, Name: "ЖКХ и связь"},
+	{Code: models.CategoryTravel, Name: "Путешествия"},
+	{Code: models.CategoryCash, Name: "Наличные"},
+	{Code: models.CategoryTransfers, Name: "Переводы"},
+	{Code: models.CategorySalary, Name: "Зарплата"},
+	{Code: models.CategoryOther, Name: "Прочее"},
+}
+
+// mccCategories сопоставляет отдельные коды MCC категориям. Диапазоны кодов обрабатываются в mccCategory.
+var mccCategories = map[int]string{
+	5411: models.CategoryGroceries, 5412: models.CategoryGroceries, 5422: models.CategoryGroceries,
+	5441: models.CategoryGroceries, 5451: models.CategoryGroceries, 5462: models.CategoryGroceries,
+	5499: models.CategoryGroceries,
+	5811: models.CategoryRestaurants, 5812: models.CategoryRestaurants, 5813: models.CategoryRestaurants,
+	5814: models.CategoryRestaurants,
+	4111: models.CategoryTransport, 4112: models.CategoryTransport, 4121: models.CategoryTransport,
+	4131: models.CategoryTransport, 4784: models.CategoryTransport, 4789: models.CategoryTransport,
+	7523: models.CategoryTransport,
+	5541: models.CategoryFuel, 5542: models.CategoryFuel, 5983: models.CategoryFuel,
+	5122: models.CategoryHealth, 5912: models.CategoryHealth, 8011: models.CategoryHealth,
+	8021: models.CategoryHealth, 8031: models.CategoryHealth, 8041: models.CategoryHealth,
+	8042: models.CategoryHealth, 8043: models.CategoryHealth, 8049: models.CategoryHealth,
+	8050: models.CategoryHealth, 8062: models.CategoryHealth, 8071: models.CategoryHealth,
+	8099: models.CategoryHealth,
+	7832: models.CategoryEntertainment, 7841: models.CategoryEntertainment, 7922: models.CategoryEntertainment,
+	7929: models.CategoryEntertainment, 7932: models.CategoryEntertainment, 7933: models.CategoryEntertainment,
+	7941: models.CategoryEntertainment, 7991: models.CategoryEntertainment, 7994: models.CategoryEntertainment,
+	7996: models.CategoryEntertainment, 7999: models.CategoryEntertainment, 5815: models.CategoryEntertainment,
+	5816: models.CategoryEntertainment, 5817: models.CategoryEntertainment, 5818: models.CategoryEntertainment,
+	4814: models.CategoryUtilities, 4816: models.CategoryUtilities, 4899: models.CategoryUtilities,
+	4900: models.CategoryUtilities,
+	4411: models.CategoryTravel, 4511: models.CategoryTravel, 4722: models.CategoryTravel,
+	7011: models.CategoryTravel, 7512: models.CategoryTravel,
+	6010: models.CategoryCash, 6011: models.CategoryCash,
+}
+
+type CategoryService struct {
+	repo        repository.CategoryRepository
+	accountRepo repository.AccountRepository
+}
+
+func NewCategoryService(repo repository.CategoryRepository, accountRepo repository.AccountRepository) *CategoryService {
+	return &CategoryService{
+		repo:        repo,
+		accountRepo: accountRepo,
+	}
+}
+
+// GetCategories возвращает справочник категорий операций
+func (s *CategoryService) GetCategories() []models.Category {
+	return categories
+}
+
+// GetRules возвращает правила категоризации пользователя
+func (s *CategoryService) GetRules(ctx context.Context, userID int64) ([]*models.CategoryRule, error) {
+	return s.repo.GetRules(ctx, &userID)
+}
+
+// CreateRule создает правило категоризации пользователя и применяет его к прошлым операциям
+func (s *CategoryService) CreateRule(ctx context.Context, userID int64, input models.CategoryRuleCreate) (*models.CategoryRule, error) {
+	return s.createRule(ctx, &userID, input)
+}
+
+// DeleteRule удаляет правило категоризации пользователя
+func (s *CategoryService) DeleteRule(ctx context.Context, userID, id int64) error {
+	return s.deleteRule(ctx, &userID, id)
+}
+
+// GetCounterpartyRules возвращает правила банка по контрагентам
+func (s *CategoryService) GetCounterpartyRules(ctx context.Context) ([]*models.CategoryRule, error) {
+	return s.repo.GetRules(ctx, nil)
+}
+
+// CreateCounterpartyRule создает правило банка, действующее для всех клиентов
+func (s *CategoryService) CreateCounterpartyRule(ctx context.Context, input models.CategoryRuleCreate) (*models.CategoryRule, error) {
+	return s.createRule(ctx, nil, input)
+}
+
+// DeleteCounterpartyRule удаляет правило банка
+func (s *CategoryService) DeleteCounterpartyRule(ctx context.Context, id int64) error {
+	return s.deleteRule(ctx, nil, id)
+}
+
+// Recategorize изменяет категорию операции пользователя. Измененная вручную категория
+// не перезаписывается правилами.
+func (s *CategoryService) Recategorize(ctx context.Context, userID, transactionID int64, input models.TransactionCategoryUpdate) (*models.TransactionCategory, error) {
+	if !validCategory(input.Category) {
+		return nil, ErrInvalidCategory
+	}
+
+	// Категория операции создается при категоризации, поэтому сначала категоризируются новые операции
+	if err := s.Categorize(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	category, err := s.repo.SetCategory(ctx, transactionID, userID, input.Category, models.CategorySourceManual)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	return category, nil
+}
+
+// Categorize назначает категории операциям пользователя, у которых их еще нет. Категория
+// определяется по первому сработавшему источнику: перевод между своими счетами, правила
+// пользователя, правила банка по контрагентам, код MCC, тип операции.
+func (s *CategoryService) Categorize(ctx context.Context, userID int64) error {
+	accounts, err := s.accountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	accountIDs := make([]int64, 0, len(accounts))
+	ownAccounts := make(map[int64]bool, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+		ownAccounts[account.ID] = true
+	}
+
+	userRules, err := s.repo.GetRules(ctx, &userID)
+	if err != nil {
+		return err
+	}
+	counterpartyRules, err := s.repo.GetRules(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for {
+		batch, err := s.repo.GetUncategorized(ctx, userID, accountIDs, categorizeBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		result := make([]*models.TransactionCategory, 0, len(batch))
+		for _, item := range batch {
+			category := categorize(item, ownAccounts, userRules, counterpartyRules)
+			category.UserID = userID
+			result = append(result, category)
+		}
+
+		if err := s.repo.SaveCategories(ctx, result); err != nil {
+			return err
+		}
+		if len(batch) < categorizeBatchSize {
+			return nil
+		}
+	}
+}
+
+// Вспомогательные функции
+
+func (s *CategoryService) createRule(ctx context.Context, userID *int64, input models.CategoryRuleCreate) (*models.CategoryRule, error) {
+	rule := &models.CategoryRule{
+		UserID:       userID,
+		Category:     input.Category,
+		Counterparty: strings.TrimSpace(input.Counterparty),
+		MCC:          strings.TrimSpace(input.MCC),
+		Merchant:     strings.TrimSpace(input.Merchant),
+	}
+
+	if !validCategory(rule.Category) {
+		return nil, ErrInvalidCategory
+	}
+	if rule.Counterparty == "" && rule.MCC == "" && rule.Merchant == "" {
+		return nil, ErrInvalidCategoryRule
+	}
+	if rule.MCC != "" {
+		if _, err := parseMCC(rule.MCC); err != nil {
+			return nil, ErrInvalidCategoryRule
+		}
+	}
+
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	// Автоматически назначенные категории пересчитываются при следующей категоризации
+	if err := s.repo.ResetAutomatic(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *CategoryService) deleteRule(ctx context.Context, userID *int64, id int64) error {
+	deleted, err := s.repo.DeleteRule(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCategoryRuleNotFound
+	}
+
+	return s.repo.ResetAutomatic(ctx, userID)
+}
+
+// categorize определяет направление и категорию операции с точки зрения владельца счетов ownAccounts
+func categorize(item *models.UncategorizedTransaction, ownAccounts map[int64]bool, userRules, counterpartyRules []*models.CategoryRule) *models.TransactionCategory {
+	t := item.Transaction
+	result := &models.TransactionCategory{TransactionID: t.ID}
+
+	counterparty := item.FromAccount
+	switch {
+	case ownAccounts[t.FromAccountID] && ownAccounts[t.ToAccountID]:
+		result.Direction = models.DirectionInternal
+		result.Category = models.CategoryTransfers
+		result.Source = models.CategorySourceDefault
+		return result
+	case ownAccounts[t.FromAccountID]:
+		result.Direction = models.DirectionExpense
+		counterparty = item.ToAccount
+	default:
+		result.Direction = models.DirectionIncome
+	}
+
+	if rule := matchRule(userRules, t, counterparty); rule != nil {
+		result.Category = rule.Category
+		result.Source = models.CategorySourceUserRule
+		return result
+	}
+	if rule := matchRule(counterpartyRules, t, counterparty); rule != nil {
+		result.Category = rule.Category
+		result.Source = models.CategorySourceCounterparty
+		return result
+	}
+	if result.Direction == models.DirectionExpense && t.MCC != "" {
+		if category := mccCategory(t.MCC); category != "" {
+			result.Category = category
+			result.Source = models.CategorySourceMCC
+			return result
+		}
+	}
+
+	result.Source = models.CategorySourceDefault
+	switch {
+	case result.Direction == models.DirectionIncome || t.Type == "transfer" || t.OriginalTransactionID != nil:
+		result.Category = models.CategoryTransfers
+	case t.Type == "card_withdrawal" || t.Type == "cash_withdrawal":
+		result.Category = models.CategoryCash
+	default:
+		result.Category = models.CategoryOther
+	}
+
+	return result
+}
+
+// matchRule возвращает первое правило, все заданные условия которого выполняются для операции
+func matchRule(rules []*models.CategoryRule, t *models.Transaction, counterparty string) *models.CategoryRule {
+	merchant := strings.ToLower(t.MerchantName)
+	for _, rule := range rules {
+		if rule.Counterparty != "" && rule.Counterparty != counterparty {
+			continue
+		}
+		if rule.MCC != "" && rule.MCC != t.MCC {
+			continue
+		}
+		if rule.Merchant != "" && !strings.Contains(merchant, strings.ToLower(rule.Merchant)) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// mccCategory возвращает категорию по коду MCC или пустую строку, если код не сопоставлен
+func mccCategory(mcc string) string {
+	code, err := parseMCC(mcc)
+	if err != nil {
+		return ""
+	}
+
+	if category, ok := mccCategories[code]; ok {
+		return category
+	}
+
+	switch {
+	case code >= 3000 && code <= 3999: // авиакомпании, аренда автомобилей, отели
+		return models.CategoryTravel
+	case code >= 5200 && code <= 5999: // розничная торговля
+		return models.CategoryShopping
+	default:
+		return ""
+	}
+}
+
+// parseMCC разбирает четырехзначный код MCC
+func parseMCC(mcc string) (int, error) {
+	if len(mcc) != 4 || strings.Trim(mcc, "0123456789") != "" {
+		return 0, ErrInvalidCategoryRule
+	}
+	return strconv.Atoi(mcc)
+}
+
+// categoryName возвращает наименование категории по коду
+func categoryName(code string) string {
+	for _, category := range categories {
+		if category.Code == code {
+			return category.Name
+		}
+	}
+	return code
+}
+
+func validCategory(code string) bool {
+	for _, category := range categories {
+		if category.Code == code {
+			return true
+		}
+	}
+	return false
+}
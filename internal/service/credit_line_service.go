@@ -0,0 +1,393 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"bank-api/pkg/centralbank"
+	"bank-api/pkg/daycount"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCreditLineNotFound = errors.New("credit line not found")
+	ErrCreditLineExists   = errors.New("account already has an active credit line")
+	ErrCreditLineRejected = errors.New("credit line rejected")
+	ErrCreditLineHasDebt  = errors.New("credit line has outstanding debt")
+	ErrInvalidCreditLimit = errors.New("credit limit must be positive")
+)
+
+const (
+	// creditLineScoringTerm - срок, на который оценивается платежеспособность по кредитной линии, месяцев
+	creditLineScoringTerm = 12
+	// maxStatementDay - выписки формируются не позже 28 числа, чтобы день был в каждом месяце
+	maxStatementDay = 28
+)
+
+// CreditLineTerms - условия кредитных линий
+type CreditLineTerms struct {
+	GraceDays         int     // льготный период с момента возникновения задолженности, дней
+	MinPaymentPercent float64 // минимальный платеж, % от задолженности
+	PaymentDueDays    int     // срок внесения минимального платежа после выписки, дней
+}
+
+func DefaultCreditLineTerms() CreditLineTerms {
+	return CreditLineTerms{
+		GraceDays:         50,
+		MinPaymentPercent: 5,
+		PaymentDueDays:    20,
+	}
+}
+
+type CreditLineService struct {
+	repo             repository.CreditLineRepository
+	centralBank      *centralbank.Client
+	accountService   *AccountService
+	analyticsService *AnalyticsService
+	scorer           CreditScorer
+	terms            CreditLineTerms
+}
+
+func NewCreditLineService(repo repository.CreditLineRepository, centralBank *centralbank.Client, accountService *AccountService, analyticsService *AnalyticsService, scorer CreditScorer, terms CreditLineTerms) *CreditLineService {
+	return &CreditLineService{
+		repo:             repo,
+		centralBank:      centralBank,
+		accountService:   accountService,
+		analyticsService: analyticsService,
+		scorer:           scorer,
+		terms:            terms,
+	}
+}
+
+// Open открывает кредитную линию на счете. Лимит и ставка определяются скорингом:
+// одобренный лимит не превышает максимальной суммы, рассчитанной скорером.
+func (s *CreditLineService) Open(ctx context.Context, input models.CreditLineCreate) (*models.CreditLine, error) {
+	if input.Limit <= 0 {
+		return nil, ErrInvalidCreditLimit
+	}
+
+	account, err := s.accountService.GetByID(ctx, input.AccountID)
+	if err != nil || account.UserID != input.UserID {
+		return nil, errors.New("account not found")
+	}
+
+	existing, err := s.repo.GetActiveByAccountID(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCreditLineExists
+	}
+
+	keyRate, err := s.centralBank.GetKeyRate()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.analyticsService.GetScoringData(ctx, input.UserID, scoringPeriodMonths)
+	if err != nil {
+		return nil, err
+	}
+	data.RequestedAmount = input.Limit
+	data.TermMonths = creditLineScoringTerm
+	data.RepaymentType = models.RepaymentAnnuity
+	data.KeyRate = keyRate
+
+	decision, err := s.scorer.Score(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !decision.Approved {
+		return nil, fmt.Errorf("%w: %s", ErrCreditLineRejected, strings.Join(decision.Reasons, "; "))
+	}
+
+	now := time.Now()
+	line := &models.CreditLine{
+		UserID:            input.UserID,
+		AccountID:         account.ID,
+		CreditLimit:       roundMoney(math.Min(input.Limit, decision.MaxAmount)),
+		InterestRate:      decision.InterestRate,
+		GraceDays:         s.terms.GraceDays,
+		MinPaymentPercent: s.terms.MinPaymentPercent,
+		StatementDay:      statementDay(now),
+		Status:            models.CreditLineStatusActive,
+	}
+
+	if err := s.repo.Create(ctx, line); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountService.SetOverdraftLimit(ctx, account.ID, line.CreditLimit); err != nil {
+		return nil, err
+	}
+
+	return s.withUsage(line, account), nil
+}
+
+func (s *CreditLineService) GetByID(ctx context.Context, userID, id int64) (*models.CreditLine, error) {
+	line, err := s.repo.GetByID(ctx, id)
+	if err != nil || line.UserID != userID {
+		return nil, ErrCreditLineNotFound
+	}
+
+	account, err := s.accountService.GetByID(ctx, line.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withUsage(line, account), nil
+}
+
+func (s *CreditLineService) GetByUserID(ctx context.Context, userID int64) ([]*models.CreditLine, error) {
+	lines, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		account, err := s.accountService.GetByID(ctx, line.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		s.withUsage(line, account)
+	}
+
+	return lines, nil
+}
+
+func (s *CreditLineService) GetStatements(ctx context.Context, userID, id int64) ([]*models.CreditLineStatement, error) {
+	line, err := s.repo.GetByID(ctx, id)
+	if err != nil || line.UserID != userID {
+		return nil, ErrCreditLineNotFound
+	}
+
+	return s.repo.GetStatements(ctx, line.ID)
+}
+
+// Close закрывает кредитную линию без задолженности и снимает лимит овердрафта со счета
+func (s *CreditLineService) Close(ctx context.Context, userID, id int64) (*models.CreditLine, error) {
+	line, err := s.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if line.Status != models.CreditLineStatusActive {
+		return line, nil
+	}
+
+	if line.UsedAmount > 0 || roundMoney(line.AccruedInterest) > 0 {
+		return nil, ErrCreditLineHasDebt
+	}
+
+	line.Status = models.CreditLineStatusClosed
+	if err := s.repo.Update(ctx, line); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountService.SetOverdraftLimit(ctx, line.AccountID, 0); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+// ProcessCreditLines начисляет проценты по действующим кредитным линиям за прошедшие дни,
+// формирует ежемесячные выписки и отмечает внесение минимальных платежей.
+// Ошибка по отдельной линии не прерывает обработку остальных.
+func (s *CreditLineService) ProcessCreditLines(ctx context.Context) error {
+	lines, err := s.repo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := daycount.Date(time.Now())
+	failed := 0
+	for _, line := range lines {
+		if err := s.processCreditLine(ctx, line, today); err != nil {
+			log.Printf("process credit line %d: %v", line.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to process %d of %d credit lines", failed, len(lines))
+	}
+	return nil
+}
+
+// processCreditLine начисляет проценты по линии за дни с последнего начисления по задолженности
+// на конец каждого дня, обновляет статусы выписок и в день выписки формирует новую
+func (s *CreditLineService) processCreditLine(ctx context.Context, line *models.CreditLine, today time.Time) error {
+	account, err := s.accountService.GetByID(ctx, line.AccountID)
+	if err != nil {
+		return err
+	}
+	used := math.Max(0, -account.Balance)
+
+	from := daycount.Date(line.CreatedAt)
+	if line.LastAccrualDate != nil {
+		from = daycount.Date(*line.LastAccrualDate).AddDate(0, 0, 1)
+	}
+	if from.Before(today) {
+		balances, err := s.accountService.endOfDayBalances(ctx, account, from, today)
+		if err != nil {
+			return err
+		}
+		for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+			accrueCreditLineDay(line, math.Max(0, -balances[day]), day)
+			accrued := day
+			line.LastAccrualDate = &accrued
+		}
+	}
+
+	if err := s.updateStatements(ctx, line, used); err != nil {
+		return err
+	}
+
+	if isStatementDate(line, today) {
+		return s.createStatement(ctx, line, used, today)
+	}
+
+	return s.repo.Update(ctx, line)
+}
+
+// Вспомогательные функции
+
+// withUsage заполняет задолженность и доступный остаток лимита по балансу счета
+func (s *CreditLineService) withUsage(line *models.CreditLine, account *models.Account) *models.CreditLine {
+	line.UsedAmount = roundMoney(math.Max(0, -account.Balance))
+	if line.Status == models.CreditLineStatusActive {
+		line.AvailableAmount = roundMoney(math.Max(0, line.CreditLimit-line.UsedAmount))
+	}
+	return line
+}
+
+// accrueCreditLineDay начисляет проценты за день на задолженность used. В течение льготного
+// периода проценты накапливаются отдельно и списываются, только если задолженность не погашена
+// полностью до его окончания; при полном погашении они не взимаются.
+func accrueCreditLineDay(line *models.CreditLine, used float64, day time.Time) {
+	if used <= 0 {
+		line.GraceStart = nil
+		line.GraceInterest = 0
+		return
+	}
+
+	interest := used * line.InterestRate / 100 * daycount.Actual365.YearFraction(day, day.AddDate(0, 0, 1))
+
+	if line.GraceStart == nil {
+		start := day
+		line.GraceStart = &start
+	}
+
+	if day.Before(line.GraceStart.AddDate(0, 0, line.GraceDays)) {
+		line.GraceInterest += interest
+		return
+	}
+
+	// Льготный период нарушен: проценты за него становятся к уплате
+	line.AccruedInterest += line.GraceInterest + interest
+	line.GraceInterest = 0
+}
+
+// createStatement формирует и сохраняет выписку вместе с состоянием линии: начисленные проценты
+// списываются со счета на счет процентных доходов и входят в задолженность, минимальный платеж - процент от задолженности плюс списанные проценты
+func (s *CreditLineService) createStatement(ctx context.Context, line *models.CreditLine, used float64, today time.Time) error {
+	var transaction *models.Transaction
+	interest := roundMoney(line.AccruedInterest)
+	if interest > 0 {
+		incomeAccountID, err := s.accountService.systemAccountID(ctx, models.SystemAccountInterestIncome)
+		if err != nil {
+			return err
+		}
+		transaction, err = s.accountService.ledgerTransaction(line.AccountID, incomeAccountID, interest, "credit_line_interest")
+		if err != nil {
+			return err
+		}
+		line.AccruedInterest = 0
+		used += interest
+	}
+
+	periodStart := daycount.Date(line.CreatedAt)
+	if line.LastStatementDate != nil {
+		periodStart = daycount.Date(*line.LastStatementDate)
+	}
+
+	var openingDebt float64
+	statements, err := s.repo.GetStatements(ctx, line.ID)
+	if err != nil {
+		return err
+	}
+	if len(statements) > 0 {
+		openingDebt = statements[0].ClosingDebt
+	}
+
+	closingDebt := roundMoney(used)
+	statement := &models.CreditLineStatement{
+		CreditLineID:    line.ID,
+		PeriodStart:     periodStart,
+		PeriodEnd:       today,
+		OpeningDebt:     openingDebt,
+		ClosingDebt:     closingDebt,
+		InterestCharged: interest,
+		MinPayment:      roundMoney(math.Min(closingDebt, closingDebt*line.MinPaymentPercent/100+interest)),
+		DueDate:         today.AddDate(0, 0, s.terms.PaymentDueDays),
+		Status:          models.StatementStatusOpen,
+	}
+	if statement.MinPayment <= 0 {
+		statement.Status = models.StatementStatusPaid
+	}
+
+	// Выписка, списание процентов и состояние линии сохраняются в одной транзакции БД
+	line.LastStatementDate = &today
+	return s.repo.SaveStatement(ctx, line, statement, transaction)
+}
+
+// updateStatements отмечает внесение минимальных платежей: платеж считается внесенным, когда
+// задолженность снизилась на его сумму относительно выписки, и просроченным после срока внесения
+func (s *CreditLineService) updateStatements(ctx context.Context, line *models.CreditLine, used float64) error {
+	statements, err := s.repo.GetOpenStatements(ctx, line.ID)
+	if err != nil {
+		return err
+	}
+
+	today := daycount.Date(time.Now())
+	for _, statement := range statements {
+		status := statement.Status
+		switch {
+		case roundMoney(used) <= roundMoney(statement.ClosingDebt-statement.MinPayment):
+			status = models.StatementStatusPaid
+		case today.After(daycount.Date(statement.DueDate)):
+			status = models.StatementStatusOverdue
+		}
+
+		if status != statement.Status {
+			if err := s.repo.UpdateStatementStatus(ctx, statement.ID, status); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// statementDay возвращает день месяца формирования выписок для линии, открытой в дату t
+func statementDay(t time.Time) int {
+	if t.Day() > maxStatementDay {
+		return maxStatementDay
+	}
+	return t.Day()
+}
+
+// isStatementDate проверяет, что сегодня день формирования выписки и она еще не сформирована
+func isStatementDate(line *models.CreditLine, today time.Time) bool {
+	if today.Day() != line.StatementDay || !today.After(daycount.Date(line.CreatedAt)) {
+		return false
+	}
+	return line.LastStatementDate == nil || daycount.Date(*line.LastStatementDate).Before(today)
+}
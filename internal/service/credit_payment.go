@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"errors"
+	"time"
+)
+
+var (
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrPaymentProcessed = errors.New("payment already processed")
+)
+
+// paymentStatusProcessing - платеж захвачен для списания и еще не проведен
+const paymentStatusProcessing = "processing"
+
+// ProcessPayment списывает плановый платеж по кредиту пользователя со счета кредита на ссудный
+// счет банка. Платеж переводится в обработку до списания, поэтому параллельный запрос
+// не спишет его повторно. После последнего платежа кредит закрывается.
+func (s *CreditService) ProcessPayment(ctx context.Context, userID, creditID, paymentID int64) error {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return ErrCreditNotFound
+	}
+
+	schedule, err := s.repo.GetPaymentSchedule(ctx, creditID)
+	if err != nil {
+		return err
+	}
+
+	var payment *models.PaymentSchedule
+	for _, p := range schedule {
+		if p.ID == paymentID {
+			payment = p
+			break
+		}
+	}
+	if payment == nil {
+		return ErrPaymentNotFound
+	}
+
+	claimed, err := s.repo.TransitionPaymentStatus(ctx, paymentID, "pending", paymentStatusProcessing)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrPaymentProcessed
+	}
+
+	// Списание платежа со счета кредита на ссудный счет
+	transaction, err := s.accountService.repayCredit(ctx, credit.AccountID, payment.Amount, "credit_payment")
+	switch err {
+	case nil:
+		payment.Status = "completed"
+	case ErrInsufficientFunds:
+		payment.Status = "overdue"
+	default:
+		// Списание не состоялось - платеж снова ожидает оплаты
+		_, _ = s.repo.TransitionPaymentStatus(ctx, paymentID, paymentStatusProcessing, "pending")
+		return err
+	}
+
+	if _, err := s.repo.TransitionPaymentStatus(ctx, paymentID, paymentStatusProcessing, payment.Status); err != nil {
+		// В случае ошибки возвращаем списанные средства
+		if transaction != nil {
+			_ = s.accountService.cancelCreditTransaction(ctx, transaction, "Платеж по кредиту не проведен")
+		}
+		_, _ = s.repo.TransitionPaymentStatus(ctx, paymentID, paymentStatusProcessing, "pending")
+		return err
+	}
+
+	// После последнего успешно списанного платежа кредит закрывается и формируется справка о погашении
+	if payment.Status != "completed" {
+		return nil
+	}
+	for _, p := range schedule {
+		if p.ID != paymentID && p.Status != "completed" {
+			return nil
+		}
+	}
+
+	credit.Status = "closed"
+	credit.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, credit); err != nil {
+		return err
+	}
+
+	_ = s.ensureDocuments(ctx, credit)
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+)
+
+// repayCredit списывает платеж по кредиту со счета заемщика на ссудный счет банка.
+// Овердрафт кредитной линии для погашения кредита не используется.
+func (s *AccountService) repayCredit(ctx context.Context, accountID int64, amount float64, transactionType string) (*models.Transaction, error) {
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	account, err := s.repo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Balance < amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	loans, err := s.repo.GetSystemAccount(ctx, models.SystemAccountLoans)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		FromAccountID: accountID,
+		ToAccountID:   loans.ID,
+		Amount:        amount,
+		Type:          transactionType,
+	}
+	if err := s.post(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// disburseCredit зачисляет сумму кредита на счет заемщика с ссудного счета банка
+func (s *AccountService) disburseCredit(ctx context.Context, accountID int64, amount float64) (*models.Transaction, error) {
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	loans, err := s.repo.GetSystemAccount(ctx, models.SystemAccountLoans)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		FromAccountID: loans.ID,
+		ToAccountID:   accountID,
+		Amount:        amount,
+		Type:          "credit_disbursement",
+	}
+	if err := s.post(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// cancelCreditTransaction отменяет операцию по кредиту (выдачу или платеж), которую не удалось
+// отразить по кредиту, отменой со ссылкой на исходную операцию
+func (s *AccountService) cancelCreditTransaction(ctx context.Context, transaction *models.Transaction, reason string) error {
+	_, err := s.compensate(ctx, transaction, transaction.Amount, "reversal", reason, nil)
+	return err
+}
@@ -5,66 +5,220 @@ import (
 	"bank-api/internal/models"
 	"bank-api/internal/repository"
 	"bank-api/pkg/centralbank"
+	"bank-api/pkg/daycount"
 	"errors"
+	"fmt"
 	"math"
 	"time"
 )
 
+var (
+	ErrApplicationNotFound    = errors.New("credit application not found")
+	ErrApplicationNotApproved = errors.New("credit application is not approved")
+	ErrInvalidCreditParams    = errors.New("amount and term must be positive")
+	ErrCreditNotFound         = errors.New("credit not found")
+	ErrCreditNotActive        = errors.New("credit is not active")
+	ErrCreditOverdue          = errors.New("credit has overdue payments")
+	ErrInvalidPrepaymentMode  = errors.New("invalid prepayment mode")
+	ErrInvalidPrepayment      = errors.New("prepayment amount must exceed accrued interest and be less than the outstanding debt")
+	ErrTermReductionBullet    = errors.New("term reduction is not available for bullet repayment")
+	ErrInvalidAccrualDate     = errors.New("accrual date is outside the credit term")
+)
+
+const (
+	// scoringPeriodMonths - период истории операций, используемый при скоринге
+	scoringPeriodMonths = 6
+	// quoteMargin - маржа банка над ключевой ставкой для предварительного расчета без скоринга
+	quoteMargin = 5.0
+)
+
 type CreditService struct {
-	repo           repository.CreditRepository
-	centralBank    *centralbank.Client
-	accountService *AccountService
+	repo             repository.CreditRepository
+	centralBank      *centralbank.Client
+	accountService   *AccountService
+	analyticsService *AnalyticsService
+	scorer           CreditScorer
+	pdnLimits        PDNLimits
+	fees             CreditFees
+	calendarRepo     repository.CalendarRepository
+	userRepo         repository.UserRepository
+	dayCount         daycount.Convention
 }
 
-func NewCreditService(repo repository.CreditRepository, centralBank *centralbank.Client, accountService *AccountService) *CreditService {
+func NewCreditService(repo repository.CreditRepository, centralBank *centralbank.Client, accountService *AccountService, analyticsService *AnalyticsService, scorer CreditScorer, pdnLimits PDNLimits, fees CreditFees, calendarRepo repository.CalendarRepository, userRepo repository.UserRepository, dayCount daycount.Convention) *CreditService {
 	return &CreditService{
-		repo:           repo,
-		centralBank:    centralBank,
-		accountService: accountService,
+		repo:             repo,
+		centralBank:      centralBank,
+		accountService:   accountService,
+		analyticsService: analyticsService,
+		scorer:           scorer,
+		pdnLimits:        pdnLimits,
+		fees:             fees,
+		calendarRepo:     calendarRepo,
+		userRepo:         userRepo,
+		dayCount:         dayCount,
 	}
 }
 
-func (s *CreditService) Create(ctx context.Context, input models.CreditCreate) (*models.Credit, error) {
-	// Получение ключевой ставки ЦБ
-	baseRate, err := s.centralBank.GetKeyRate()
+// SubmitApplication регистрирует заявку на кредит и сразу проводит ее скоринг
+func (s *CreditService) SubmitApplication(ctx context.Context, input models.CreditCreate) (*models.CreditApplication, error) {
+	if input.Amount <= 0 || input.TermMonths <= 0 {
+		return nil, ErrInvalidCreditParams
+	}
+
+	if _, err := scheduleGeneratorFor(input.RepaymentType); err != nil {
+		return nil, err
+	}
+	repaymentType := input.RepaymentType
+	if repaymentType == "" {
+		repaymentType = models.RepaymentAnnuity
+	}
+
+	account, err := s.accountService.GetByID(ctx, input.AccountID)
+	if err != nil || account.UserID != input.UserID {
+		return nil, errors.New("account not found")
+	}
+
+	application := &models.CreditApplication{
+		UserID:        input.UserID,
+		AccountID:     input.AccountID,
+		Amount:        input.Amount,
+		TermMonths:    input.TermMonths,
+		RepaymentType: repaymentType,
+		Status:        models.ApplicationStatusSubmitted,
+		WithInsurance: input.WithInsurance,
+	}
+
+	if err := s.repo.CreateApplication(ctx, application); err != nil {
+		return nil, err
+	}
+
+	if err := s.scoreApplication(ctx, application); err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+func (s *CreditService) GetApplication(ctx context.Context, userID, id int64) (*models.CreditApplication, error) {
+	application, err := s.repo.GetApplicationByID(ctx, id)
+	if err != nil || application.UserID != userID {
+		return nil, ErrApplicationNotFound
+	}
+
+	return application, nil
+}
+
+func (s *CreditService) GetApplications(ctx context.Context, userID int64) ([]*models.CreditApplication, error) {
+	return s.repo.GetApplicationsByUserID(ctx, userID)
+}
+
+// DisburseApplication выдает кредит по одобренной заявке. Сумма кредита ограничивается
+// максимальной суммой, определенной скорингом.
+func (s *CreditService) DisburseApplication(ctx context.Context, userID, id int64) (*models.Credit, error) {
+	application, err := s.GetApplication(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if application.Status != models.ApplicationStatusApproved {
+		return nil, ErrApplicationNotApproved
+	}
+
+	generator, err := scheduleGeneratorFor(application.RepaymentType)
 	if err != nil {
 		return nil, err
 	}
 
-	// Добавление маржи банка (5%)
-	interestRate := baseRate + 5.0
+	engine, err := s.interestEngine(ctx, s.dayCount)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := math.Min(application.Amount, application.MaxAmount)
+	issueDate := time.Now()
+	schedule := generator.Generate(engine, amount, application.InterestRate, issueDate, engine.DueDates(issueDate, application.TermMonths))
 
 	credit := &models.Credit{
-		UserID:       input.UserID,
-		AccountID:    input.AccountID,
-		Amount:       input.Amount,
-		InterestRate: interestRate,
-		TermMonths:   input.TermMonths,
-		Status:       "active",
+		UserID:          application.UserID,
+		AccountID:       application.AccountID,
+		Amount:          amount,
+		InterestRate:    application.InterestRate,
+		TermMonths:      application.TermMonths,
+		Status:          "active",
+		RepaymentType:   application.RepaymentType,
+		DayCount:        string(s.dayCount),
+		IssueFee:        s.fees.issueFee(amount),
+		ScheduleVersion: 1,
+	}
+	if application.WithInsurance {
+		credit.InsurancePremium = s.fees.insurancePremium(amount, application.TermMonths)
+	}
+
+	flows := creditCashFlows(amount, credit.IssueFee, credit.InsurancePremium, issueDate, schedule)
+	credit.PSK = calculatePSK(flows)
+	credit.PSKAmount = calculatePSKAmount(flows)
+
+	// Заявка переводится в статус disbursed до движения средств: из параллельных запросов
+	// выдачу проводит только тот, которому удался переход из статуса approved
+	ok, err := s.repo.TransitionApplication(ctx, application.ID, models.ApplicationStatusApproved, models.ApplicationStatusDisbursed)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrApplicationNotApproved
 	}
 
 	if err := s.repo.Create(ctx, credit); err != nil {
+		// В случае ошибки возвращаем заявку в статус approved
+		_, _ = s.repo.TransitionApplication(ctx, application.ID, models.ApplicationStatusDisbursed, models.ApplicationStatusApproved)
 		return nil, err
 	}
 
-	// Увеличиваем баланс счета на сумму кредита
-	if err := s.accountService.UpdateBalance(ctx, input.AccountID, input.Amount); err != nil {
+	// Зачисляем на счет с ссудного счета сумму кредита за вычетом комиссии и страховой премии
+	disbursement, err := s.accountService.disburseCredit(ctx, credit.AccountID, credit.Amount-credit.IssueFee-credit.InsurancePremium)
+	if err != nil {
 		// В случае ошибки отменяем создание кредита
 		_ = s.repo.Delete(ctx, credit.ID)
+		_, _ = s.repo.TransitionApplication(ctx, application.ID, models.ApplicationStatusDisbursed, models.ApplicationStatusApproved)
 		return nil, err
 	}
 
 	// Создание графика платежей
-	if err := s.createPaymentSchedule(ctx, credit); err != nil {
-		// В случае ошибки отменяем создание кредита и возвращаем средства
-		_ = s.accountService.UpdateBalance(ctx, input.AccountID, -input.Amount)
+	if err := s.createPaymentSchedule(ctx, credit, schedule); err != nil {
+		// В случае ошибки отменяем создание кредита и выдачу средств
+		_ = s.accountService.cancelCreditTransaction(ctx, disbursement, "Кредит не выдан")
 		_ = s.repo.Delete(ctx, credit.ID)
+		_, _ = s.repo.TransitionApplication(ctx, application.ID, models.ApplicationStatusDisbursed, models.ApplicationStatusApproved)
 		return nil, err
 	}
 
+	application.Status = models.ApplicationStatusDisbursed
+	application.CreditID = &credit.ID
+	if err := s.repo.UpdateApplication(ctx, application); err != nil {
+		return nil, err
+	}
+
+	// Кредитный договор; при ошибке он будет сформирован при запросе списка документов
+	_ = s.ensureDocuments(ctx, credit)
+
 	return credit, nil
 }
 
+// Quote рассчитывает график платежей и ПСК по стандартной ставке без создания заявки и кредита
+func (s *CreditService) Quote(ctx context.Context, input models.CreditQuoteRequest) (*models.CreditQuote, error) {
+	if input.Amount <= 0 || input.TermMonths <= 0 {
+		return nil, ErrInvalidCreditParams
+	}
+
+	keyRate, err := s.centralBank.GetKeyRate()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildQuote(ctx, input.Amount, keyRate+quoteMargin, input.TermMonths, input.RepaymentType, input.WithInsurance, time.Now())
+}
+
 func (s *CreditService) GetByID(ctx context.Context, id int64) (*models.Credit, error) {
 	return s.repo.GetByID(ctx, id)
 }
@@ -73,80 +227,445 @@ func (s *CreditService) GetByUserID(ctx context.Context, userID int64) ([]*model
 	return s.repo.GetByUserID(ctx, userID)
 }
 
-func (s *CreditService) GetPaymentSchedule(ctx context.Context, creditID int64) ([]*models.PaymentSchedule, error) {
+func (s *CreditService) GetPaymentSchedule(ctx context.Context, userID, creditID int64) ([]*models.PaymentSchedule, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
 	return s.repo.GetPaymentSchedule(ctx, creditID)
 }
 
-func (s *CreditService) ProcessPayment(ctx context.Context, creditID int64, paymentID int64) error {
-	schedule, err := s.repo.GetPaymentSchedule(ctx, creditID)
+// GetScheduleHistory возвращает все версии графика платежей, начиная с первоначальной
+func (s *CreditService) GetScheduleHistory(ctx context.Context, userID, creditID int64) ([]*models.ScheduleVersion, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	payments, err := s.repo.GetPaymentScheduleHistory(ctx, creditID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*models.ScheduleVersion, 0)
+	for _, payment := range payments {
+		if len(versions) == 0 || versions[len(versions)-1].Version != payment.Version {
+			versions = append(versions, &models.ScheduleVersion{
+				Version:   payment.Version,
+				Current:   payment.Version == credit.ScheduleVersion,
+				CreatedAt: payment.CreatedAt,
+			})
+		}
+		version := versions[len(versions)-1]
+		version.Payments = append(version.Payments, payment)
+	}
+
+	return versions, nil
+}
+
+// Prepay проводит досрочное погашение кредита: полное или частичное с сокращением срока
+// либо уменьшением платежа. Проценты начисляются по дату погашения, оставшиеся платежи
+// пересчитываются в новую версию графика, предыдущая версия сохраняется.
+func (s *CreditService) Prepay(ctx context.Context, userID, creditID int64, input models.CreditPrepaymentRequest) (*models.CreditPrepaymentResult, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	if credit.Status != "active" {
+		return nil, ErrCreditNotActive
+	}
+
+	switch input.Mode {
+	case models.PrepaymentFull, models.PrepaymentReducePayment:
+	case models.PrepaymentReduceTerm:
+		if credit.RepaymentType == models.RepaymentBullet {
+			return nil, ErrTermReductionBullet
+		}
+	default:
+		return nil, ErrInvalidPrepaymentMode
+	}
+
+	generator, err := scheduleGeneratorFor(credit.RepaymentType)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := s.interestEngine(ctx, daycount.Convention(credit.DayCount))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.repo.GetPaymentSchedule(ctx, credit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	// Разделяем график на погашенные и оставшиеся платежи; началом текущего процентного
+	// периода считается дата последнего наступившего платежа либо дата выдачи
+	var paid, remaining []*models.PaymentSchedule
+	periodStart := credit.CreatedAt
+	for _, payment := range schedule {
+		if payment.Status == "completed" {
+			paid = append(paid, payment)
+		} else {
+			if !payment.DueDate.After(now) {
+				return nil, ErrCreditOverdue
+			}
+			remaining = append(remaining, payment)
+		}
+		if !payment.DueDate.After(now) && payment.DueDate.After(periodStart) {
+			periodStart = payment.DueDate
+		}
+	}
+
+	if len(remaining) == 0 {
+		return nil, ErrCreditNotActive
+	}
+
+	var outstanding float64
+	for _, payment := range remaining {
+		outstanding += payment.Principal
+	}
+	outstanding = roundMoney(outstanding)
+	accrued := roundMoney(engine.Interest(outstanding, credit.InterestRate, periodStart, now))
+
+	prepayment := &models.CreditPrepayment{
+		CreditID:        credit.ID,
+		Mode:            input.Mode,
+		Interest:        accrued,
+		PreviousVersion: credit.ScheduleVersion,
+		ScheduleVersion: credit.ScheduleVersion + 1,
+	}
+
+	if input.Mode == models.PrepaymentFull {
+		prepayment.Principal = outstanding
+	} else {
+		prepayment.Principal = roundMoney(input.Amount - accrued)
+		if prepayment.Principal <= 0 || prepayment.Principal >= outstanding {
+			return nil, ErrInvalidPrepayment
+		}
+	}
+	prepayment.Amount = roundMoney(prepayment.Principal + prepayment.Interest)
+
+	// Новая версия графика: погашенные платежи, досрочный платеж и пересчитанный остаток
+	newSchedule := carryOverPayments(paid)
+	prepaymentRow := newPayment(len(newSchedule)+1, prepayment.Principal, prepayment.Interest, now)
+	prepaymentRow.Status = "completed"
+	newSchedule = append(newSchedule, prepaymentRow)
+
+	if input.Mode != models.PrepaymentFull {
+		newOutstanding := outstanding - prepayment.Principal
+
+		termMonths := len(remaining)
+		if input.Mode == models.PrepaymentReduceTerm {
+			termMonths = reducedTerm(generator, remaining[0].Amount, newOutstanding, credit.InterestRate, len(remaining))
+		}
+
+		// Сохраняем прежние даты платежей; проценты первого платежа начисляются с даты досрочного погашения
+		dueDates := make([]time.Time, termMonths)
+		for i := range dueDates {
+			dueDates[i] = remaining[i].DueDate
+		}
+		for _, payment := range generator.Generate(engine, newOutstanding, credit.InterestRate, now, dueDates) {
+			payment.PaymentNumber = len(newSchedule) + 1
+			newSchedule = append(newSchedule, payment)
+		}
+	}
+
+	// Сумма погашения списывается со счета заемщика на ссудный счет отдельной операцией
+	transaction, err := s.accountService.repayCredit(ctx, credit.AccountID, prepayment.Amount, "credit_prepayment")
+	if err != nil {
+		return nil, err
+	}
+	prepayment.TransactionID = &transaction.ID
+
+	credit.ScheduleVersion = prepayment.ScheduleVersion
+	if input.Mode == models.PrepaymentFull {
+		credit.Status = "closed"
+		credit.UpdatedAt = now
+	}
+
+	if err := s.repo.CreatePrepayment(ctx, prepayment, credit, newSchedule); err != nil {
+		// В случае ошибки возвращаем средства на счет
+		_ = s.accountService.cancelCreditTransaction(ctx, transaction, "Досрочное погашение не проведено")
+		return nil, err
+	}
+
+	// Справка о погашении; при ошибке она будет сформирована при запросе списка документов
+	if credit.Status == "closed" {
+		_ = s.ensureDocuments(ctx, credit)
+	}
+
+	return &models.CreditPrepaymentResult{
+		Prepayment: prepayment,
+		Credit:     credit,
+		Schedule:   newSchedule,
+	}, nil
+}
+
+// GetAccruedInterest возвращает проценты, начисленные по кредиту с начала текущего процентного
+// периода до даты date. Сохраненные ежедневные начисления дополняются расчетными за дни,
+// которые еще не обработаны (в том числе будущие).
+func (s *CreditService) GetAccruedInterest(ctx context.Context, userID, creditID int64, date time.Time) (*models.AccruedInterest, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	date = daycount.Date(date)
+	if date.Before(daycount.Date(credit.CreatedAt)) {
+		return nil, ErrInvalidAccrualDate
+	}
+
+	engine, err := s.interestEngine(ctx, daycount.Convention(credit.DayCount))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.repo.GetPaymentSchedule(ctx, credit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	periodStart := daycount.Date(credit.CreatedAt)
+	for _, payment := range schedule {
+		dueDate := daycount.Date(payment.DueDate)
+		if !dueDate.After(date) && dueDate.After(periodStart) {
+			periodStart = dueDate
+		}
+	}
+
+	stored, err := s.repo.GetAccruals(ctx, credit.ID, periodStart, date)
+	if err != nil {
+		return nil, err
+	}
+	storedByDate := make(map[time.Time]*models.InterestAccrual, len(stored))
+	for _, accrual := range stored {
+		storedByDate[daycount.Date(accrual.AccrualDate)] = accrual
+	}
+
+	result := &models.AccruedInterest{
+		CreditID:    credit.ID,
+		Date:        date,
+		DayCount:    credit.DayCount,
+		PeriodStart: periodStart,
+		Principal:   outstandingPrincipal(schedule, date),
+		Accruals:    make([]*models.InterestAccrual, 0),
+	}
+
+	var accrued float64
+	for _, accrual := range engine.DailyAccruals(credit, schedule, periodStart, date) {
+		if storedAccrual, ok := storedByDate[accrual.AccrualDate]; ok {
+			accrual = storedAccrual
+		}
+		accrued += accrual.Amount
+		result.Accruals = append(result.Accruals, accrual)
+	}
+	result.Accrued = roundMoney(accrued)
+
+	return result, nil
+}
+
+// AccrueInterest сохраняет ежедневные начисления процентов по действующим кредитам
+// за все прошедшие дни, по которым начисление еще не проводилось
+func (s *CreditService) AccrueInterest(ctx context.Context) error {
+	credits, err := s.repo.GetActive(ctx)
 	if err != nil {
 		return err
 	}
 
-	var payment *models.PaymentSchedule
-	for _, p := range schedule {
-		if p.ID == paymentID {
-			payment = p
-			break
+	calendar, err := s.calendar(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := daycount.Date(time.Now())
+	for _, credit := range credits {
+		engine := InterestEngine{Convention: daycount.Convention(credit.DayCount), Calendar: calendar}
+
+		from := daycount.Date(credit.CreatedAt)
+		last, err := s.repo.GetLastAccrualDate(ctx, credit.ID)
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			from = daycount.Date(*last).AddDate(0, 0, 1)
+		}
+
+		schedule, err := s.repo.GetPaymentSchedule(ctx, credit.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, accrual := range engine.DailyAccruals(credit, schedule, from, today) {
+			if err := s.repo.CreateAccrual(ctx, accrual); err != nil {
+				return err
+			}
 		}
 	}
 
-	if payment == nil {
-		return errors.New("payment not found")
+	return nil
+}
+
+// Вспомогательные функции
+
+// scoreApplication проводит скоринг заявки и сохраняет решение вместе с его причинами
+func (s *CreditService) scoreApplication(ctx context.Context, application *models.CreditApplication) error {
+	application.Status = models.ApplicationStatusScoring
+	if err := s.repo.UpdateApplication(ctx, application); err != nil {
+		return err
+	}
+
+	// Получение ключевой ставки ЦБ
+	keyRate, err := s.centralBank.GetKeyRate()
+	if err != nil {
+		return err
+	}
+
+	data, err := s.analyticsService.GetScoringData(ctx, application.UserID, scoringPeriodMonths)
+	if err != nil {
+		return err
 	}
+	data.RequestedAmount = application.Amount
+	data.TermMonths = application.TermMonths
+	data.RepaymentType = application.RepaymentType
+	data.KeyRate = keyRate
 
-	if payment.Status != "pending" {
-		return errors.New("payment already processed")
+	decision, err := s.scorer.Score(ctx, data)
+	if err != nil {
+		return err
 	}
 
-	// Проверка достаточности средств
-	account, err := s.accountService.GetByID(ctx, payment.CreditID)
+	now := time.Now()
+	application.Score = decision.Score
+	application.MaxAmount = decision.MaxAmount
+	application.InterestRate = decision.InterestRate
+	application.Reasons = decision.Reasons
+	application.DecidedAt = &now
+
+	approved := decision.Approved
+
+	// Расчет ПДН с учетом платежа по новому кредиту на одобренную сумму
+	amount := application.Amount
+	if approved {
+		amount = math.Min(application.Amount, decision.MaxAmount)
+	}
+	offer, err := s.buildQuote(ctx, amount, decision.InterestRate, application.TermMonths, application.RepaymentType, application.WithInsurance, now)
 	if err != nil {
 		return err
 	}
+	application.PDN = calculatePDN(data, averageMonthlyPayment(offer.Schedule))
 
-	if account.Balance < payment.Amount {
-		// Начисление штрафа за просрочку
-		payment.Amount *= 1.1 // +10% к сумме
-		payment.Status = "overdue"
+	switch {
+	case application.PDN > s.pdnLimits.BlockThreshold:
+		approved = false
+		application.Reasons = append(application.Reasons, fmt.Sprintf("debt burden indicator (PDN) %.2f%% exceeds the cap %.2f%%", application.PDN, s.pdnLimits.BlockThreshold))
+	case application.PDN > s.pdnLimits.FlagThreshold:
+		application.PDNFlagged = true
+		application.Reasons = append(application.Reasons, fmt.Sprintf("debt burden indicator (PDN) %.2f%% exceeds the flag threshold %.2f%%", application.PDN, s.pdnLimits.FlagThreshold))
+	}
+
+	if approved {
+		application.Status = models.ApplicationStatusApproved
+
+		// ПСК предложения на одобренную сумму
+		application.PSK = offer.PSK
+		application.PSKAmount = offer.PSKAmount
 	} else {
-		payment.Status = "completed"
+		application.Status = models.ApplicationStatusRejected
 	}
 
-	return s.repo.UpdatePaymentStatus(ctx, paymentID, payment.Status)
+	return s.repo.UpdateApplication(ctx, application)
 }
 
-// Вспомогательные функции
+// buildQuote рассчитывает график платежей, комиссии и ПСК для заданных условий кредита
+func (s *CreditService) buildQuote(ctx context.Context, amount, interestRate float64, termMonths int, repaymentType string, withInsurance bool, issueDate time.Time) (*models.CreditQuote, error) {
+	generator, err := scheduleGeneratorFor(repaymentType)
+	if err != nil {
+		return nil, err
+	}
+	if repaymentType == "" {
+		repaymentType = models.RepaymentAnnuity
+	}
+
+	engine, err := s.interestEngine(ctx, s.dayCount)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := generator.Generate(engine, amount, interestRate, issueDate, engine.DueDates(issueDate, termMonths))
+
+	quote := &models.CreditQuote{
+		Amount:        amount,
+		TermMonths:    termMonths,
+		InterestRate:  interestRate,
+		RepaymentType: repaymentType,
+		IssueFee:      s.fees.issueFee(amount),
+		Schedule:      schedule,
+	}
+	if withInsurance {
+		quote.InsurancePremium = s.fees.insurancePremium(amount, termMonths)
+	}
+	if len(schedule) > 0 {
+		quote.MonthlyPayment = schedule[0].Amount
+	}
+	for _, payment := range schedule {
+		quote.TotalPayments += payment.Amount
+	}
+	quote.TotalPayments = roundMoney(quote.TotalPayments)
 
-func (s *CreditService) createPaymentSchedule(ctx context.Context, credit *models.Credit) error {
-	// Расчет аннуитетного платежа
-	monthlyRate := credit.InterestRate / 12 / 100
-	annuityPayment := credit.Amount * (monthlyRate * math.Pow(1+monthlyRate, float64(credit.TermMonths))) / (math.Pow(1+monthlyRate, float64(credit.TermMonths)) - 1)
+	flows := creditCashFlows(amount, quote.IssueFee, quote.InsurancePremium, issueDate, schedule)
+	quote.PSK = calculatePSK(flows)
+	quote.PSKAmount = calculatePSKAmount(flows)
 
-	remainingPrincipal := credit.Amount
-	dueDate := time.Now().AddDate(0, 1, 0)
+	return quote, nil
+}
 
-	for i := 1; i <= credit.TermMonths; i++ {
-		interest := remainingPrincipal * monthlyRate
-		principal := annuityPayment - interest
-		remainingPrincipal -= principal
+// interestEngine возвращает движок начисления процентов по конвенции и производственному календарю
+func (s *CreditService) interestEngine(ctx context.Context, convention daycount.Convention) (InterestEngine, error) {
+	if convention == "" {
+		convention = s.dayCount
+	}
 
-		payment := &models.PaymentSchedule{
-			CreditID:      credit.ID,
-			PaymentNumber: i,
-			Amount:        annuityPayment,
-			Principal:     principal,
-			Interest:      interest,
-			DueDate:       dueDate,
-			Status:        "pending",
+	calendar, err := s.calendar(ctx)
+	if err != nil {
+		return InterestEngine{}, err
+	}
+
+	return InterestEngine{Convention: convention, Calendar: calendar}, nil
+}
+
+// calendar загружает производственный календарь
+func (s *CreditService) calendar(ctx context.Context) (*daycount.Calendar, error) {
+	days, err := s.calendarRepo.GetCalendarDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var holidays, workdays []time.Time
+	for _, day := range days {
+		if day.IsWorking {
+			workdays = append(workdays, day.Date)
+		} else {
+			holidays = append(holidays, day.Date)
 		}
+	}
+
+	return daycount.NewCalendar(holidays, workdays), nil
+}
 
+func (s *CreditService) createPaymentSchedule(ctx context.Context, credit *models.Credit, schedule []*models.PaymentSchedule) error {
+	for _, payment := range schedule {
+		payment.CreditID = credit.ID
+		payment.Version = credit.ScheduleVersion
 		if err := s.repo.CreatePaymentSchedule(ctx, payment); err != nil {
 			return err
 		}
-
-		dueDate = dueDate.AddDate(0, 1, 0)
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
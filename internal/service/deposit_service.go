@@ -0,0 +1,511 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"bank-api/pkg/centralbank"
+	"bank-api/pkg/daycount"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+var (
+	ErrDepositNotFound             = errors.New("deposit not found")
+	ErrDepositNotActive            = errors.New("deposit is closed")
+	ErrInvalidDepositType          = errors.New("invalid deposit type")
+	ErrInvalidDepositAmount        = errors.New("deposit amount must be positive")
+	ErrInvalidDepositTerm          = errors.New("invalid deposit term")
+	ErrDepositTopUpNotAllowed      = errors.New("term deposit cannot be topped up")
+	ErrPartialWithdrawalNotAllowed = errors.New("partial withdrawal from a term deposit is not allowed")
+	ErrDepositConflict             = errors.New("deposit was changed by another operation, retry the request")
+)
+
+const (
+	// maxDepositTerm - максимальный срок срочного вклада, месяцев
+	maxDepositTerm = 36
+	// depositDayCount - конвенция начисления процентов по вкладам (фактическое число дней в году)
+	depositDayCount = daycount.ActualActual
+)
+
+// DepositTerms - условия вкладов. Ставки привязаны к ключевой ставке ЦБ РФ.
+type DepositTerms struct {
+	SavingsSpread       float64 // ставка накопительного счета = ключевая ставка - спред, п.п.
+	TermSpread          float64 // ставка срочного вклада = ключевая ставка - спред, п.п.
+	EarlyWithdrawalRate float64 // ставка при досрочном закрытии срочного вклада (до востребования), % годовых
+}
+
+func DefaultDepositTerms() DepositTerms {
+	return DepositTerms{
+		SavingsSpread:       4,
+		TermSpread:          2,
+		EarlyWithdrawalRate: 0.01,
+	}
+}
+
+type DepositService struct {
+	repo           repository.DepositRepository
+	centralBank    *centralbank.Client
+	accountService *AccountService
+	terms          DepositTerms
+}
+
+func NewDepositService(repo repository.DepositRepository, centralBank *centralbank.Client, accountService *AccountService, terms DepositTerms) *DepositService {
+	return &DepositService{
+		repo:           repo,
+		centralBank:    centralBank,
+		accountService: accountService,
+		terms:          terms,
+	}
+}
+
+// GetRates возвращает текущие ставки по вкладам, рассчитанные от ключевой ставки ЦБ РФ
+func (s *DepositService) GetRates(ctx context.Context) (*models.DepositRates, error) {
+	keyRate, err := s.centralBank.GetKeyRate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DepositRates{
+		KeyRate:     keyRate,
+		SavingsRate: depositRate(keyRate, s.terms.SavingsSpread),
+		TermRate:    depositRate(keyRate, s.terms.TermSpread),
+	}, nil
+}
+
+// Open открывает вклад: сумма списывается со связанного счета. Ставка срочного вклада
+// фиксируется на весь срок, ставка накопительного счета пересматривается ежемесячно.
+func (s *DepositService) Open(ctx context.Context, input models.DepositCreate) (*models.Deposit, error) {
+	input.Amount = roundMoney(input.Amount)
+	if input.Amount <= 0 {
+		return nil, ErrInvalidDepositAmount
+	}
+
+	switch input.Type {
+	case models.DepositTypeSavings:
+		input.TermMonths = 0
+	case models.DepositTypeTerm:
+		if input.TermMonths <= 0 || input.TermMonths > maxDepositTerm {
+			return nil, ErrInvalidDepositTerm
+		}
+	default:
+		return nil, ErrInvalidDepositType
+	}
+
+	account, err := s.accountService.GetByID(ctx, input.AccountID)
+	if err != nil || account.UserID != input.UserID {
+		return nil, errors.New("account not found")
+	}
+
+	// Вклад открывается за счет собственных средств, без использования овердрафта
+	if account.Balance < input.Amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	rates, err := s.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	today := daycount.Date(time.Now())
+	deposit := &models.Deposit{
+		UserID:           input.UserID,
+		AccountID:        account.ID,
+		Type:             input.Type,
+		Amount:           input.Amount,
+		Balance:          input.Amount,
+		InterestRate:     rates.SavingsRate,
+		Capitalization:   input.Capitalization,
+		TermMonths:       input.TermMonths,
+		Status:           models.DepositStatusActive,
+		NextInterestDate: daycount.AddMonths(today, 1),
+	}
+	if input.Type == models.DepositTypeTerm {
+		maturityDate := daycount.AddMonths(today, input.TermMonths)
+		deposit.InterestRate = rates.TermRate
+		deposit.MaturityDate = &maturityDate
+	}
+
+	pool, err := s.accountService.systemAccountID(ctx, models.SystemAccountDeposits)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := s.accountService.ledgerTransaction(account.ID, pool, input.Amount, "deposit_open")
+	if err != nil {
+		return nil, err
+	}
+
+	// Сумма списывается со счета на счет вкладов банка вместе с сохранением вклада
+	if err := s.repo.Create(ctx, deposit, transaction); err != nil {
+		if err == repository.ErrInsufficientBalance {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (s *DepositService) GetByID(ctx context.Context, userID, id int64) (*models.Deposit, error) {
+	deposit, err := s.repo.GetByID(ctx, id)
+	if err != nil || deposit.UserID != userID {
+		return nil, ErrDepositNotFound
+	}
+
+	return deposit, nil
+}
+
+func (s *DepositService) GetByUserID(ctx context.Context, userID int64) ([]*models.Deposit, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+// TopUp пополняет накопительный счет со связанного счета
+func (s *DepositService) TopUp(ctx context.Context, userID, id int64, input models.DepositOperation) (*models.Deposit, error) {
+	deposit, err := s.getActive(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deposit.Type != models.DepositTypeSavings {
+		return nil, ErrDepositTopUpNotAllowed
+	}
+
+	if input.Amount <= 0 {
+		return nil, ErrInvalidDepositAmount
+	}
+
+	account, err := s.accountService.GetByID(ctx, deposit.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Balance < input.Amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	// Проценты за прошедшие дни начисляются на остаток до пополнения
+	transactions, err := s.accrue(ctx, deposit, daycount.Date(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := s.accountService.systemAccountID(ctx, models.SystemAccountDeposits)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := s.accountService.ledgerTransaction(deposit.AccountID, pool, input.Amount, "deposit_top_up")
+	if err != nil {
+		return nil, err
+	}
+
+	deposit.Balance = roundMoney(deposit.Balance + transaction.Amount)
+	if err := s.save(ctx, deposit, append(transactions, transaction)); err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// Withdraw переводит часть средств накопительного счета на связанный счет. Срочный вклад
+// можно только закрыть досрочно целиком.
+func (s *DepositService) Withdraw(ctx context.Context, userID, id int64, input models.DepositOperation) (*models.Deposit, error) {
+	deposit, err := s.getActive(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deposit.Type != models.DepositTypeSavings {
+		return nil, ErrPartialWithdrawalNotAllowed
+	}
+
+	if input.Amount <= 0 {
+		return nil, ErrInvalidDepositAmount
+	}
+
+	if input.Amount > deposit.Balance {
+		return nil, ErrInsufficientFunds
+	}
+
+	transactions, err := s.accrue(ctx, deposit, daycount.Date(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := s.accountService.systemAccountID(ctx, models.SystemAccountDeposits)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := s.accountService.ledgerTransaction(pool, deposit.AccountID, input.Amount, "deposit_withdrawal")
+	if err != nil {
+		return nil, err
+	}
+
+	// Сумма списывается с вклада, только если его не изменила параллельная операция,
+	// поэтому одновременные снятия не выплатят средства дважды
+	deposit.Balance = roundMoney(deposit.Balance - transaction.Amount)
+	if err := s.save(ctx, deposit, append(transactions, transaction)); err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// Close закрывает вклад и возвращает средства на связанный счет. По накопительному счету
+// выплачиваются проценты, начисленные по день закрытия. При досрочном закрытии срочного вклада
+// проценты пересчитываются по ставке до востребования за весь срок, а выплаченные
+// и капитализированные проценты сверх нее удерживаются из возвращаемой суммы.
+func (s *DepositService) Close(ctx context.Context, userID, id int64) (*models.Deposit, error) {
+	deposit, err := s.getActive(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	today := daycount.Date(time.Now())
+	transactions, err := s.accrue(ctx, deposit, today)
+	if err != nil {
+		return nil, err
+	}
+
+	if deposit.Type == models.DepositTypeTerm && today.Before(daycount.Date(*deposit.MaturityDate)) {
+		principal := deposit.Balance - deposit.InterestPaid
+		interest := deposit.Amount * s.terms.EarlyWithdrawalRate / 100 * depositDayCount.YearFraction(deposit.CreatedAt, today)
+		balance := roundMoney(math.Max(0, principal+interest))
+
+		adjustment, err := s.interestAdjustment(ctx, balance-deposit.Balance)
+		if err != nil {
+			return nil, err
+		}
+		if adjustment != nil {
+			transactions = append(transactions, adjustment)
+		}
+
+		deposit.Balance = balance
+		deposit.InterestPaid = roundMoney(interest)
+		deposit.AccruedInterest = 0
+	}
+
+	if err := s.close(ctx, deposit, transactions); err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// ProcessDeposits начисляет проценты по действующим вкладам за прошедшие дни, капитализирует
+// или выплачивает их ежемесячно и закрывает срочные вклады, срок которых истек.
+// Ошибка по отдельному вкладу не прерывает обработку остальных.
+func (s *DepositService) ProcessDeposits(ctx context.Context) error {
+	deposits, err := s.repo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	rates, err := s.GetRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := daycount.Date(time.Now())
+	failed := 0
+	for _, deposit := range deposits {
+		if err := s.processDeposit(ctx, deposit, rates, today); err != nil {
+			log.Printf("process deposit %d: %v", deposit.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to process %d of %d deposits", failed, len(deposits))
+	}
+	return nil
+}
+
+// Вспомогательные функции
+
+// processDeposit начисляет проценты по вкладу до today и закрывает срочный вклад, срок которого истек
+func (s *DepositService) processDeposit(ctx context.Context, deposit *models.Deposit, rates *models.DepositRates, today time.Time) error {
+	nextInterestDate := deposit.NextInterestDate
+	transactions, err := s.accrue(ctx, deposit, today)
+	if err != nil {
+		return err
+	}
+
+	// Ставка накопительного счета пересматривается в начале каждого процентного периода
+	if deposit.Type == models.DepositTypeSavings && !deposit.NextInterestDate.Equal(nextInterestDate) {
+		deposit.InterestRate = rates.SavingsRate
+	}
+
+	if deposit.Type == models.DepositTypeTerm && !today.Before(daycount.Date(*deposit.MaturityDate)) {
+		return s.close(ctx, deposit, transactions)
+	}
+
+	return s.save(ctx, deposit, transactions)
+}
+
+// save сохраняет вклад вместе с операциями по нему в одной транзакции БД
+func (s *DepositService) save(ctx context.Context, deposit *models.Deposit, transactions []*models.Transaction) error {
+	err := s.repo.Update(ctx, deposit, transactions...)
+	switch err {
+	case repository.ErrDepositChanged:
+		return ErrDepositConflict
+	case repository.ErrInsufficientBalance:
+		return ErrInsufficientFunds
+	}
+	return err
+}
+
+func (s *DepositService) getActive(ctx context.Context, userID, id int64) (*models.Deposit, error) {
+	deposit, err := s.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deposit.Status != models.DepositStatusActive {
+		return nil, ErrDepositNotActive
+	}
+
+	return deposit, nil
+}
+
+// accrue начисляет проценты за каждый день до today (не включая) на остаток вклада.
+// В даты выплаты процентов они капитализируются или перечисляются на связанный счет.
+// По срочному вкладу проценты начисляются до даты окончания срока. Возвращает операции
+// выплаты процентов: они сохраняются вместе с датой последнего начисления.
+func (s *DepositService) accrue(ctx context.Context, deposit *models.Deposit, today time.Time) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	from := daycount.Date(deposit.CreatedAt)
+	if deposit.LastAccrualDate != nil {
+		from = daycount.Date(*deposit.LastAccrualDate).AddDate(0, 0, 1)
+	}
+
+	for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if deposit.MaturityDate != nil && !day.Before(daycount.Date(*deposit.MaturityDate)) {
+			break
+		}
+
+		next := day.AddDate(0, 0, 1)
+		deposit.AccruedInterest += deposit.Balance * deposit.InterestRate / 100 * depositDayCount.YearFraction(day, next)
+		accrued := day
+		deposit.LastAccrualDate = &accrued
+
+		if !next.Before(daycount.Date(deposit.NextInterestDate)) {
+			transaction, err := s.payInterest(ctx, deposit)
+			if err != nil {
+				return nil, err
+			}
+			if transaction != nil {
+				transactions = append(transactions, transaction)
+			}
+			deposit.NextInterestDate = nextInterestDate(deposit.CreatedAt, next)
+		}
+	}
+
+	return transactions, nil
+}
+
+// payInterest капитализирует начисленные проценты или перечисляет их на связанный счет и возвращает
+// операцию выплаты со счета процентных расходов банка. Капитализированные проценты зачисляются
+// на счет вкладов банка. Доли копеек остаются в начисленных процентах до следующей выплаты.
+func (s *DepositService) payInterest(ctx context.Context, deposit *models.Deposit) (*models.Transaction, error) {
+	interest := roundMoney(deposit.AccruedInterest)
+	if interest <= 0 {
+		return nil, nil
+	}
+
+	expense, err := s.accountService.systemAccountID(ctx, models.SystemAccountInterestExpense)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient := deposit.AccountID
+	if deposit.Capitalization {
+		if recipient, err = s.accountService.systemAccountID(ctx, models.SystemAccountDeposits); err != nil {
+			return nil, err
+		}
+		deposit.Balance = roundMoney(deposit.Balance + interest)
+	}
+
+	transaction, err := s.accountService.ledgerTransaction(expense, recipient, interest, "deposit_interest")
+	if err != nil {
+		return nil, err
+	}
+
+	deposit.AccruedInterest -= interest
+	deposit.InterestPaid = roundMoney(deposit.InterestPaid + interest)
+	return transaction, nil
+}
+
+// interestAdjustment готовит операцию, изменяющую сумму вклада на delta за счет процентных расходов
+// банка: положительная delta доплачивает проценты, отрицательная удерживает выплаченные сверх
+// ставки досрочного закрытия
+func (s *DepositService) interestAdjustment(ctx context.Context, delta float64) (*models.Transaction, error) {
+	delta = roundMoney(delta)
+	if delta == 0 {
+		return nil, nil
+	}
+
+	pool, err := s.accountService.systemAccountID(ctx, models.SystemAccountDeposits)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.accountService.systemAccountID(ctx, models.SystemAccountInterestExpense)
+	if err != nil {
+		return nil, err
+	}
+
+	if delta > 0 {
+		return s.accountService.ledgerTransaction(expense, pool, delta, "deposit_interest")
+	}
+	return s.accountService.ledgerTransaction(pool, expense, -delta, "deposit_interest_withheld")
+}
+
+// close выплачивает оставшиеся проценты, возвращает сумму вклада на связанный счет и закрывает вклад.
+// transactions - операции по вкладу, подготовленные до закрытия; все они сохраняются вместе с вкладом.
+func (s *DepositService) close(ctx context.Context, deposit *models.Deposit, transactions []*models.Transaction) error {
+	transaction, err := s.payInterest(ctx, deposit)
+	if err != nil {
+		return err
+	}
+	if transaction != nil {
+		transactions = append(transactions, transaction)
+	}
+
+	if deposit.Balance > 0 {
+		pool, err := s.accountService.systemAccountID(ctx, models.SystemAccountDeposits)
+		if err != nil {
+			return err
+		}
+		payout, err := s.accountService.ledgerTransaction(pool, deposit.AccountID, deposit.Balance, "deposit_close")
+		if err != nil {
+			return err
+		}
+		transactions = append(transactions, payout)
+	}
+
+	now := time.Now()
+	deposit.Balance = 0
+	deposit.AccruedInterest = 0
+	deposit.Status = models.DepositStatusClosed
+	deposit.ClosedAt = &now
+
+	return s.save(ctx, deposit, transactions)
+}
+
+// depositRate рассчитывает ставку вклада от ключевой ставки
+func depositRate(keyRate, spread float64) float64 {
+	return roundMoney(math.Max(0, keyRate-spread))
+}
+
+// nextInterestDate возвращает первую после after дату выплаты процентов: проценты выплачиваются
+// ежемесячно в число открытия вклада
+func nextInterestDate(openedAt, after time.Time) time.Time {
+	opened := daycount.Date(openedAt)
+	for i := 1; ; i++ {
+		if date := daycount.AddMonths(opened, i); date.After(after) {
+			return date
+		}
+	}
+}
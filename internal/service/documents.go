@@ -0,0 +1,368 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"bank-api/internal/models"
+	"bank-api/pkg/pdfdoc"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	ErrDocumentNotFound  = errors.New("document not found")
+	ErrDocumentCorrupted = errors.New("document checksum mismatch")
+)
+
+// bankName - наименование банка в документах
+const bankName = "АО «Банк»"
+
+// documentTemplates - тексты документов по кредиту. Каждый шаблон - отдельный абзац или строка.
+var documentTemplates = template.Must(template.New("documents").Funcs(template.FuncMap{
+	"money":   formatMoney,
+	"date":    formatDate,
+	"percent": formatPercent,
+}).Parse(`
+{{define "agreement_title"}}Кредитный договор № {{.Credit.ID}} от {{date .Credit.CreatedAt}}{{end}}
+{{define "agreement_psk"}}ПОЛНАЯ СТОИМОСТЬ КРЕДИТА: {{percent .Credit.PSK}}% годовых, {{money .Credit.PSKAmount}} руб.{{end}}
+{{define "agreement_parties"}}{{.Bank}}, именуемое в дальнейшем «Банк», с одной стороны, и {{.Borrower}}, именуемый(-ая) в дальнейшем «Заемщик», с другой стороны, заключили настоящий договор о нижеследующем.{{end}}
+{{define "agreement_subject"}}Банк предоставляет Заемщику кредит в сумме {{money .Credit.Amount}} руб. на срок {{.Credit.TermMonths}} мес. под {{percent .Credit.InterestRate}}% годовых, а Заемщик обязуется возвратить полученный кредит и уплатить проценты на него в размере, сроки и на условиях договора. Кредит зачисляется на счет Заемщика № {{.AccountNumber}}, с которого производится погашение.{{end}}
+{{define "agreement_interest"}}Проценты начисляются ежедневно на остаток основного долга по конвенции {{.Credit.DayCount}}. Даты платежей, выпадающие на нерабочие дни, переносятся на ближайший следующий рабочий день.{{end}}
+{{define "agreement_prepayment"}}Заемщик вправе досрочно погасить кредит полностью или частично. Проценты уплачиваются по дату фактического погашения; при частичном досрочном погашении Заемщик выбирает сокращение срока кредита или уменьшение ежемесячного платежа.{{end}}
+{{define "agreement_schedule"}}Погашение кредита производится в соответствии с графиком платежей. Сумма платежей по графику: {{money .TotalPayments}} руб., в том числе основной долг {{money .TotalPrincipal}} руб. и проценты {{money .TotalInterest}} руб.{{end}}
+{{define "certificate_title"}}Справка о погашении кредита{{end}}
+{{define "certificate_body"}}{{.Bank}} настоящим подтверждает, что обязательства {{.Borrower}} по кредитному договору № {{.Credit.ID}} от {{date .Credit.CreatedAt}} на сумму {{money .Credit.Amount}} руб. исполнены в полном объеме {{date .ClosedAt}}. Задолженность по кредиту отсутствует, договор прекращен надлежащим исполнением.{{end}}
+`))
+
+// creditDocumentData - данные для подстановки в шаблоны документов
+type creditDocumentData struct {
+	Bank           string
+	Borrower       string
+	AccountNumber  string
+	Credit         *models.Credit
+	Schedule       []*models.PaymentSchedule
+	TotalPayments  float64
+	TotalPrincipal float64
+	TotalInterest  float64
+	ClosedAt       time.Time
+}
+
+// GetDocuments возвращает документы по кредиту. Документы, которые не удалось сформировать
+// при выдаче или закрытии кредита, формируются повторно.
+func (s *CreditService) GetDocuments(ctx context.Context, userID, creditID int64) ([]*models.CreditDocument, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	if err := s.ensureDocuments(ctx, credit); err != nil {
+		return nil, err
+	}
+
+	documents, err := s.repo.GetDocuments(ctx, credit.ID)
+	if err != nil {
+		return nil, err
+	}
+	if documents == nil {
+		documents = make([]*models.CreditDocument, 0)
+	}
+
+	return documents, nil
+}
+
+// GetDocument возвращает документ с содержимым, проверяя его контрольную сумму
+func (s *CreditService) GetDocument(ctx context.Context, userID, creditID, documentID int64) (*models.CreditDocument, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	document, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil || document.CreditID != credit.ID {
+		return nil, ErrDocumentNotFound
+	}
+
+	if checksum(document.Content) != document.Checksum {
+		return nil, ErrDocumentCorrupted
+	}
+
+	return document, nil
+}
+
+// Вспомогательные функции
+
+// ensureDocuments формирует недостающие документы: договор - для любого кредита,
+// справку о погашении - для закрытого
+func (s *CreditService) ensureDocuments(ctx context.Context, credit *models.Credit) error {
+	documents, err := s.repo.GetDocuments(ctx, credit.ID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(documents))
+	for _, document := range documents {
+		existing[document.Type] = true
+	}
+
+	if !existing[models.DocumentTypeAgreement] {
+		if err := s.createDocument(ctx, credit, models.DocumentTypeAgreement); err != nil {
+			return err
+		}
+	}
+
+	if credit.Status == "closed" && !existing[models.DocumentTypeClosureCertificate] {
+		if err := s.createDocument(ctx, credit, models.DocumentTypeClosureCertificate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createDocument формирует PDF-документ и сохраняет его вместе с контрольной суммой
+func (s *CreditService) createDocument(ctx context.Context, credit *models.Credit, documentType string) error {
+	data, err := s.documentData(ctx, credit, documentType)
+	if err != nil {
+		return err
+	}
+
+	var doc *pdfdoc.Document
+	var fileName string
+	switch documentType {
+	case models.DocumentTypeAgreement:
+		doc, err = renderAgreement(data)
+		fileName = fmt.Sprintf("credit-%d-agreement.pdf", credit.ID)
+	case models.DocumentTypeClosureCertificate:
+		doc, err = renderClosureCertificate(data)
+		fileName = fmt.Sprintf("credit-%d-closure-certificate.pdf", credit.ID)
+	default:
+		return ErrDocumentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return s.repo.CreateDocument(ctx, &models.CreditDocument{
+		CreditID: credit.ID,
+		Type:     documentType,
+		FileName: fileName,
+		Checksum: checksum(content),
+		Size:     len(content),
+		Content:  content,
+	})
+}
+
+// documentData собирает данные для документа. В договор попадает первоначальный график платежей,
+// в справку о погашении - платежи действующей версии графика.
+func (s *CreditService) documentData(ctx context.Context, credit *models.Credit, documentType string) (*creditDocumentData, error) {
+	data := &creditDocumentData{
+		Bank:          bankName,
+		Borrower:      fmt.Sprintf("клиент № %d", credit.UserID),
+		AccountNumber: strconv.FormatInt(credit.AccountID, 10),
+		Credit:        credit,
+		ClosedAt:      credit.UpdatedAt,
+	}
+
+	user, err := s.userRepo.GetByID(ctx, credit.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		data.Borrower = fmt.Sprintf("%s (%s)", user.Username, user.Email)
+	}
+
+	account, err := s.accountService.GetByID(ctx, credit.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	data.AccountNumber = account.Number
+
+	payments, err := s.repo.GetPaymentScheduleHistory(ctx, credit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := credit.ScheduleVersion
+	if documentType == models.DocumentTypeAgreement && len(payments) > 0 {
+		version = payments[0].Version
+	}
+
+	for _, payment := range payments {
+		if payment.Version != version {
+			continue
+		}
+		if documentType == models.DocumentTypeClosureCertificate && payment.Status != "completed" {
+			continue
+		}
+		data.Schedule = append(data.Schedule, payment)
+		data.TotalPayments += payment.Amount
+		data.TotalPrincipal += payment.Principal
+		data.TotalInterest += payment.Interest
+	}
+
+	return data, nil
+}
+
+func renderAgreement(data *creditDocumentData) (*pdfdoc.Document, error) {
+	text, err := renderTexts(data, "agreement_title", "agreement_psk", "agreement_parties", "agreement_subject",
+		"agreement_interest", "agreement_prepayment", "agreement_schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdfdoc.New(text["agreement_title"], data.Credit.CreatedAt)
+	doc.Notice(text["agreement_psk"])
+	doc.Heading(text["agreement_title"])
+	doc.Paragraph(text["agreement_parties"])
+
+	doc.Subheading("1. Предмет договора")
+	doc.Paragraph(text["agreement_subject"])
+
+	doc.Subheading("2. Индивидуальные условия")
+	doc.Field("Сумма кредита", money(data.Credit.Amount))
+	doc.Field("Срок кредита", fmt.Sprintf("%d мес.", data.Credit.TermMonths))
+	doc.Field("Процентная ставка", formatPercent(data.Credit.InterestRate)+"% годовых")
+	doc.Field("Способ погашения", repaymentTypeName(data.Credit.RepaymentType))
+	doc.Field("Комиссия за выдачу", money(data.Credit.IssueFee))
+	doc.Field("Страховая премия", money(data.Credit.InsurancePremium))
+	doc.Field("Полная стоимость кредита", fmt.Sprintf("%s%% годовых, %s", formatPercent(data.Credit.PSK), money(data.Credit.PSKAmount)))
+	doc.Field("Счет заемщика", data.AccountNumber)
+
+	doc.Subheading("3. Проценты и досрочное погашение")
+	doc.Paragraph(text["agreement_interest"])
+	doc.Paragraph(text["agreement_prepayment"])
+
+	doc.Subheading("4. График платежей")
+	doc.Paragraph(text["agreement_schedule"])
+	writeScheduleTable(doc, data)
+
+	doc.Subheading("Подписи сторон")
+	doc.Field("Банк", "____________________ / "+data.Bank)
+	doc.Field("Заемщик", "____________________ / "+data.Borrower)
+
+	return doc, nil
+}
+
+func renderClosureCertificate(data *creditDocumentData) (*pdfdoc.Document, error) {
+	text, err := renderTexts(data, "certificate_title", "certificate_body")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdfdoc.New(text["certificate_title"], data.ClosedAt)
+	doc.Heading(text["certificate_title"])
+	doc.Field("Дата выдачи справки", formatDate(data.ClosedAt))
+	doc.Paragraph(text["certificate_body"])
+
+	doc.Field("Сумма кредита", money(data.Credit.Amount))
+	doc.Field("Уплачено основного долга", money(data.TotalPrincipal))
+	doc.Field("Уплачено процентов", money(data.TotalInterest))
+	doc.Field("Остаток задолженности", money(0))
+
+	doc.Subheading("Платежи по кредиту")
+	writeScheduleTable(doc, data)
+
+	doc.Field("Банк", "____________________ / "+data.Bank)
+
+	return doc, nil
+}
+
+// writeScheduleTable выводит таблицу платежей с итоговой строкой
+func writeScheduleTable(doc *pdfdoc.Document, data *creditDocumentData) {
+	doc.Table([]pdfdoc.Column{
+		{Title: "№", Width: 12, Align: pdfdoc.AlignCenter},
+		{Title: "Дата платежа", Width: 32, Align: pdfdoc.AlignCenter},
+		{Title: "Платеж", Width: 34, Align: pdfdoc.AlignRight},
+		{Title: "Основной долг", Width: 34, Align: pdfdoc.AlignRight},
+		{Title: "Проценты", Width: 34, Align: pdfdoc.AlignRight},
+		{Title: "Остаток долга", Width: 34, Align: pdfdoc.AlignRight},
+	})
+
+	outstanding := data.TotalPrincipal
+	for _, payment := range data.Schedule {
+		outstanding = math.Max(0, outstanding-payment.Principal)
+		doc.Row(
+			strconv.Itoa(payment.PaymentNumber),
+			formatDate(payment.DueDate),
+			formatMoney(payment.Amount),
+			formatMoney(payment.Principal),
+			formatMoney(payment.Interest),
+			formatMoney(outstanding),
+		)
+	}
+	doc.TotalRow("", "Итого", formatMoney(data.TotalPayments), formatMoney(data.TotalPrincipal), formatMoney(data.TotalInterest), "")
+	doc.EndTable()
+}
+
+// renderTexts подставляет данные в указанные шаблоны
+func renderTexts(data interface{}, names ...string) (map[string]string, error) {
+	texts := make(map[string]string, len(names))
+	for _, name := range names {
+		var buf bytes.Buffer
+		if err := documentTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+			return nil, err
+		}
+		texts[name] = buf.String()
+	}
+	return texts, nil
+}
+
+func repaymentTypeName(repaymentType string) string {
+	switch repaymentType {
+	case models.RepaymentDifferentiated:
+		return "дифференцированные платежи"
+	case models.RepaymentBullet:
+		return "ежемесячная уплата процентов, погашение основного долга в конце срока"
+	default:
+		return "аннуитетные (равные) платежи"
+	}
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// formatMoney форматирует сумму в виде "1 234 567,89"
+func formatMoney(amount float64) string {
+	value := strconv.FormatFloat(math.Abs(roundMoney(amount)), 'f', 2, 64)
+	integer, fraction := value[:len(value)-3], value[len(value)-2:]
+
+	var groups []string
+	for len(integer) > 3 {
+		groups = append([]string{integer[len(integer)-3:]}, groups...)
+		integer = integer[:len(integer)-3]
+	}
+	groups = append([]string{integer}, groups...)
+
+	sign := ""
+	if roundMoney(amount) < 0 {
+		sign = "-"
+	}
+	return sign + strings.Join(groups, " ") + "," + fraction
+}
+
+func money(amount float64) string {
+	return formatMoney(amount) + " руб."
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("02.01.2006")
+}
+
+// formatPercent форматирует ставку с точностью до тысячных без лишних нулей
+func formatPercent(rate float64) string {
+	return strings.Replace(strconv.FormatFloat(math.Round(rate*1000)/1000, 'f', -1, 64), ".", ",", 1)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/pkg/daycount"
+	"time"
+)
+
+// systemAccountID возвращает ID внутреннего счета банка по его коду
+func (s *AccountService) systemAccountID(ctx context.Context, code string) (int64, error) {
+	account, err := s.repo.GetSystemAccount(ctx, code)
+	if err != nil {
+		return 0, err
+	}
+	return account.ID, nil
+}
+
+// ledgerTransaction готовит операцию с участием внутренних счетов банка. Такие операции проводят
+// репозитории вкладов и кредитных линий вместе с изменением своих данных в одной транзакции БД,
+// поэтому здесь проверяется только сумма.
+func (s *AccountService) ledgerTransaction(fromAccountID, toAccountID int64, amount float64, transactionType string) (*models.Transaction, error) {
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	return &models.Transaction{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		Type:          transactionType,
+	}, nil
+}
+
+// endOfDayBalances восстанавливает остатки счета на конец каждого дня периода [from, to) от текущего
+// остатка по проведенным операциям. Дни задаются в UTC, как в daycount.Date.
+func (s *AccountService) endOfDayBalances(ctx context.Context, account *models.Account, from, to time.Time) (map[time.Time]float64, error) {
+	turnover, err := s.repo.GetDailyNetTurnover(ctx, account.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// Остаток на конец периода - текущий остаток без операций, проведенных начиная с дня to
+	balance := account.Balance
+	for day, net := range turnover {
+		if !daycount.Date(day).Before(to) {
+			balance -= net
+		}
+	}
+
+	balances := make(map[time.Time]float64)
+	for day := to.AddDate(0, 0, -1); !day.Before(from); day = day.AddDate(0, 0, -1) {
+		balances[day] = balance
+		balance -= turnover[day]
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"math"
+)
+
+// PDNLimits задает макропруденциальные пороги показателя долговой нагрузки (ПДН), в процентах.
+// Заявки с ПДН выше FlagThreshold помечаются, выше BlockThreshold - отклоняются.
+type PDNLimits struct {
+	FlagThreshold  float64
+	BlockThreshold float64
+}
+
+// DefaultPDNLimits возвращает пороги ПДН по умолчанию
+func DefaultPDNLimits() PDNLimits {
+	return PDNLimits{
+		FlagThreshold:  50,
+		BlockThreshold: 80,
+	}
+}
+
+// calculatePDN рассчитывает показатель долговой нагрузки: отношение суммы среднемесячных
+// платежей по действующим кредитам и по новому кредиту к среднемесячному подтвержденному доходу.
+// Возвращает значение в процентах, при отсутствии подтвержденного дохода - 100%.
+func calculatePDN(data *models.ScoringData, newMonthlyPayment float64) float64 {
+	if data.MonthlyIncome <= 0 {
+		return 100
+	}
+
+	pdn := (data.MonthlyPayments + newMonthlyPayment) / data.MonthlyIncome * 100
+	return math.Round(pdn*100) / 100
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"math"
+	"time"
+)
+
+// CreditFees задает платежи заемщика помимо платежей по графику, которые входят в ПСК:
+// единовременную комиссию за выдачу и страховую премию (оплачивается единовременно при выдаче).
+type CreditFees struct {
+	IssueFeePercent      float64 // процент от суммы кредита
+	InsuranceRatePercent float64 // годовой процент от суммы кредита
+}
+
+// DefaultCreditFees возвращает параметры комиссий по умолчанию
+func DefaultCreditFees() CreditFees {
+	return CreditFees{
+		IssueFeePercent:      0,
+		InsuranceRatePercent: 1,
+	}
+}
+
+func (f CreditFees) issueFee(amount float64) float64 {
+	return roundMoney(amount * f.IssueFeePercent / 100)
+}
+
+func (f CreditFees) insurancePremium(amount float64, termMonths int) float64 {
+	return roundMoney(amount * f.InsuranceRatePercent / 100 * float64(termMonths) / 12)
+}
+
+// cashFlow представляет денежный поток по кредиту с точки зрения кредитора:
+// выдача - отрицательная сумма, платежи заемщика - положительные
+type cashFlow struct {
+	date   time.Time
+	amount float64
+}
+
+// creditCashFlows формирует денежные потоки для расчета ПСК: выдачу за вычетом
+// комиссии и страховой премии и платежи по графику
+func creditCashFlows(amount, issueFee, insurancePremium float64, issueDate time.Time, schedule []*models.PaymentSchedule) []cashFlow {
+	flows := make([]cashFlow, 0, len(schedule)+1)
+	flows = append(flows, cashFlow{date: issueDate, amount: -amount + issueFee + insurancePremium})
+	for _, payment := range schedule {
+		flows = append(flows, cashFlow{date: payment.DueDate, amount: payment.Amount})
+	}
+	return flows
+}
+
+// calculatePSK рассчитывает полную стоимость кредита в процентах годовых по формуле
+// Федерального закона 353-ФЗ: ПСК = i * ЧБП * 100, где ЧБП = 12 (базовый период - месяц),
+// а i - решение уравнения sum(ДПк / ((1 + ек*i) * (1 + i)^qк)) = 0, где qк - число полных
+// базовых периодов с даты выдачи до к-го платежа, ек - доля неполного базового периода.
+// Результат округляется до третьего знака после запятой.
+func calculatePSK(flows []cashFlow) float64 {
+	if len(flows) < 2 {
+		return 0
+	}
+
+	start := flows[0].date
+	periodDays := 365.0 / 12
+	q := make([]float64, len(flows))
+	e := make([]float64, len(flows))
+	for k, flow := range flows {
+		months := 0
+		for !start.AddDate(0, months+1, 0).After(flow.date) {
+			months++
+		}
+		q[k] = float64(months)
+		e[k] = flow.date.Sub(start.AddDate(0, months, 0)).Hours() / 24 / periodDays
+	}
+
+	npv := func(i float64) float64 {
+		var sum float64
+		for k, flow := range flows {
+			sum += flow.amount / ((1 + e[k]*i) * math.Pow(1+i, q[k]))
+		}
+		return sum
+	}
+
+	// Функция монотонно убывает по i, корень ищем делением отрезка пополам
+	low, high := 0.0, 1.0
+	if npv(low) <= 0 {
+		return 0
+	}
+	for npv(high) > 0 && high < 1e6 {
+		high *= 2
+	}
+	for iter := 0; iter < 200; iter++ {
+		mid := (low + high) / 2
+		if npv(mid) > 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	psk := (low + high) / 2 * 12 * 100
+	return math.Round(psk*1000) / 1000
+}
+
+// calculatePSKAmount рассчитывает ПСК в денежном выражении: сумму всех платежей заемщика
+// (по графику, комиссий и страховки) за вычетом суммы кредита
+func calculatePSKAmount(flows []cashFlow) float64 {
+	var sum float64
+	for _, flow := range flows {
+		sum += flow.amount
+	}
+	return roundMoney(sum)
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
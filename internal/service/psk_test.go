@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"testing"
+	"time"
+)
+
+// Примеры расчета ПСК по формуле 353-ФЗ. Для потоков в даты полных базовых периодов
+// ПСК равна месячной ставке, умноженной на 12; при неполном периоде i = доход / ек.
+func TestCalculatePSK(t *testing.T) {
+	issueDate := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	annuity := func(disbursed float64) []cashFlow {
+		flows := []cashFlow{{date: issueDate, amount: -disbursed}}
+		for month := 1; month <= 12; month++ {
+			flows = append(flows, cashFlow{date: issueDate.AddDate(0, month, 0), amount: 8884.88})
+		}
+		return flows
+	}
+
+	tests := []struct {
+		name  string
+		flows []cashFlow
+		want  float64
+	}{
+		{
+			name: "one payment after a full month",
+			flows: []cashFlow{
+				{date: issueDate, amount: -100000},
+				{date: issueDate.AddDate(0, 1, 0), amount: 101000},
+			},
+			want: 12,
+		},
+		{
+			// ек = 15 / (365 / 12), i = 0.005 / ек
+			name: "one payment after an incomplete period",
+			flows: []cashFlow{
+				{date: issueDate, amount: -100000},
+				{date: issueDate.AddDate(0, 0, 15), amount: 100500},
+			},
+			want: 12.167,
+		},
+		{
+			// 100 000 руб. под 12% годовых на 12 месяцев, аннуитетный платеж 8 884,88 руб.
+			name:  "annuity without fees",
+			flows: annuity(100000),
+			want:  12,
+		},
+		{
+			// Комиссия за выдачу 1 000 руб. и страховая премия 1 000 руб. уменьшают сумму к выдаче
+			name:  "annuity with issue fee and insurance",
+			flows: annuity(98000),
+			want:  15.855,
+		},
+		{
+			name:  "no payments",
+			flows: []cashFlow{{date: issueDate, amount: -100000}},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculatePSK(tt.flows); got != tt.want {
+				t.Errorf("calculatePSK() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreditCashFlows(t *testing.T) {
+	issueDate := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+	fees := CreditFees{IssueFeePercent: 1, InsuranceRatePercent: 1}
+	schedule := []*models.PaymentSchedule{
+		{Amount: 50500, DueDate: issueDate.AddDate(0, 1, 0)},
+		{Amount: 50250, DueDate: issueDate.AddDate(0, 2, 0)},
+	}
+
+	issueFee, premium := fees.issueFee(100000), fees.insurancePremium(100000, 24)
+	if issueFee != 1000 || premium != 2000 {
+		t.Fatalf("fees = %v, %v, want 1000, 2000", issueFee, premium)
+	}
+
+	flows := creditCashFlows(100000, issueFee, premium, issueDate, schedule)
+	if len(flows) != 3 || flows[0].amount != -97000 || flows[2].amount != 50250 {
+		t.Errorf("creditCashFlows() = %+v", flows)
+	}
+	if got := calculatePSKAmount(flows); got != 3750 {
+		t.Errorf("calculatePSKAmount() = %v, want 3750", got)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"bank-api/pkg/daycount"
+	"math"
+	"sort"
+	"time"
+)
+
+// Параметры выявления регулярных операций
+const (
+	recurringLookbackMonths = 6    // глубина анализируемой истории
+	recurringMinOccurrences = 3    // минимальное число повторений
+	recurringAmountSpread   = 0.2  // допустимый разброс сумм внутри группы (20% от минимальной)
+	recurringMinRegularity  = 0.6  // доля интервалов, совпадающих с периодом
+	recurringMinConfidence  = 0.5  // операции с меньшей уверенностью не учитываются в прогнозе
+	recurringStaleFactor    = 1.5  // операция считается прекратившейся после 1.5 пропущенных периодов
+)
+
+// recurrence описывает периодичность: номинальный интервал и допуск в днях
+type recurrence struct {
+	period    string
+	days      float64
+	tolerance float64
+}
+
+var recurrences = []recurrence{
+	{models.RecurrenceWeekly, 7, 2},
+	{models.RecurrenceBiweekly, 14, 3},
+	{models.RecurrenceMonthly, 30.4, 4},
+}
+
+// detectRecurring выявляет регулярные операции в истории: операции с одним контрагентом группируются
+// по близким суммам, затем по медианному интервалу между ними определяется периодичность.
+// Уверенность учитывает долю регулярных интервалов, стабильность суммы и число повторений.
+func detectRecurring(transactions []*models.CounterpartyTransaction, now time.Time) []models.RecurringPayment {
+	groups := make(map[[2]string][]*models.CounterpartyTransaction)
+	for _, transaction := range transactions {
+		if transaction.Counterparty == "" {
+			continue
+		}
+		key := [2]string{transaction.Direction, transaction.Counterparty}
+		groups[key] = append(groups[key], transaction)
+	}
+
+	payments := []models.RecurringPayment{}
+	for _, group := range groups {
+		for _, cluster := range clusterAmounts(group) {
+			if payment, ok := recurringPayment(cluster, now); ok {
+				payments = append(payments, payment)
+			}
+		}
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		a, b := payments[i], payments[j]
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		return a.Counterparty < b.Counterparty
+	})
+
+	return payments
+}
+
+// clusterAmounts разбивает операции с контрагентом на группы с близкими суммами
+// (например, подписка и разовые покупки в одном магазине). Операции в группах упорядочены по времени.
+func clusterAmounts(transactions []*models.CounterpartyTransaction) [][]*models.CounterpartyTransaction {
+	sorted := make([]*models.CounterpartyTransaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount < sorted[j].Amount
+	})
+
+	var clusters [][]*models.CounterpartyTransaction
+	var current []*models.CounterpartyTransaction
+	for _, transaction := range sorted {
+		if len(current) > 0 && transaction.Amount > current[0].Amount*(1+recurringAmountSpread) {
+			clusters = append(clusters, current)
+			current = nil
+		}
+		current = append(current, transaction)
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+
+	for _, cluster := range clusters {
+		sort.SliceStable(cluster, func(i, j int) bool {
+			return cluster[i].CreatedAt.Before(cluster[j].CreatedAt)
+		})
+	}
+	return clusters
+}
+
+// recurringPayment определяет периодичность группы операций с близкими суммами
+func recurringPayment(cluster []*models.CounterpartyTransaction, now time.Time) (models.RecurringPayment, bool) {
+	if len(cluster) < recurringMinOccurrences {
+		return models.RecurringPayment{}, false
+	}
+
+	intervals := make([]float64, 0, len(cluster)-1)
+	amounts := make([]float64, 0, len(cluster))
+	for i, transaction := range cluster {
+		amounts = append(amounts, transaction.Amount)
+		if i > 0 {
+			intervals = append(intervals, transaction.CreatedAt.Sub(cluster[i-1].CreatedAt).Hours()/24)
+		}
+	}
+
+	rec, ok := matchRecurrence(median(intervals))
+	if !ok {
+		return models.RecurringPayment{}, false
+	}
+
+	regular := 0
+	for _, interval := range intervals {
+		if math.Abs(interval-rec.days) <= rec.tolerance {
+			regular++
+		}
+	}
+	regularity := float64(regular) / float64(len(intervals))
+	if regularity < recurringMinRegularity {
+		return models.RecurringPayment{}, false
+	}
+
+	last := cluster[len(cluster)-1]
+	lastDate := last.CreatedAt.In(now.Location())
+	if now.Sub(lastDate).Hours()/24 > rec.days*recurringStaleFactor {
+		return models.RecurringPayment{}, false
+	}
+
+	amount := median(amounts)
+	deviation := stdDev(amounts)
+	variation := 0.0
+	if amount > 0 {
+		variation = math.Min(deviation/amount, 0.5)
+	}
+	confidence := regularity * (1 - variation) * math.Min(1, float64(len(cluster))/float64(recurringMinOccurrences+1))
+	confidence = math.Round(confidence*100) / 100
+	if confidence < recurringMinConfidence {
+		return models.RecurringPayment{}, false
+	}
+
+	payment := models.RecurringPayment{
+		Direction:       last.Direction,
+		Category:        last.Category,
+		Counterparty:    last.Counterparty,
+		Amount:          roundMoney(amount),
+		AmountDeviation: roundMoney(deviation),
+		Period:          rec.period,
+		Occurrences:     len(cluster),
+		LastDate:        lastDate,
+		Confidence:      confidence,
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for k := 1; ; k++ {
+		if next := nextOccurrence(payment, k); !next.Before(today) {
+			payment.NextDate = next
+			break
+		}
+	}
+
+	return payment, true
+}
+
+// matchRecurrence подбирает периодичность по медианному интервалу между операциями
+func matchRecurrence(interval float64) (recurrence, bool) {
+	for _, rec := range recurrences {
+		if math.Abs(interval-rec.days) <= rec.tolerance {
+			return rec, true
+		}
+	}
+	return recurrence{}, false
+}
+
+// nextOccurrence возвращает дату k-го повторения после последней операции.
+// Ежемесячные операции повторяются в то же число месяца.
+func nextOccurrence(payment models.RecurringPayment, k int) time.Time {
+	switch payment.Period {
+	case models.RecurrenceWeekly:
+		return payment.LastDate.AddDate(0, 0, 7*k)
+	case models.RecurrenceBiweekly:
+		return payment.LastDate.AddDate(0, 0, 14*k)
+	default:
+		return daycount.AddMonths(payment.LastDate, k)
+	}
+}
+
+// occurrenceUncertainty - разброс суммы одного повторения для доверительного интервала прогноза:
+// стандартное отклонение суммы плюс доля суммы, соответствующая неуверенности в повторении
+func occurrenceUncertainty(payment models.RecurringPayment) float64 {
+	return payment.AmountDeviation + (1-payment.Confidence)*payment.Amount
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/pkg/daycount"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRestructuringNotFound   = errors.New("restructuring request not found")
+	ErrRestructuringNotPending = errors.New("restructuring request is already decided")
+	ErrInvalidRestructuring    = errors.New("invalid restructuring parameters")
+)
+
+const (
+	// maxPaymentHoliday - максимальное число отсрочиваемых платежей (кредитные каникулы до 6 месяцев)
+	maxPaymentHoliday = 6
+	// maxTermExtension - максимальное увеличение срока кредита, месяцев
+	maxTermExtension = 60
+)
+
+// RequestRestructuring регистрирует запрос клиента на реструктуризацию кредита.
+// График меняется только после согласования оператором.
+func (s *CreditService) RequestRestructuring(ctx context.Context, userID, creditID int64, input models.CreditRestructuringRequest) (*models.CreditRestructuring, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	if credit.Status != "active" {
+		return nil, ErrCreditNotActive
+	}
+
+	restructuring := &models.CreditRestructuring{
+		CreditID: credit.ID,
+		UserID:   userID,
+		Type:     input.Type,
+		Reason:   input.Reason,
+		Status:   models.RestructuringStatusPending,
+	}
+
+	switch input.Type {
+	case models.RestructuringPaymentHoliday:
+		if input.Payments <= 0 || input.Payments > maxPaymentHoliday {
+			return nil, ErrInvalidRestructuring
+		}
+		restructuring.Payments = input.Payments
+	case models.RestructuringTermExtension:
+		if input.ExtraMonths <= 0 || input.ExtraMonths > maxTermExtension {
+			return nil, ErrInvalidRestructuring
+		}
+		restructuring.ExtraMonths = input.ExtraMonths
+	case models.RestructuringRateChange:
+		if input.InterestRate <= 0 {
+			return nil, ErrInvalidRestructuring
+		}
+		restructuring.InterestRate = input.InterestRate
+	default:
+		return nil, ErrInvalidRestructuring
+	}
+
+	if err := s.repo.CreateRestructuring(ctx, restructuring); err != nil {
+		return nil, err
+	}
+
+	return restructuring, nil
+}
+
+func (s *CreditService) GetRestructurings(ctx context.Context, userID, creditID int64) ([]*models.CreditRestructuring, error) {
+	credit, err := s.repo.GetByID(ctx, creditID)
+	if err != nil || credit.UserID != userID {
+		return nil, ErrCreditNotFound
+	}
+
+	return s.repo.GetRestructuringsByCreditID(ctx, creditID)
+}
+
+// GetPendingRestructurings возвращает запросы, ожидающие решения оператора
+func (s *CreditService) GetPendingRestructurings(ctx context.Context) ([]*models.CreditRestructuring, error) {
+	return s.repo.GetRestructuringsByStatus(ctx, models.RestructuringStatusPending)
+}
+
+// ApproveRestructuring согласует реструктуризацию: оставшиеся платежи пересчитываются
+// в новую версию графика, предыдущая версия сохраняется
+func (s *CreditService) ApproveRestructuring(ctx context.Context, operatorID, id int64, decision models.CreditRestructuringDecision) (*models.CreditRestructuring, error) {
+	restructuring, err := s.getPendingRestructuring(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, err := s.repo.GetByID(ctx, restructuring.CreditID)
+	if err != nil {
+		return nil, ErrCreditNotFound
+	}
+
+	if credit.Status != "active" {
+		return nil, ErrCreditNotActive
+	}
+
+	schedule, err := s.restructuredSchedule(ctx, credit, restructuring)
+	if err != nil {
+		return nil, err
+	}
+
+	credit.ScheduleVersion++
+	now := time.Now()
+	restructuring.Status = models.RestructuringStatusApproved
+	restructuring.OperatorID = &operatorID
+	restructuring.DecisionComment = decision.Comment
+	restructuring.ScheduleVersion = &credit.ScheduleVersion
+	restructuring.DecidedAt = &now
+
+	// Решение и новая версия графика сохраняются вместе и только для запроса, ожидающего решения
+	decided, err := s.repo.DecideRestructuring(ctx, restructuring, credit, schedule)
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, ErrRestructuringNotPending
+	}
+
+	return restructuring, nil
+}
+
+func (s *CreditService) RejectRestructuring(ctx context.Context, operatorID, id int64, decision models.CreditRestructuringDecision) (*models.CreditRestructuring, error) {
+	restructuring, err := s.getPendingRestructuring(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	restructuring.Status = models.RestructuringStatusRejected
+	restructuring.OperatorID = &operatorID
+	restructuring.DecisionComment = decision.Comment
+	restructuring.DecidedAt = &now
+
+	decided, err := s.repo.DecideRestructuring(ctx, restructuring, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, ErrRestructuringNotPending
+	}
+
+	return restructuring, nil
+}
+
+// Вспомогательные функции
+
+func (s *CreditService) getPendingRestructuring(ctx context.Context, id int64) (*models.CreditRestructuring, error) {
+	restructuring, err := s.repo.GetRestructuringByID(ctx, id)
+	if err != nil {
+		return nil, ErrRestructuringNotFound
+	}
+
+	if restructuring.Status != models.RestructuringStatusPending {
+		return nil, ErrRestructuringNotPending
+	}
+
+	return restructuring, nil
+}
+
+// restructuredSchedule рассчитывает новую версию графика. Непогашенные (в том числе просроченные)
+// платежи заменяются новыми: при кредитных каникулах первые платежи переносятся на конец срока,
+// а проценты за время каникул уплачиваются с первым платежом после них; при увеличении срока
+// остаток долга распределяется на большее число платежей; при изменении ставки платежи
+// пересчитываются по новой ставке. Новые платежи назначаются не раньше текущей даты.
+func (s *CreditService) restructuredSchedule(ctx context.Context, credit *models.Credit, restructuring *models.CreditRestructuring) ([]*models.PaymentSchedule, error) {
+	generator, err := scheduleGeneratorFor(credit.RepaymentType)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := s.interestEngine(ctx, daycount.Convention(credit.DayCount))
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetPaymentSchedule(ctx, credit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var paid, remaining []*models.PaymentSchedule
+	periodStart := credit.CreatedAt
+	for _, payment := range current {
+		if payment.Status == "completed" {
+			paid = append(paid, payment)
+			if payment.DueDate.After(periodStart) {
+				periodStart = payment.DueDate
+			}
+		} else {
+			remaining = append(remaining, payment)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return nil, ErrCreditNotActive
+	}
+
+	var outstanding float64
+	for _, payment := range remaining {
+		outstanding += payment.Principal
+	}
+	outstanding = roundMoney(outstanding)
+
+	from := remaining[0].DueDate
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+
+	count := len(remaining)
+	var dueDates []time.Time
+	switch restructuring.Type {
+	case models.RestructuringPaymentHoliday:
+		dueDates = engine.DueDatesFrom(credit.CreatedAt, from, count+restructuring.Payments)[restructuring.Payments:]
+		credit.TermMonths += restructuring.Payments
+	case models.RestructuringTermExtension:
+		dueDates = engine.DueDatesFrom(credit.CreatedAt, from, count+restructuring.ExtraMonths)
+		credit.TermMonths += restructuring.ExtraMonths
+	case models.RestructuringRateChange:
+		dueDates = engine.DueDatesFrom(credit.CreatedAt, from, count)
+		credit.InterestRate = restructuring.InterestRate
+	default:
+		return nil, ErrInvalidRestructuring
+	}
+
+	schedule := carryOverPayments(paid)
+	for _, payment := range generator.Generate(engine, outstanding, credit.InterestRate, periodStart, dueDates) {
+		payment.PaymentNumber = len(schedule) + 1
+		schedule = append(schedule, payment)
+	}
+
+	return schedule, nil
+}
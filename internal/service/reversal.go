@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrTransactionNotReversible = errors.New("transaction cannot be reversed or refunded")
+	ErrTransactionReversed      = errors.New("transaction already reversed")
+	ErrRefundExceedsAmount      = errors.New("refund amount exceeds the remaining amount")
+	ErrReasonRequired           = errors.New("reason is required")
+)
+
+// reversibleTypes - операции, которые оператор может отменить. Платежи по кредитам не отменяются:
+// они связаны с графиком платежей и досрочными погашениями.
+var reversibleTypes = map[string]bool{
+	"transfer":        true,
+	"card_payment":    true,
+	"card_withdrawal": true,
+	"top_up":          true,
+	"withdrawal":      true,
+	"cash_deposit":    true,
+	"cash_withdrawal": true,
+}
+
+// refundableTypes - операции, по которым получатель может вернуть средства отправителю
+var refundableTypes = map[string]bool{
+	"transfer":     true,
+	"card_payment": true,
+}
+
+// Reverse полностью отменяет проведенную операцию по решению оператора: сумма, еще не возвращенная
+// получателем, переводится со счета получателя обратно на счет отправителя. Операция отменяется один раз.
+func (s *AccountService) Reverse(ctx context.Context, operatorID, transactionID int64, input models.TransactionReversal) (*models.Transaction, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	original, err := s.repo.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+	if !compensable(original, reversibleTypes) {
+		return nil, ErrTransactionNotReversible
+	}
+
+	// После отмены или полного возврата отменять нечего
+	remaining := roundMoney(original.Amount - original.RefundedAmount)
+	if remaining <= 0 {
+		return nil, ErrTransactionReversed
+	}
+
+	return s.compensate(ctx, original, remaining, "reversal", reason, &operatorID)
+}
+
+// Refund возвращает отправителю всю сумму операции или ее часть по инициативе получателя.
+// Сумма возвратов не может превышать сумму операции.
+func (s *AccountService) Refund(ctx context.Context, userID, transactionID int64, input models.TransactionRefund) (*models.Transaction, error) {
+	original, err := s.repo.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	recipient, err := s.repo.GetByID(ctx, original.ToAccountID)
+	if err != nil || recipient.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+	if !compensable(original, refundableTypes) {
+		return nil, ErrTransactionNotReversible
+	}
+
+	amount := roundMoney(input.Amount)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if amount > roundMoney(original.Amount-original.RefundedAmount) {
+		return nil, ErrRefundExceedsAmount
+	}
+
+	return s.compensate(ctx, original, amount, "refund", strings.TrimSpace(input.Reason), nil)
+}
+
+// Вспомогательные функции
+
+// compensate проводит компенсирующую операцию: перевод суммы от получателя исходной операции
+// отправителю со ссылкой на исходную операцию
+func (s *AccountService) compensate(ctx context.Context, original *models.Transaction, amount float64, transactionType, reason string, operatorID *int64) (*models.Transaction, error) {
+	transaction := &models.Transaction{
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		Amount:                amount,
+		Type:                  transactionType,
+		OperatorID:            operatorID,
+		OriginalTransactionID: &original.ID,
+		Reason:                reason,
+	}
+
+	// Внутренние счета банка (касса, корреспондентский счет) могут уходить в минус,
+	// поэтому отмена выдачи наличных и вывода в другой банк проводится без проверки остатка
+	source, err := s.repo.GetByID(ctx, transaction.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.userRepo.GetByID(ctx, source.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner != nil && owner.Role == models.RoleSystem {
+		err = s.post(ctx, transaction)
+	} else {
+		err = s.execute(ctx, transaction)
+	}
+	if err != nil {
+		switch err {
+		case repository.ErrTransactionReversed:
+			return nil, ErrTransactionReversed
+		case repository.ErrCompensationExceedsAmount:
+			return nil, ErrRefundExceedsAmount
+		}
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// compensable сообщает, можно ли отменить операцию или вернуть по ней средства: операция
+// проведена, ее тип входит в types и сама она не является отменой или возвратом
+func compensable(transaction *models.Transaction, types map[string]bool) bool {
+	return transaction.Status == "completed" && transaction.OriginalTransactionID == nil && types[transaction.Type]
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInvalidRepaymentType = errors.New("invalid repayment type")
+
+// ScheduleGenerator рассчитывает график платежей для своего типа погашения
+type ScheduleGenerator interface {
+	// Generate рассчитывает график платежей без сохранения. Проценты начисляются движком
+	// engine на остаток долга с даты start по датам платежей dueDates.
+	Generate(engine InterestEngine, amount, interestRate float64, start time.Time, dueDates []time.Time) []*models.PaymentSchedule
+	// MaxPrincipal возвращает максимальную сумму кредита, при которой наибольший
+	// регулярный ежемесячный платеж не превышает payment
+	MaxPrincipal(payment, interestRate float64, termMonths int) float64
+}
+
+var scheduleGenerators = map[string]ScheduleGenerator{
+	models.RepaymentAnnuity:        annuitySchedule{},
+	models.RepaymentDifferentiated: differentiatedSchedule{},
+	models.RepaymentBullet:         bulletSchedule{},
+}
+
+// scheduleGeneratorFor возвращает генератор графика для типа погашения (по умолчанию - аннуитет)
+func scheduleGeneratorFor(repaymentType string) (ScheduleGenerator, error) {
+	if repaymentType == "" {
+		repaymentType = models.RepaymentAnnuity
+	}
+
+	generator, ok := scheduleGenerators[repaymentType]
+	if !ok {
+		return nil, ErrInvalidRepaymentType
+	}
+
+	return generator, nil
+}
+
+// annuitySchedule - равные ежемесячные платежи. Размер платежа рассчитывается по месячной ставке,
+// проценты - по фактическим периодам, последний платеж погашает остаток долга.
+type annuitySchedule struct{}
+
+func (annuitySchedule) Generate(engine InterestEngine, amount, interestRate float64, start time.Time, dueDates []time.Time) []*models.PaymentSchedule {
+	payment := roundMoney(annuityPayment(amount, interestRate, len(dueDates)))
+
+	schedule := make([]*models.PaymentSchedule, 0, len(dueDates))
+	remainingPrincipal := amount
+	periodStart := start
+
+	for i, dueDate := range dueDates {
+		interest := roundMoney(engine.Interest(remainingPrincipal, interestRate, periodStart, dueDate))
+		principal := payment - interest
+		if i == len(dueDates)-1 || principal > remainingPrincipal {
+			principal = remainingPrincipal
+		}
+		remainingPrincipal = roundMoney(remainingPrincipal - principal)
+
+		schedule = append(schedule, newPayment(i+1, principal, interest, dueDate))
+		periodStart = dueDate
+	}
+
+	return schedule
+}
+
+func (annuitySchedule) MaxPrincipal(payment, interestRate float64, termMonths int) float64 {
+	monthlyRate := interestRate / 12 / 100
+	if monthlyRate == 0 {
+		return payment * float64(termMonths)
+	}
+	return payment * (1 - math.Pow(1+monthlyRate, -float64(termMonths))) / monthlyRate
+}
+
+// differentiatedSchedule - равные доли основного долга и проценты на остаток
+type differentiatedSchedule struct{}
+
+func (differentiatedSchedule) Generate(engine InterestEngine, amount, interestRate float64, start time.Time, dueDates []time.Time) []*models.PaymentSchedule {
+	principal := roundMoney(amount / float64(len(dueDates)))
+
+	schedule := make([]*models.PaymentSchedule, 0, len(dueDates))
+	remainingPrincipal := amount
+	periodStart := start
+
+	for i, dueDate := range dueDates {
+		interest := roundMoney(engine.Interest(remainingPrincipal, interestRate, periodStart, dueDate))
+		if i == len(dueDates)-1 {
+			principal = remainingPrincipal
+		}
+		remainingPrincipal = roundMoney(remainingPrincipal - principal)
+
+		schedule = append(schedule, newPayment(i+1, principal, interest, dueDate))
+		periodStart = dueDate
+	}
+
+	return schedule
+}
+
+func (differentiatedSchedule) MaxPrincipal(payment, interestRate float64, termMonths int) float64 {
+	// Наибольший платеж - первый: P/n + P*r
+	monthlyRate := interestRate / 12 / 100
+	return payment / (1/float64(termMonths) + monthlyRate)
+}
+
+// bulletSchedule - ежемесячно только проценты, основной долг погашается последним платежом
+type bulletSchedule struct{}
+
+func (bulletSchedule) Generate(engine InterestEngine, amount, interestRate float64, start time.Time, dueDates []time.Time) []*models.PaymentSchedule {
+	schedule := make([]*models.PaymentSchedule, 0, len(dueDates))
+	periodStart := start
+
+	for i, dueDate := range dueDates {
+		interest := roundMoney(engine.Interest(amount, interestRate, periodStart, dueDate))
+		var principal float64
+		if i == len(dueDates)-1 {
+			principal = amount
+		}
+
+		schedule = append(schedule, newPayment(i+1, principal, interest, dueDate))
+		periodStart = dueDate
+	}
+
+	return schedule
+}
+
+func (bulletSchedule) MaxPrincipal(payment, interestRate float64, termMonths int) float64 {
+	// Регулярный платеж - только проценты, погашение тела учитывается при оценке ПДН
+	monthlyRate := interestRate / 12 / 100
+	if monthlyRate == 0 {
+		return 0
+	}
+	return payment / monthlyRate
+}
+
+func newPayment(number int, principal, interest float64, dueDate time.Time) *models.PaymentSchedule {
+	return &models.PaymentSchedule{
+		PaymentNumber: number,
+		Amount:        roundMoney(principal + interest),
+		Principal:     principal,
+		Interest:      interest,
+		DueDate:       dueDate,
+		Status:        "pending",
+	}
+}
+
+// carryOverPayments копирует погашенные платежи в новую версию графика
+func carryOverPayments(paid []*models.PaymentSchedule) []*models.PaymentSchedule {
+	schedule := make([]*models.PaymentSchedule, 0, len(paid))
+	for i, payment := range paid {
+		copied := newPayment(i+1, payment.Principal, payment.Interest, payment.DueDate)
+		copied.Status = payment.Status
+		schedule = append(schedule, copied)
+	}
+	return schedule
+}
+
+// annuityPayment рассчитывает ежемесячный аннуитетный платеж
+func annuityPayment(amount, annualRate float64, termMonths int) float64 {
+	monthlyRate := annualRate / 12 / 100
+	if monthlyRate == 0 {
+		return amount / float64(termMonths)
+	}
+	return amount * (monthlyRate * math.Pow(1+monthlyRate, float64(termMonths))) / (math.Pow(1+monthlyRate, float64(termMonths)) - 1)
+}
+
+// reducedTerm возвращает наименьшее число платежей (не больше maxTerm), при котором
+// остаток долга погашается платежами не больше текущего
+func reducedTerm(generator ScheduleGenerator, payment, principal, interestRate float64, maxTerm int) int {
+	for term := 1; term < maxTerm; term++ {
+		if generator.MaxPrincipal(payment, interestRate, term) >= principal {
+			return term
+		}
+	}
+	return maxTerm
+}
+
+// averageMonthlyPayment возвращает среднемесячный платеж по первым 12 платежам графика
+func averageMonthlyPayment(schedule []*models.PaymentSchedule) float64 {
+	count := len(schedule)
+	if count > 12 {
+		count = 12
+	}
+	if count == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, payment := range schedule[:count] {
+		total += payment.Amount
+	}
+	return total / float64(count)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bank-api/internal/models"
+	"bank-api/pkg/daycount"
+	"math"
+	"testing"
+	"time"
+)
+
+// Графики считаются по конвенции 30/360 с платежами 15-го числа: каждый период равен 1/12 года,
+// поэтому проценты за месяц - остаток * ставка / 12, как в стандартных таблицах амортизации
+
+func monthlyDueDates(start time.Time, count int) []time.Time {
+	dates := make([]time.Time, 0, count)
+	for month := 1; month <= count; month++ {
+		dates = append(dates, start.AddDate(0, month, 0))
+	}
+	return dates
+}
+
+func TestScheduleGenerators(t *testing.T) {
+	engine := InterestEngine{Convention: daycount.Thirty360}
+	start := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	type row struct {
+		principal, interest, amount float64
+	}
+
+	tests := []struct {
+		name          string
+		repaymentType string
+		amount        float64
+		rate          float64
+		term          int
+		want          []row // ожидаемые платежи; nil - проверяются только итоги
+		wantInterest  float64
+	}{
+		{
+			// 100 000 руб. под 12% на 12 месяцев: платеж 8 884,88 руб., последний выравнивает остаток
+			name:          "annuity",
+			repaymentType: models.RepaymentAnnuity,
+			amount:        100000,
+			rate:          12,
+			term:          12,
+			want: []row{
+				{7884.88, 1000.00, 8884.88},
+				{7963.73, 921.15, 8884.88},
+				{8043.37, 841.51, 8884.88},
+				{8123.80, 761.08, 8884.88},
+				{8205.04, 679.84, 8884.88},
+				{8287.09, 597.79, 8884.88},
+				{8369.96, 514.92, 8884.88},
+				{8453.66, 431.22, 8884.88},
+				{8538.20, 346.68, 8884.88},
+				{8623.58, 261.30, 8884.88},
+				{8709.81, 175.07, 8884.88},
+				{8796.88, 87.97, 8884.85},
+			},
+			wantInterest: 6618.53,
+		},
+		{
+			// Равные доли долга по 10 000 руб., проценты убывают на 100 руб. каждый месяц
+			name:          "differentiated",
+			repaymentType: models.RepaymentDifferentiated,
+			amount:        120000,
+			rate:          12,
+			term:          12,
+			want: []row{
+				{10000, 1200, 11200},
+				{10000, 1100, 11100},
+				{10000, 1000, 11000},
+				{10000, 900, 10900},
+				{10000, 800, 10800},
+				{10000, 700, 10700},
+				{10000, 600, 10600},
+				{10000, 500, 10500},
+				{10000, 400, 10400},
+				{10000, 300, 10300},
+				{10000, 200, 10200},
+				{10000, 100, 10100},
+			},
+			wantInterest: 7800,
+		},
+		{
+			name:          "differentiated with rounded principal",
+			repaymentType: models.RepaymentDifferentiated,
+			amount:        100000,
+			rate:          12,
+			term:          3,
+			want: []row{
+				{33333.33, 1000, 34333.33},
+				{33333.33, 666.67, 34000},
+				{33333.34, 333.33, 33666.67},
+			},
+			wantInterest: 2000,
+		},
+		{
+			name:          "bullet",
+			repaymentType: models.RepaymentBullet,
+			amount:        100000,
+			rate:          12,
+			term:          3,
+			want: []row{
+				{0, 1000, 1000},
+				{0, 1000, 1000},
+				{100000, 1000, 101000},
+			},
+			wantInterest: 3000,
+		},
+		{
+			name:          "annuity without interest",
+			repaymentType: models.RepaymentAnnuity,
+			amount:        1000,
+			rate:          0,
+			term:          3,
+			want: []row{
+				{333.33, 0, 333.33},
+				{333.33, 0, 333.33},
+				{333.34, 0, 333.34},
+			},
+			wantInterest: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := scheduleGeneratorFor(tt.repaymentType)
+			if err != nil {
+				t.Fatalf("scheduleGeneratorFor() error = %v", err)
+			}
+
+			dueDates := monthlyDueDates(start, tt.term)
+			schedule := generator.Generate(engine, tt.amount, tt.rate, start, dueDates)
+			if len(schedule) != len(tt.want) {
+				t.Fatalf("len(schedule) = %d, want %d", len(schedule), len(tt.want))
+			}
+
+			var principal, interest float64
+			for i, payment := range schedule {
+				want := tt.want[i]
+				if payment.PaymentNumber != i+1 || !payment.DueDate.Equal(dueDates[i]) {
+					t.Errorf("payment %d: number %d, due %v", i+1, payment.PaymentNumber, payment.DueDate)
+				}
+				if !moneyEqual(payment.Principal, want.principal) || !moneyEqual(payment.Interest, want.interest) || !moneyEqual(payment.Amount, want.amount) {
+					t.Errorf("payment %d = %.2f + %.2f = %.2f, want %.2f + %.2f = %.2f", i+1,
+						payment.Principal, payment.Interest, payment.Amount, want.principal, want.interest, want.amount)
+				}
+				principal += payment.Principal
+				interest += payment.Interest
+			}
+
+			if !moneyEqual(principal, tt.amount) {
+				t.Errorf("total principal = %.2f, want %.2f", principal, tt.amount)
+			}
+			if !moneyEqual(interest, tt.wantInterest) {
+				t.Errorf("total interest = %.2f, want %.2f", interest, tt.wantInterest)
+			}
+		})
+	}
+}
+
+func TestScheduleGeneratorFor(t *testing.T) {
+	tests := []struct {
+		repaymentType string
+		want          ScheduleGenerator
+		wantErr       error
+	}{
+		{"", annuitySchedule{}, nil},
+		{models.RepaymentAnnuity, annuitySchedule{}, nil},
+		{models.RepaymentDifferentiated, differentiatedSchedule{}, nil},
+		{models.RepaymentBullet, bulletSchedule{}, nil},
+		{"balloon", nil, ErrInvalidRepaymentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repaymentType, func(t *testing.T) {
+			got, err := scheduleGeneratorFor(tt.repaymentType)
+			if err != tt.wantErr || got != tt.want {
+				t.Errorf("scheduleGeneratorFor() = %T, %v, want %T, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxPrincipal(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator ScheduleGenerator
+		payment   float64
+		rate      float64
+		term      int
+		want      float64
+	}{
+		{"annuity", annuitySchedule{}, 8884.88, 12, 12, 100000},
+		{"annuity without interest", annuitySchedule{}, 1000, 0, 12, 12000},
+		{"differentiated", differentiatedSchedule{}, 11200, 12, 12, 120000},
+		{"bullet", bulletSchedule{}, 1000, 12, 12, 100000},
+		{"bullet without interest", bulletSchedule{}, 1000, 0, 12, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Платеж округлен до копеек, поэтому сумма совпадает с точностью до рубля
+			if got := tt.generator.MaxPrincipal(tt.payment, tt.rate, tt.term); math.Abs(got-tt.want) > 1 {
+				t.Errorf("MaxPrincipal() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReducedTerm(t *testing.T) {
+	// Остаток 50 000 руб. при платеже 8 884,88 руб. под 12% гасится за 6 платежей
+	if got := reducedTerm(annuitySchedule{}, 8884.88, 50000, 12, 12); got != 6 {
+		t.Errorf("reducedTerm() = %d, want 6", got)
+	}
+	if got := reducedTerm(annuitySchedule{}, 100, 50000, 12, 12); got != 12 {
+		t.Errorf("reducedTerm() = %d, want 12", got)
+	}
+}
+
+func moneyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
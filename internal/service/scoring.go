@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"fmt"
+	"math"
+)
+
+// CreditScorer принимает решение по кредитной заявке на основе данных о заемщике
+type CreditScorer interface {
+	Score(ctx context.Context, data *models.ScoringData) (*models.ScoringDecision, error)
+}
+
+// RuleBasedScorer - скоринговая модель на правилах: балл складывается из возраста
+// клиента в банке, активности по счетам, долговой нагрузки и просрочек
+type RuleBasedScorer struct {
+	// MinScore - минимальный балл для одобрения заявки
+	MinScore int
+	// MaxPaymentToIncome - максимальная доля платежей по всем кредитам в доходе
+	MaxPaymentToIncome float64
+}
+
+func NewRuleBasedScorer() *RuleBasedScorer {
+	return &RuleBasedScorer{
+		MinScore:           550,
+		MaxPaymentToIncome: 0.5,
+	}
+}
+
+func (s *RuleBasedScorer) Score(ctx context.Context, data *models.ScoringData) (*models.ScoringDecision, error) {
+	decision := &models.ScoringDecision{}
+
+	if data.MonthlyIncome <= 0 {
+		decision.Reasons = append(decision.Reasons, "no confirmed income")
+		return decision, nil
+	}
+
+	score := 600
+
+	// Возраст клиента в банке: до +100 баллов за два года
+	ageBonus := data.AccountAgeDays * 100 / 730
+	if ageBonus > 100 {
+		ageBonus = 100
+	}
+	score += ageBonus
+	if data.AccountAgeDays < 90 {
+		score -= 50
+		decision.Reasons = append(decision.Reasons, fmt.Sprintf("short relationship with the bank: %d days", data.AccountAgeDays))
+	}
+
+	// Активность по счетам: до +50 баллов
+	activityBonus := data.TransactionsCount / 2
+	if activityBonus > 50 {
+		activityBonus = 50
+	}
+	score += activityBonus
+	if data.TransactionsCount < 5 {
+		decision.Reasons = append(decision.Reasons, fmt.Sprintf("low account activity: %d transactions", data.TransactionsCount))
+	}
+
+	// Просрочки по действующим кредитам
+	if data.OverduePayments > 0 {
+		score -= 100 * data.OverduePayments
+		decision.Reasons = append(decision.Reasons, fmt.Sprintf("overdue payments on existing credits: %d", data.OverduePayments))
+	}
+
+	// Текущая долговая нагрузка
+	currentLoad := data.MonthlyPayments / data.MonthlyIncome
+	score -= int(currentLoad * 200)
+	decision.Reasons = append(decision.Reasons, fmt.Sprintf("confirmed monthly income %.2f, existing monthly payments %.2f", data.MonthlyIncome, data.MonthlyPayments))
+
+	decision.Score = score
+	decision.InterestRate = data.KeyRate + scoreMargin(score)
+
+	// Максимальная сумма - при которой платеж укладывается в допустимую долговую нагрузку
+	generator, err := scheduleGeneratorFor(data.RepaymentType)
+	if err != nil {
+		return nil, err
+	}
+
+	availablePayment := data.MonthlyIncome*s.MaxPaymentToIncome - data.MonthlyPayments
+	if availablePayment > 0 {
+		decision.MaxAmount = math.Floor(generator.MaxPrincipal(availablePayment, decision.InterestRate, data.TermMonths))
+	}
+
+	switch {
+	case score < s.MinScore:
+		decision.Reasons = append(decision.Reasons, fmt.Sprintf("score %d is below the minimum %d", score, s.MinScore))
+	case decision.MaxAmount <= 0:
+		decision.Reasons = append(decision.Reasons, "debt burden leaves no room for a new payment")
+	default:
+		decision.Approved = true
+		decision.Reasons = append(decision.Reasons, fmt.Sprintf("approved with score %d, max amount %.2f at %.2f%%", score, decision.MaxAmount, decision.InterestRate))
+		if decision.MaxAmount < data.RequestedAmount {
+			decision.Reasons = append(decision.Reasons, "requested amount is reduced to the max amount")
+		}
+	}
+
+	return decision, nil
+}
+
+// scoreMargin возвращает маржу банка над ключевой ставкой в зависимости от балла
+func scoreMargin(score int) float64 {
+	switch {
+	case score >= 750:
+		return 3.0
+	case score >= 650:
+		return 5.0
+	default:
+		return 7.0
+	}
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"bank-api/internal/models"
+	"bank-api/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrStandingOrderNotFound = errors.New("standing order not found")
+	ErrInvalidStandingOrder  = errors.New("invalid standing order")
+	ErrInvalidSchedule       = errors.New("invalid standing order schedule")
+	ErrStandingOrderStatus   = errors.New("operation is not allowed in the current standing order status")
+)
+
+// StandingOrderTerms - политика повторов при недостатке средств на счете списания
+type StandingOrderTerms struct {
+	MaxAttempts   int           // число попыток перевода по одному сроку, включая первую
+	RetryInterval time.Duration // интервал между попытками
+}
+
+func DefaultStandingOrderTerms() StandingOrderTerms {
+	return StandingOrderTerms{
+		MaxAttempts:   3,
+		RetryInterval: 24 * time.Hour,
+	}
+}
+
+type StandingOrderService struct {
+	repo           repository.StandingOrderRepository
+	accountService *AccountService
+	userRepo       repository.UserRepository
+	terms          StandingOrderTerms
+}
+
+func NewStandingOrderService(repo repository.StandingOrderRepository, accountService *AccountService, userRepo repository.UserRepository, terms StandingOrderTerms) *StandingOrderService {
+	return &StandingOrderService{
+		repo:           repo,
+		accountService: accountService,
+		userRepo:       userRepo,
+		terms:          terms,
+	}
+}
+
+// Create создает постоянное поручение со счета пользователя. Параметры, не относящиеся
+// к выбранному расписанию, не сохраняются.
+func (s *StandingOrderService) Create(ctx context.Context, userID int64, input models.StandingOrderCreate) (*models.StandingOrder, error) {
+	if input.Amount <= 0 || input.FromAccountID == input.ToAccountID {
+		return nil, ErrInvalidStandingOrder
+	}
+
+	fromAccount, err := s.accountService.GetByID(ctx, input.FromAccountID)
+	if err != nil || fromAccount.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+	if _, err := s.accountService.GetByID(ctx, input.ToAccountID); err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &models.StandingOrder{
+		UserID:        userID,
+		FromAccountID: input.FromAccountID,
+		ToAccountID:   input.ToAccountID,
+		Amount:        roundMoney(input.Amount),
+		Description:   input.Description,
+		Schedule:      input.Schedule,
+		Status:        models.StandingOrderStatusActive,
+	}
+
+	switch input.Schedule {
+	case models.StandingOrderOnce:
+		if input.ExecuteAt == nil {
+			return nil, ErrInvalidSchedule
+		}
+		order.ExecuteAt = input.ExecuteAt
+	case models.StandingOrderMonthly:
+		if input.DayOfMonth == nil || *input.DayOfMonth < 1 || *input.DayOfMonth > 31 {
+			return nil, ErrInvalidSchedule
+		}
+		order.DayOfMonth = input.DayOfMonth
+	case models.StandingOrderWeekly:
+		if input.DayOfWeek == nil || *input.DayOfWeek < 1 || *input.DayOfWeek > 7 {
+			return nil, ErrInvalidSchedule
+		}
+		order.DayOfWeek = input.DayOfWeek
+	case models.StandingOrderCron:
+		if _, err := cron.ParseStandard(input.Cron); err != nil {
+			return nil, ErrInvalidSchedule
+		}
+		order.Cron = input.Cron
+	default:
+		return nil, ErrInvalidSchedule
+	}
+
+	if input.EndDate != nil && order.Schedule != models.StandingOrderOnce {
+		endDate := input.EndDate.In(loc)
+		endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+		order.EndDate = &endDate
+	}
+
+	// Первый срок исполнения должен быть в будущем и не позже даты окончания
+	first, ok := nextStandingOrderRun(order, time.Now(), loc)
+	if !ok {
+		return nil, ErrInvalidSchedule
+	}
+	order.NextOccurrence = &first
+	order.NextRunAt = &first
+
+	if err := s.repo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *StandingOrderService) GetByUserID(ctx context.Context, userID int64) ([]*models.StandingOrder, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+func (s *StandingOrderService) GetByID(ctx context.Context, userID, id int64) (*models.StandingOrder, error) {
+	return s.getOwned(ctx, userID, id)
+}
+
+// GetExecutions возвращает историю попыток исполнения поручения
+func (s *StandingOrderService) GetExecutions(ctx context.Context, userID, id int64) ([]*models.StandingOrderExecution, error) {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetExecutions(ctx, id)
+}
+
+// Pause приостанавливает исполнение активного поручения
+func (s *StandingOrderService) Pause(ctx context.Context, userID, id int64) (*models.StandingOrder, error) {
+	order, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.StandingOrderStatusActive {
+		return nil, ErrStandingOrderStatus
+	}
+
+	order.Status = models.StandingOrderStatusPaused
+	if err := s.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Resume возобновляет приостановленное поручение. Сроки, пропущенные во время паузы, не исполняются,
+// кроме разового перевода: он выполняется сразу после возобновления.
+func (s *StandingOrderService) Resume(ctx context.Context, userID, id int64) (*models.StandingOrder, error) {
+	order, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.StandingOrderStatusPaused {
+		return nil, ErrStandingOrderStatus
+	}
+
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	order.Status = models.StandingOrderStatusActive
+	order.Attempts = 0
+	switch {
+	case order.NextOccurrence != nil && order.NextOccurrence.After(now):
+		order.NextRunAt = order.NextOccurrence
+	case order.Schedule == models.StandingOrderOnce:
+		order.NextRunAt = &now
+	default:
+		next, ok := nextStandingOrderRun(order, now, loc)
+		if ok {
+			order.NextOccurrence = &next
+			order.NextRunAt = &next
+		} else {
+			order.Status = models.StandingOrderStatusCompleted
+			order.NextOccurrence = nil
+			order.NextRunAt = nil
+		}
+	}
+
+	if err := s.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Cancel отменяет активное или приостановленное поручение
+func (s *StandingOrderService) Cancel(ctx context.Context, userID, id int64) (*models.StandingOrder, error) {
+	order, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.StandingOrderStatusActive && order.Status != models.StandingOrderStatusPaused {
+		return nil, ErrStandingOrderStatus
+	}
+
+	order.Status = models.StandingOrderStatusCancelled
+	order.NextOccurrence = nil
+	order.NextRunAt = nil
+	if err := s.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ProcessStandingOrders исполняет поручения, срок которых наступил.
+// Ошибка по отдельному поручению не прерывает обработку остальных.
+func (s *StandingOrderService) ProcessStandingOrders(ctx context.Context) error {
+	now := time.Now()
+	orders, err := s.repo.GetDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, order := range orders {
+		if err := s.execute(ctx, order, now); err != nil {
+			log.Printf("execute standing order %d: %v", order.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to process %d of %d standing orders", failed, len(orders))
+	}
+	return nil
+}
+
+// Вспомогательные функции
+
+func (s *StandingOrderService) getOwned(ctx context.Context, userID, id int64) (*models.StandingOrder, error) {
+	order, err := s.repo.GetByID(ctx, id)
+	if err != nil || order.UserID != userID {
+		return nil, ErrStandingOrderNotFound
+	}
+
+	return order, nil
+}
+
+// location возвращает часовой пояс пользователя, в котором считается расписание поручений
+func (s *StandingOrderService) location(ctx context.Context, userID int64) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return userTimezone(user)
+}
+
+// execute выполняет попытку перевода по поручению и сохраняет ее в истории. Перед переводом срок
+// исполнения занимается: поручение переводится к следующему сроку условным обновлением, поэтому
+// параллельный обработчик не выполнит перевод повторно. При недостатке средств попытка повторяется
+// через RetryInterval, пока не исчерпано MaxAttempts попыток и не наступил следующий срок.
+// Остальные ошибки перевода не повторяются.
+func (s *StandingOrderService) execute(ctx context.Context, order *models.StandingOrder, now time.Time) error {
+	loc, err := s.location(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+
+	occurrence := *order.NextOccurrence
+	execution := &models.StandingOrderExecution{
+		StandingOrderID: order.ID,
+		ScheduledAt:     occurrence,
+		Attempt:         order.Attempts + 1,
+		Status:          models.ExecutionStatusCompleted,
+	}
+
+	// Следующий срок после текущего. Если исполнение задержалось на несколько сроков,
+	// пропущенные сроки не исполняются.
+	next, hasNext := nextStandingOrderRun(order, occurrence, loc)
+	if hasNext && next.Before(now) {
+		next, hasNext = nextStandingOrderRun(order, now, loc)
+	}
+
+	// Занимаем срок исполнения до перевода
+	status, runAt := order.Status, order.NextRunAt
+	order.Attempts = 0
+	if hasNext {
+		order.NextOccurrence = &next
+		order.NextRunAt = &next
+	} else {
+		order.NextOccurrence = nil
+		order.NextRunAt = nil
+		order.Status = models.StandingOrderStatusCompleted
+	}
+	claimed, err := s.repo.Advance(ctx, order, status, runAt)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	transaction, err := s.accountService.Transfer(ctx, order.FromAccountID, order.ToAccountID, order.Amount)
+	if err == nil {
+		execution.TransactionID = &transaction.ID
+		return s.repo.CreateExecution(ctx, execution)
+	}
+
+	execution.Status = models.ExecutionStatusFailed
+	execution.Error = err.Error()
+
+	// Возвращаем поручение к текущему сроку для повторной попытки или отмечаем неисполненный
+	// разовый перевод. Состояние не меняется, если поручение изменено после занятия срока.
+	status, runAt = order.Status, order.NextRunAt
+	retryAt := now.Add(s.terms.RetryInterval)
+	changed := true
+	switch {
+	case err == ErrInsufficientFunds && execution.Attempt < s.terms.MaxAttempts && (!hasNext || retryAt.Before(next)):
+		execution.Status = models.ExecutionStatusRetry
+		order.Status = models.StandingOrderStatusActive
+		order.Attempts = execution.Attempt
+		order.NextOccurrence = &occurrence
+		order.NextRunAt = &retryAt
+	case order.Schedule == models.StandingOrderOnce:
+		order.Status = models.StandingOrderStatusFailed
+	default:
+		changed = false
+	}
+
+	if err := s.repo.CreateExecution(ctx, execution); err != nil {
+		return err
+	}
+	if changed {
+		if _, err := s.repo.Advance(ctx, order, status, runAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduledTransfer - ожидаемый перевод по поручению
+type scheduledTransfer struct {
+	Date  time.Time
+	Order *models.StandingOrder
+}
+
+// scheduledTransfers возвращает ожидаемые переводы по активным поручениям со счетов accountIDs
+// и на них в периоде [from, to). Просроченные попытки считаются ожидаемыми в момент from.
+func (s *StandingOrderService) scheduledTransfers(ctx context.Context, accountIDs []int64, from, to time.Time, loc *time.Location) ([]scheduledTransfer, error) {
+	orders, err := s.repo.GetActiveByAccounts(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []scheduledTransfer
+	for _, order := range orders {
+		if order.NextOccurrence == nil || order.NextRunAt == nil {
+			continue
+		}
+
+		occurrence, date := *order.NextOccurrence, *order.NextRunAt
+		for {
+			if date.Before(from) {
+				date = from
+			}
+			if !date.Before(to) {
+				break
+			}
+			transfers = append(transfers, scheduledTransfer{Date: date, Order: order})
+
+			next, ok := nextStandingOrderRun(order, occurrence, loc)
+			if !ok {
+				break
+			}
+			occurrence, date = next, next
+		}
+	}
+
+	return transfers, nil
+}
+
+// nextStandingOrderRun возвращает первый срок перевода по поручению позже момента after
+// с учетом даты окончания поручения
+func nextStandingOrderRun(order *models.StandingOrder, after time.Time, loc *time.Location) (time.Time, bool) {
+	local := after.In(loc)
+	var next time.Time
+
+	switch order.Schedule {
+	case models.StandingOrderOnce:
+		if order.ExecuteAt == nil || !order.ExecuteAt.After(after) {
+			return time.Time{}, false
+		}
+		next = *order.ExecuteAt
+	case models.StandingOrderMonthly:
+		next = monthDay(local.Year(), local.Month(), *order.DayOfMonth, loc)
+		if !next.After(after) {
+			next = monthDay(local.Year(), local.Month()+1, *order.DayOfMonth, loc)
+		}
+	case models.StandingOrderWeekly:
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		// В поручении неделя начинается с понедельника (1), в time.Weekday - с воскресенья (0)
+		shift := (*order.DayOfWeek%7 - int(day.Weekday()) + 7) % 7
+		next = day.AddDate(0, 0, shift)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+	case models.StandingOrderCron:
+		schedule, err := cron.ParseStandard(order.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		next = schedule.Next(local)
+		if next.IsZero() {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if order.EndDate != nil && dayKey(next.In(loc)) > dayKey(*order.EndDate) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+// monthDay возвращает начало дня day месяца month (последнего дня, если в месяце меньше дней)
+func monthDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"bank-api/internal/models"
+	"bank-api/pkg/pdfdoc"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+var (
+	ErrInvalidStatementPeriod = errors.New("invalid statement period")
+	ErrInvalidStatementFormat = errors.New("invalid statement format")
+)
+
+// maxStatementPeriod - максимальная длительность периода выписки
+const maxStatementPeriod = 5 * 366 * 24 * time.Hour
+
+// statementWriter выводит выписку в одном из форматов: заголовок, строки по одной, итоги
+type statementWriter interface {
+	begin(statement *models.AccountStatement) error
+	line(line *models.StatementLine) error
+	end(statement *models.AccountStatement) error
+}
+
+// GetStatement возвращает заголовок выписки по счету пользователя за период с from по to включительно.
+// Остатки рассчитываются от текущего остатка счета назад по проведенным операциям, поэтому
+// сходятся с балансом счета, даже если часть его истории не отражена операциями.
+func (s *AccountService) GetStatement(ctx context.Context, userID, accountID int64, from, to time.Time) (*models.AccountStatement, error) {
+	if to.Before(from) || to.Sub(from) > maxStatementPeriod {
+		return nil, ErrInvalidStatementPeriod
+	}
+
+	account, err := s.repo.GetByID(ctx, accountID)
+	if err != nil || account.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Входящий остаток - текущий остаток за вычетом оборотов с начала периода
+	now := time.Now()
+	incoming, outgoing, err := s.repo.GetTurnover(ctx, accountID, from, now)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.AccountStatement{
+		Account:        account,
+		Owner:          owner.Username,
+		From:           from,
+		To:             to,
+		OpeningBalance: roundMoney(account.Balance - incoming + outgoing),
+		CreatedAt:      now,
+	}
+
+	incoming, outgoing, err = s.repo.GetTurnover(ctx, accountID, from, statementEnd(statement))
+	if err != nil {
+		return nil, err
+	}
+
+	statement.TotalIncoming = roundMoney(incoming)
+	statement.TotalOutgoing = roundMoney(outgoing)
+	statement.ClosingBalance = roundMoney(statement.OpeningBalance + incoming - outgoing)
+
+	return statement, nil
+}
+
+// WriteStatement выводит выписку в w в указанном формате. Строки читаются из базы и выводятся
+// по одной; CSV и 1C записываются в w сразу, PDF формируется целиком перед отправкой.
+func (s *AccountService) WriteStatement(ctx context.Context, statement *models.AccountStatement, format string, w io.Writer) error {
+	writer, err := newStatementWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.begin(statement); err != nil {
+		return err
+	}
+
+	balance := statement.OpeningBalance
+	err = s.repo.StreamStatementLines(ctx, statement.Account.ID, statement.From, statementEnd(statement), func(line *models.StatementLine) error {
+		balance = roundMoney(balance + line.Incoming - line.Outgoing)
+		line.Balance = balance
+		return writer.line(line)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.end(statement)
+}
+
+// StatementContentType возвращает MIME-тип и расширение файла выписки
+func StatementContentType(format string) (string, string, error) {
+	switch format {
+	case models.StatementFormatCSV:
+		return "text/csv; charset=utf-8", "csv", nil
+	case models.StatementFormatPDF:
+		return "application/pdf", "pdf", nil
+	case models.StatementFormat1C:
+		return "text/plain; charset=windows-1251", "txt", nil
+	default:
+		return "", "", ErrInvalidStatementFormat
+	}
+}
+
+// csvStatementWriter выводит выписку в CSV с разделителем ";" (открывается в Excel)
+type csvStatementWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvStatementWriter) begin(statement *models.AccountStatement) error {
+	c.w.Comma = ';'
+	records := [][]string{
+		{"Выписка по счету", statement.Account.Number},
+		{"Владелец", statement.Owner},
+		{"Валюта", statement.Account.Currency},
+		{"Период", formatDate(statement.From) + " - " + formatDate(statement.To)},
+		{"Входящий остаток", plainAmount(statement.OpeningBalance)},
+		{},
+		{"Дата", "Номер", "Операция", "Счет контрагента", "Контрагент", "Поступление", "Списание", "Остаток"},
+	}
+	return c.w.WriteAll(records)
+}
+
+func (c *csvStatementWriter) line(line *models.StatementLine) error {
+	return c.w.Write([]string{
+		line.Transaction.CreatedAt.Format("02.01.2006 15:04:05"),
+		strconv.FormatInt(line.Transaction.ID, 10),
+		transactionTypeName(line.Transaction.Type),
+		line.CounterpartyAccount,
+		line.CounterpartyName,
+		plainAmount(line.Incoming),
+		plainAmount(line.Outgoing),
+		plainAmount(line.Balance),
+	})
+}
+
+func (c *csvStatementWriter) end(statement *models.AccountStatement) error {
+	records := [][]string{
+		{"Итого", "", "", "", "", plainAmount(statement.TotalIncoming), plainAmount(statement.TotalOutgoing), ""},
+		{},
+		{"Исходящий остаток", plainAmount(statement.ClosingBalance)},
+	}
+	return c.w.WriteAll(records)
+}
+
+// pdfStatementWriter выводит выписку в PDF. Документ собирается в памяти, т.к. число страниц
+// выводится в колонтитуле, и записывается в w после последней строки.
+type pdfStatementWriter struct {
+	w   io.Writer
+	doc *pdfdoc.Document
+}
+
+func (p *pdfStatementWriter) begin(statement *models.AccountStatement) error {
+	p.doc = pdfdoc.New("Выписка по счету "+statement.Account.Number, statement.CreatedAt)
+	p.doc.Heading("Выписка по счету № " + statement.Account.Number)
+	p.doc.Field("Банк:", bankName)
+	p.doc.Field("Владелец счета:", statement.Owner)
+	p.doc.Field("Валюта:", statement.Account.Currency)
+	p.doc.Field("Период:", "с "+formatDate(statement.From)+" по "+formatDate(statement.To))
+	p.doc.Field("Входящий остаток:", formatMoney(statement.OpeningBalance))
+	p.doc.Table([]pdfdoc.Column{
+		{Title: "Дата", Width: 28, Align: pdfdoc.AlignCenter},
+		{Title: "Операция", Width: 34, Align: pdfdoc.AlignLeft},
+		{Title: "Контрагент", Width: 40, Align: pdfdoc.AlignLeft},
+		{Title: "Поступление", Width: 26, Align: pdfdoc.AlignRight},
+		{Title: "Списание", Width: 26, Align: pdfdoc.AlignRight},
+		{Title: "Остаток", Width: 26, Align: pdfdoc.AlignRight},
+	})
+	return nil
+}
+
+func (p *pdfStatementWriter) line(line *models.StatementLine) error {
+	p.doc.Row(
+		line.Transaction.CreatedAt.Format("02.01.2006 15:04"),
+		transactionTypeName(line.Transaction.Type),
+		line.CounterpartyAccount,
+		pdfAmount(line.Incoming),
+		pdfAmount(line.Outgoing),
+		formatMoney(line.Balance),
+	)
+	return nil
+}
+
+func (p *pdfStatementWriter) end(statement *models.AccountStatement) error {
+	p.doc.TotalRow("Итого обороты", "", "", formatMoney(statement.TotalIncoming), formatMoney(statement.TotalOutgoing), "")
+	p.doc.EndTable()
+	p.doc.Field("Исходящий остаток:", formatMoney(statement.ClosingBalance))
+	p.doc.Field("Дата формирования:", statement.CreatedAt.Format("02.01.2006 15:04"))
+	return p.doc.Write(p.w)
+}
+
+// oneCStatementWriter выводит выписку в формате обмена 1CClientBankExchange версии 1.03
+// в кодировке Windows-1251 с переводами строк CRLF
+type oneCStatementWriter struct {
+	w         *bufio.Writer
+	statement *models.AccountStatement
+}
+
+func (o *oneCStatementWriter) begin(statement *models.AccountStatement) error {
+	o.statement = statement
+	o.keyword("1CClientBankExchange")
+	o.field("ВерсияФормата", "1.03")
+	o.field("Кодировка", "Windows")
+	o.field("Отправитель", bankName)
+	o.field("Получатель", "")
+	o.field("ДатаСоздания", formatDate(statement.CreatedAt))
+	o.field("ВремяСоздания", statement.CreatedAt.Format("15:04:05"))
+	o.field("ДатаНачала", formatDate(statement.From))
+	o.field("ДатаКонца", formatDate(statement.To))
+	o.field("РасчСчет", statement.Account.Number)
+	o.keyword("СекцияРасчСчет")
+	o.field("ДатаНачала", formatDate(statement.From))
+	o.field("ДатаКонца", formatDate(statement.To))
+	o.field("РасчСчет", statement.Account.Number)
+	o.field("НачальныйОстаток", plainAmount(statement.OpeningBalance))
+	o.field("ВсегоПоступило", plainAmount(statement.TotalIncoming))
+	o.field("ВсегоСписано", plainAmount(statement.TotalOutgoing))
+	o.field("КонечныйОстаток", plainAmount(statement.ClosingBalance))
+	o.keyword("КонецРасчСчет")
+	return nil
+}
+
+func (o *oneCStatementWriter) line(line *models.StatementLine) error {
+	transaction := line.Transaction
+	payerAccount, recipientAccount := line.CounterpartyAccount, o.statement.Account.Number
+	payer, recipient := line.CounterpartyName, o.statement.Owner
+	dateField := "ДатаПоступило"
+	if line.Outgoing > 0 {
+		payerAccount, recipientAccount = recipientAccount, payerAccount
+		payer, recipient = recipient, payer
+		dateField = "ДатаСписано"
+	}
+
+	o.field("СекцияДокумент", "Банковский ордер")
+	o.field("Номер", strconv.FormatInt(transaction.ID, 10))
+	o.field("Дата", formatDate(transaction.CreatedAt))
+	o.field("Сумма", plainAmount(transaction.Amount))
+	o.field("ПлательщикСчет", payerAccount)
+	o.field("Плательщик", payer)
+	o.field("ПолучательСчет", recipientAccount)
+	o.field("Получатель", recipient)
+	o.field(dateField, formatDate(transaction.CreatedAt))
+	o.field("НазначениеПлатежа", transactionTypeName(transaction.Type))
+	o.keyword("КонецДокумента")
+	return nil
+}
+
+func (o *oneCStatementWriter) end(statement *models.AccountStatement) error {
+	o.keyword("КонецФайла")
+	return o.w.Flush()
+}
+
+// field выводит строку "ключ=значение". Ошибка записи сохраняется в bufio.Writer
+// и возвращается при Flush.
+func (o *oneCStatementWriter) field(key, value string) {
+	fmt.Fprintf(o.w, "%s=%s\r\n", key, value)
+}
+
+// keyword выводит ключевое слово начала или конца секции
+func (o *oneCStatementWriter) keyword(key string) {
+	fmt.Fprintf(o.w, "%s\r\n", key)
+}
+
+// Вспомогательные функции
+
+func newStatementWriter(format string, w io.Writer) (statementWriter, error) {
+	switch format {
+	case models.StatementFormatCSV:
+		// BOM нужен, чтобы Excel распознал UTF-8
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+		return &csvStatementWriter{w: csv.NewWriter(w)}, nil
+	case models.StatementFormatPDF:
+		return &pdfStatementWriter{w: w}, nil
+	case models.StatementFormat1C:
+		encoder := encoding.ReplaceUnsupported(charmap.Windows1251.NewEncoder())
+		return &oneCStatementWriter{w: bufio.NewWriter(encoder.Writer(w))}, nil
+	default:
+		return nil, ErrInvalidStatementFormat
+	}
+}
+
+// statementEnd возвращает момент окончания периода выписки (начало дня после последнего)
+func statementEnd(statement *models.AccountStatement) time.Time {
+	return statement.To.AddDate(0, 0, 1)
+}
+
+func transactionTypeName(transactionType string) string {
+	switch transactionType {
+	case "transfer":
+		return "Перевод между счетами"
+	case "card_payment":
+		return "Оплата картой"
+	case "card_withdrawal":
+		return "Снятие наличных по карте"
+	case "cash_deposit":
+		return "Внесение наличных в кассе"
+	case "cash_withdrawal":
+		return "Выдача наличных в кассе"
+	case "top_up":
+		return "Пополнение из другого банка"
+	case "withdrawal":
+		return "Перевод в другой банк"
+	case "reversal":
+		return "Отмена операции"
+	case "refund":
+		return "Возврат средств"
+	case "credit_disbursement":
+		return "Выдача кредита"
+	case "credit_payment":
+		return "Платеж по кредиту"
+	case "credit_prepayment":
+		return "Досрочное погашение кредита"
+	case "credit_line_interest":
+		return "Проценты по кредитной линии"
+	case "deposit_open":
+		return "Открытие вклада"
+	case "deposit_top_up":
+		return "Пополнение вклада"
+	case "deposit_withdrawal":
+		return "Частичное снятие со вклада"
+	case "deposit_interest":
+		return "Выплата процентов по вкладу"
+	case "deposit_interest_withheld":
+		return "Удержание процентов по вкладу"
+	case "deposit_close":
+		return "Закрытие вклада"
+	default:
+		return transactionType
+	}
+}
+
+// plainAmount форматирует сумму для обмена с учетными системами: "1234.50"
+func plainAmount(amount float64) string {
+	return strconv.FormatFloat(roundMoney(amount), 'f', 2, 64)
+}
+
+// pdfAmount выводит пустую ячейку вместо нулевой суммы
+func pdfAmount(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return formatMoney(amount)
+}
@@ -5,6 +5,7 @@ import (
 	"bank-api/internal/models"
 	"bank-api/internal/repository"
 	"errors"
+	"time"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -12,6 +13,8 @@ var (
 	ErrUserAlreadyExists    = errors.New("user with this email already exists")
 	ErrUsernameAlreadyExists = errors.New("username is already taken")
 	ErrInvalidCredentials   = errors.New("invalid email or password")
+	ErrInvalidTimezone      = errors.New("invalid timezone")
+	ErrUserNotFound         = errors.New("user not found")
 )
 
 type UserService struct {
@@ -40,6 +43,14 @@ func (s *UserService) Register(ctx context.Context, input models.UserRegistratio
 		return nil, ErrUsernameAlreadyExists
 	}
 
+	timezone := input.Timezone
+	if timezone == "" {
+		timezone = models.DefaultTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
 	// Хеширование пароля
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -50,6 +61,8 @@ func (s *UserService) Register(ctx context.Context, input models.UserRegistratio
 		Email:    input.Email,
 		Username: input.Username,
 		Password: string(hashedPassword),
+		Role:     models.RoleCustomer,
+		Timezone: timezone,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
@@ -76,6 +89,31 @@ func (s *UserService) GetByID(ctx context.Context, id int64) (*models.User, erro
 	return s.repo.GetByID(ctx, id)
 }
 
+// UpdateProfile изменяет настройки профиля пользователя
+func (s *UserService) UpdateProfile(ctx context.Context, id int64, input models.UserProfileUpdate) (*models.User, error) {
+	if input.Timezone == "" {
+		return nil, ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.Timezone = input.Timezone
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (s *UserService) Update(ctx context.Context, user *models.User) error {
 	return s.repo.Update(ctx, user)
 }
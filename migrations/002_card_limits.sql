@@ -0,0 +1,30 @@
+-- Лимиты и ограничения по картам
+CREATE TABLE card_limits (
+    card_id BIGINT PRIMARY KEY REFERENCES cards(id),
+    daily_limit DECIMAL(15,2) NOT NULL DEFAULT 0,
+    monthly_limit DECIMAL(15,2) NOT NULL DEFAULT 0,
+    per_transaction_limit DECIMAL(15,2) NOT NULL DEFAULT 0,
+    online_enabled BOOLEAN NOT NULL DEFAULT true,
+    contactless_enabled BOOLEAN NOT NULL DEFAULT true,
+    atm_enabled BOOLEAN NOT NULL DEFAULT true,
+    foreign_enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Накопительные счетчики расходов по картам (по дням и по месяцам)
+CREATE TABLE card_spend_counters (
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    period VARCHAR(10) NOT NULL,
+    period_start DATE NOT NULL,
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (card_id, period, period_start)
+);
+
+CREATE INDEX idx_cards_number ON cards(number);
+
+CREATE TRIGGER update_card_limits_updated_at
+    BEFORE UPDATE ON card_limits
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+-- Жизненный цикл карт: статус, причина смены статуса и связь с перевыпущенной картой
+ALTER TABLE cards ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE cards ADD COLUMN status_reason VARCHAR(30);
+ALTER TABLE cards ADD COLUMN previous_card_id BIGINT REFERENCES cards(id);
+ALTER TABLE cards ADD COLUMN auto_reissue BOOLEAN NOT NULL DEFAULT true;
+
+UPDATE cards SET status = 'closed', status_reason = 'customer_request' WHERE is_active = false;
+
+CREATE INDEX idx_cards_status ON cards(status);
+CREATE INDEX idx_cards_previous_card_id ON cards(previous_card_id);
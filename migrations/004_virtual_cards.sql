@@ -0,0 +1,6 @@
+-- Виртуальные и одноразовые карты
+ALTER TABLE cards ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'physical';
+ALTER TABLE cards ADD COLUMN merchant_account_id BIGINT REFERENCES accounts(id);
+ALTER TABLE cards ADD COLUMN valid_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_cards_single_use_valid_until ON cards(valid_until) WHERE type = 'single_use';
@@ -0,0 +1,42 @@
+-- Справочник BIN-диапазонов выпускаемых карт
+CREATE TABLE card_bins (
+    id BIGSERIAL PRIMARY KEY,
+    bin VARCHAR(8) UNIQUE NOT NULL,
+    payment_system VARCHAR(20) NOT NULL,
+    product_code VARCHAR(50) NOT NULL,
+    product_name VARCHAR(100) NOT NULL,
+    product_type VARCHAR(20) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO card_bins (bin, payment_system, product_code, product_name, product_type, is_default) VALUES
+    ('220099', 'mir', 'mir_classic', 'Мир Классическая', 'debit', true),
+    ('220098', 'mir', 'mir_premium', 'Мир Премиальная', 'debit', false),
+    ('489099', 'visa', 'visa_classic', 'Visa Classic', 'debit', false),
+    ('489098', 'visa', 'visa_gold', 'Visa Gold', 'debit', false),
+    ('539099', 'mastercard', 'mastercard_standard', 'Mastercard Standard', 'debit', false),
+    ('539098', 'mastercard', 'mastercard_world', 'Mastercard World', 'debit', false);
+
+ALTER TABLE cards ADD COLUMN payment_system VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE cards ADD COLUMN product_code VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE cards ADD COLUMN product_type VARCHAR(20) NOT NULL DEFAULT '';
+
+UPDATE cards SET payment_system = CASE
+    WHEN number LIKE '220%' THEN 'mir'
+    WHEN number LIKE '4%' THEN 'visa'
+    WHEN number LIKE '5%' OR number LIKE '2%' THEN 'mastercard'
+    ELSE 'unknown'
+END;
+
+-- Номер карты должен быть уникальным. Перед применением миграции
+-- дубликаты, выпущенные старым генератором, необходимо перевыпустить.
+DROP INDEX IF EXISTS idx_cards_number;
+CREATE UNIQUE INDEX idx_cards_number ON cards(number);
+
+CREATE TRIGGER update_card_bins_updated_at
+    BEFORE UPDATE ON card_bins
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
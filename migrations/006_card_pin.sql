@@ -0,0 +1,3 @@
+-- PIN-код карты: зашифрованный PIN-блок ISO 9564 и счетчик неверных попыток ввода
+ALTER TABLE cards ADD COLUMN encrypted_pin_block VARCHAR(32);
+ALTER TABLE cards ADD COLUMN pin_try_count INTEGER NOT NULL DEFAULT 0;
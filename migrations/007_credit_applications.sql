@@ -0,0 +1,25 @@
+-- Заявки на кредит и решения скоринга
+CREATE TABLE credit_applications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    amount DECIMAL(15,2) NOT NULL,
+    term_months INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    max_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    interest_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    credit_id BIGINT REFERENCES credits(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_applications_user_id ON credit_applications(user_id);
+CREATE INDEX idx_credit_applications_status ON credit_applications(status);
+
+CREATE TRIGGER update_credit_applications_updated_at
+    BEFORE UPDATE ON credit_applications
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
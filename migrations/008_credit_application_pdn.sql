@@ -0,0 +1,3 @@
+-- Показатель долговой нагрузки (ПДН) по заявке на кредит
+ALTER TABLE credit_applications ADD COLUMN pdn DECIMAL(7,2) NOT NULL DEFAULT 0;
+ALTER TABLE credit_applications ADD COLUMN pdn_flagged BOOLEAN NOT NULL DEFAULT false;
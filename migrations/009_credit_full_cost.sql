@@ -0,0 +1,9 @@
+-- Полная стоимость кредита (ПСК), комиссия за выдачу и страхование
+ALTER TABLE credits ADD COLUMN psk DECIMAL(8,3) NOT NULL DEFAULT 0;
+ALTER TABLE credits ADD COLUMN psk_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE credits ADD COLUMN issue_fee DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE credits ADD COLUMN insurance_premium DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE credit_applications ADD COLUMN with_insurance BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE credit_applications ADD COLUMN psk DECIMAL(8,3) NOT NULL DEFAULT 0;
+ALTER TABLE credit_applications ADD COLUMN psk_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
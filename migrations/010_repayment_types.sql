@@ -0,0 +1,3 @@
+-- Тип погашения кредита: аннуитетный, дифференцированный, проценты ежемесячно с погашением долга в конце срока
+ALTER TABLE credits ADD COLUMN repayment_type VARCHAR(20) NOT NULL DEFAULT 'annuity';
+ALTER TABLE credit_applications ADD COLUMN repayment_type VARCHAR(20) NOT NULL DEFAULT 'annuity';
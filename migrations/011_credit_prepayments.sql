@@ -0,0 +1,20 @@
+-- Версии графика платежей: при пересчете графика старые строки сохраняются для истории
+ALTER TABLE credits ADD COLUMN schedule_version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE payment_schedules ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_payment_schedules_credit_version ON payment_schedules(credit_id, version);
+
+-- Досрочные погашения кредитов
+CREATE TABLE credit_prepayments (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES credits(id),
+    mode VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    principal DECIMAL(15,2) NOT NULL,
+    interest DECIMAL(15,2) NOT NULL,
+    previous_version INTEGER NOT NULL,
+    schedule_version INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_prepayments_credit_id ON credit_prepayments(credit_id);
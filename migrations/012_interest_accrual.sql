@@ -0,0 +1,54 @@
+-- Конвенция расчета дней для начисления процентов фиксируется при выдаче кредита
+ALTER TABLE credits ADD COLUMN day_count VARCHAR(20) NOT NULL DEFAULT 'actual/365';
+
+-- Производственный календарь: праздничные нерабочие дни и рабочие дни, перенесенные на выходные.
+-- Даты платежей, выпадающие на нерабочие дни, переносятся на следующий рабочий день.
+CREATE TABLE calendar_days (
+    date DATE PRIMARY KEY,
+    is_working BOOLEAN NOT NULL DEFAULT false,
+    description VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+INSERT INTO calendar_days (date, is_working, description) VALUES
+    ('2025-01-01', false, 'Новогодние каникулы'),
+    ('2025-01-02', false, 'Новогодние каникулы'),
+    ('2025-01-03', false, 'Новогодние каникулы'),
+    ('2025-01-06', false, 'Новогодние каникулы'),
+    ('2025-01-07', false, 'Рождество Христово'),
+    ('2025-01-08', false, 'Новогодние каникулы'),
+    ('2025-05-01', false, 'Праздник Весны и Труда'),
+    ('2025-05-02', false, 'Перенос выходного дня'),
+    ('2025-05-08', false, 'Перенос выходного дня'),
+    ('2025-05-09', false, 'День Победы'),
+    ('2025-06-12', false, 'День России'),
+    ('2025-06-13', false, 'Перенос выходного дня'),
+    ('2025-11-01', true, 'Рабочая суббота'),
+    ('2025-11-03', false, 'Перенос выходного дня'),
+    ('2025-11-04', false, 'День народного единства'),
+    ('2025-12-31', false, 'Перенос выходного дня'),
+    ('2026-01-01', false, 'Новогодние каникулы'),
+    ('2026-01-02', false, 'Новогодние каникулы'),
+    ('2026-01-05', false, 'Новогодние каникулы'),
+    ('2026-01-06', false, 'Новогодние каникулы'),
+    ('2026-01-07', false, 'Рождество Христово'),
+    ('2026-01-08', false, 'Новогодние каникулы'),
+    ('2026-01-09', false, 'Перенос выходного дня'),
+    ('2026-02-23', false, 'День защитника Отечества'),
+    ('2026-03-09', false, 'Перенос выходного дня'),
+    ('2026-05-01', false, 'Праздник Весны и Труда'),
+    ('2026-05-11', false, 'Перенос выходного дня'),
+    ('2026-06-12', false, 'День России'),
+    ('2026-11-04', false, 'День народного единства'),
+    ('2026-12-31', false, 'Перенос выходного дня');
+
+-- Ежедневные начисления процентов по кредитам
+CREATE TABLE interest_accruals (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES credits(id),
+    accrual_date DATE NOT NULL,
+    principal DECIMAL(15,2) NOT NULL,
+    interest_rate DECIMAL(5,2) NOT NULL,
+    amount DECIMAL(15,6) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (credit_id, accrual_date)
+);
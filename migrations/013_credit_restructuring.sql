@@ -0,0 +1,29 @@
+-- Роли пользователей: клиенты и операторы банка
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer';
+
+-- Запросы на реструктуризацию кредитов (кредитные каникулы, увеличение срока, изменение ставки)
+CREATE TABLE credit_restructurings (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES credits(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL,
+    payments INTEGER NOT NULL DEFAULT 0,
+    extra_months INTEGER NOT NULL DEFAULT 0,
+    interest_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    operator_id BIGINT REFERENCES users(id),
+    decision_comment TEXT NOT NULL DEFAULT '',
+    schedule_version INTEGER,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_restructurings_credit_id ON credit_restructurings(credit_id);
+CREATE INDEX idx_credit_restructurings_status ON credit_restructurings(status);
+
+CREATE TRIGGER update_credit_restructurings_updated_at
+    BEFORE UPDATE ON credit_restructurings
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
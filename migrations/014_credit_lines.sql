@@ -0,0 +1,54 @@
+-- Лимит овердрафта: баланс счета может уходить в минус в пределах лимита кредитной линии
+ALTER TABLE accounts ADD COLUMN overdraft_limit DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- Возобновляемые кредитные линии (овердрафт)
+CREATE TABLE credit_lines (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    credit_limit DECIMAL(15,2) NOT NULL,
+    interest_rate DECIMAL(5,2) NOT NULL,
+    grace_days INTEGER NOT NULL,
+    min_payment_percent DECIMAL(5,2) NOT NULL,
+    statement_day INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    accrued_interest DECIMAL(15,6) NOT NULL DEFAULT 0,
+    grace_interest DECIMAL(15,6) NOT NULL DEFAULT 0,
+    grace_start DATE,
+    last_accrual_date DATE,
+    last_statement_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- На счете может быть только одна действующая кредитная линия
+CREATE UNIQUE INDEX idx_credit_lines_active_account ON credit_lines(account_id) WHERE status = 'active';
+CREATE INDEX idx_credit_lines_user_id ON credit_lines(user_id);
+
+CREATE TRIGGER update_credit_lines_updated_at
+    BEFORE UPDATE ON credit_lines
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Ежемесячные выписки по кредитным линиям
+CREATE TABLE credit_line_statements (
+    id BIGSERIAL PRIMARY KEY,
+    credit_line_id BIGINT NOT NULL REFERENCES credit_lines(id),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    opening_debt DECIMAL(15,2) NOT NULL,
+    closing_debt DECIMAL(15,2) NOT NULL,
+    interest_charged DECIMAL(15,2) NOT NULL,
+    min_payment DECIMAL(15,2) NOT NULL,
+    due_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_credit_line_statements_line_id ON credit_line_statements(credit_line_id);
+
+CREATE TRIGGER update_credit_line_statements_updated_at
+    BEFORE UPDATE ON credit_line_statements
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
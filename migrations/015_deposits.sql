@@ -0,0 +1,29 @@
+-- Вклады и накопительные счета
+CREATE TABLE deposits (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    balance DECIMAL(15,2) NOT NULL,
+    interest_rate DECIMAL(5,2) NOT NULL,
+    capitalization BOOLEAN NOT NULL DEFAULT FALSE,
+    term_months INTEGER NOT NULL DEFAULT 0,
+    accrued_interest DECIMAL(15,6) NOT NULL DEFAULT 0,
+    interest_paid DECIMAL(15,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    next_interest_date DATE NOT NULL,
+    maturity_date DATE,
+    last_accrual_date DATE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_deposits_user_id ON deposits(user_id);
+CREATE INDEX idx_deposits_status ON deposits(status);
+
+CREATE TRIGGER update_deposits_updated_at
+    BEFORE UPDATE ON deposits
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
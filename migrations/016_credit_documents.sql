@@ -0,0 +1,12 @@
+-- PDF-документы по кредитам: договор и справка о погашении
+CREATE TABLE credit_documents (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT NOT NULL REFERENCES credits(id),
+    type VARCHAR(30) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    size INTEGER NOT NULL,
+    content BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (credit_id, type)
+);
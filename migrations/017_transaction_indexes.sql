@@ -0,0 +1,9 @@
+-- Составные индексы для постраничной истории транзакций: отбор по счету с сортировкой
+-- по дате или сумме и ключом id для курсора. Одиночные индексы по счетам становятся избыточными.
+DROP INDEX IF EXISTS idx_transactions_from_account_id;
+DROP INDEX IF EXISTS idx_transactions_to_account_id;
+
+CREATE INDEX idx_transactions_from_account_created ON transactions(from_account_id, created_at, id);
+CREATE INDEX idx_transactions_to_account_created ON transactions(to_account_id, created_at, id);
+CREATE INDEX idx_transactions_from_account_amount ON transactions(from_account_id, amount, id);
+CREATE INDEX idx_transactions_to_account_amount ON transactions(to_account_id, amount, id);
@@ -0,0 +1,44 @@
+-- Данные торговой точки для карточных операций: код категории (MCC) и наименование
+ALTER TABLE transactions
+    ADD COLUMN mcc VARCHAR(4) NOT NULL DEFAULT '',
+    ADD COLUMN merchant_name VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Правила категоризации: пользовательские (user_id задан) и правила банка по контрагентам (user_id NULL).
+-- Правило срабатывает, если совпадают все заданные условия.
+CREATE TABLE category_rules (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id),
+    category VARCHAR(30) NOT NULL,
+    counterparty VARCHAR(20) NOT NULL DEFAULT '',
+    mcc VARCHAR(4) NOT NULL DEFAULT '',
+    merchant VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_category_rules_user_id ON category_rules(user_id);
+
+CREATE TRIGGER update_category_rules_updated_at
+    BEFORE UPDATE ON category_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Категории операций с точки зрения каждого участника: перевод между клиентами
+-- является расходом для отправителя и доходом для получателя
+CREATE TABLE transaction_categories (
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    direction VARCHAR(10) NOT NULL,
+    category VARCHAR(30) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (transaction_id, user_id)
+);
+
+CREATE INDEX idx_transaction_categories_user_category ON transaction_categories(user_id, direction, category);
+
+CREATE TRIGGER update_transaction_categories_updated_at
+    BEFORE UPDATE ON transaction_categories
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Часовой пояс пользователя (имя из базы IANA) для расчета аналитики по календарным периодам
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow';
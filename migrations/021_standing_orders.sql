@@ -0,0 +1,45 @@
+-- Регулярные и отложенные переводы (постоянные поручения)
+CREATE TABLE standing_orders (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    from_account_id BIGINT NOT NULL REFERENCES accounts(id),
+    to_account_id BIGINT NOT NULL REFERENCES accounts(id),
+    amount DECIMAL(15,2) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    schedule VARCHAR(10) NOT NULL,
+    execute_at TIMESTAMP WITH TIME ZONE,
+    day_of_month INTEGER,
+    day_of_week INTEGER,
+    cron VARCHAR(100) NOT NULL DEFAULT '',
+    end_date DATE,
+    status VARCHAR(20) NOT NULL,
+    next_occurrence TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_standing_orders_user_id ON standing_orders(user_id);
+CREATE INDEX idx_standing_orders_due ON standing_orders(next_run_at) WHERE status = 'active';
+CREATE INDEX idx_standing_orders_from_account_id ON standing_orders(from_account_id);
+CREATE INDEX idx_standing_orders_to_account_id ON standing_orders(to_account_id);
+
+CREATE TRIGGER update_standing_orders_updated_at
+    BEFORE UPDATE ON standing_orders
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- История исполнения поручений: каждая попытка перевода
+CREATE TABLE standing_order_executions (
+    id BIGSERIAL PRIMARY KEY,
+    standing_order_id BIGINT NOT NULL REFERENCES standing_orders(id),
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempt INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    transaction_id BIGINT REFERENCES transactions(id),
+    error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_standing_order_executions_order_id ON standing_order_executions(standing_order_id, id);
@@ -0,0 +1,24 @@
+-- Системный пользователь - владелец внутренних счетов банка. Вход под ним невозможен:
+-- значение password_hash не является bcrypt-хешем
+INSERT INTO users (email, username, password_hash, role)
+VALUES ('system@bank.local', 'system', '!', 'system');
+
+-- Внутренние счета банка: касса (наличные) и корреспондентский счет (переводы из других банков и в другие банки).
+-- Баланс системного счета может быть отрицательным - он отражает сальдо проведенных через него операций.
+INSERT INTO accounts (user_id, number, balance, currency)
+SELECT id, '20202810000000000001', 0, 'RUB' FROM users WHERE username = 'system'
+UNION ALL
+SELECT id, '30102810000000000001', 0, 'RUB' FROM users WHERE username = 'system';
+
+CREATE TABLE system_accounts (
+    code VARCHAR(20) PRIMARY KEY,
+    account_id BIGINT NOT NULL UNIQUE REFERENCES accounts(id)
+);
+
+INSERT INTO system_accounts (code, account_id)
+SELECT 'cash', id FROM accounts WHERE number = '20202810000000000001'
+UNION ALL
+SELECT 'clearing', id FROM accounts WHERE number = '30102810000000000001';
+
+-- Оператор, проведший кассовую операцию
+ALTER TABLE transactions ADD COLUMN operator_id BIGINT REFERENCES users(id);
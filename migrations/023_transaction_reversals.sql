@@ -0,0 +1,8 @@
+-- Отмена и возврат операций: компенсирующая транзакция ссылается на исходную
+ALTER TABLE transactions ADD COLUMN original_transaction_id BIGINT REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN reason VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_transactions_original_id ON transactions(original_transaction_id);
+
+-- Операция может быть отменена только один раз
+CREATE UNIQUE INDEX idx_transactions_reversal ON transactions(original_transaction_id) WHERE type = 'reversal';
@@ -0,0 +1,2 @@
+-- Причина перевыпуска карты: у старой карты - причина ее замены, у новой - причина выпуска взамен старой
+ALTER TABLE cards ADD COLUMN reissue_reason VARCHAR(30);
@@ -0,0 +1,9 @@
+-- Ссудный счет банка: на него списываются платежи и досрочные погашения по кредитам
+INSERT INTO accounts (user_id, number, balance, currency)
+SELECT id, '45505810000000000001', 0, 'RUB' FROM users WHERE username = 'system';
+
+INSERT INTO system_accounts (code, account_id)
+SELECT 'loans', id FROM accounts WHERE number = '45505810000000000001';
+
+-- Операция списания досрочного погашения со счета заемщика
+ALTER TABLE credit_prepayments ADD COLUMN transaction_id BIGINT REFERENCES transactions(id);
@@ -0,0 +1,5 @@
+-- Идентификатор входящего платежа в другом банке: пополнение со счета в другом банке
+-- проводится оператором после подтверждения платежа и зачисляется только один раз
+ALTER TABLE transactions ADD COLUMN external_reference VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX idx_transactions_external_reference ON transactions(external_reference) WHERE external_reference <> '';
@@ -0,0 +1,13 @@
+-- Внутренние счета банка для вкладов: средства вкладчиков и расходы на выплату процентов
+INSERT INTO accounts (user_id, number, balance, currency)
+SELECT id, '42301810000000000001', 0, 'RUB' FROM users WHERE username = 'system'
+UNION ALL
+SELECT id, '70606810000000000001', 0, 'RUB' FROM users WHERE username = 'system';
+
+INSERT INTO system_accounts (code, account_id)
+SELECT 'deposits', id FROM accounts WHERE number = '42301810000000000001'
+UNION ALL
+SELECT 'interest_expense', id FROM accounts WHERE number = '70606810000000000001';
+
+-- Версия вклада: изменение сохраняется, только если вклад не изменила параллельная операция
+ALTER TABLE deposits ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- Процентные доходы банка: проценты, списываемые по кредитным линиям
+INSERT INTO accounts (user_id, number, balance, currency)
+SELECT id, '70601810000000000001', 0, 'RUB' FROM users WHERE username = 'system';
+
+INSERT INTO system_accounts (code, account_id)
+SELECT 'interest_income', id FROM accounts WHERE number = '70601810000000000001';
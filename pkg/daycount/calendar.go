@@ -0,0 +1,50 @@
+package daycount
+
+import (
+	"time"
+)
+
+// Calendar - производственный календарь: выходные дни, праздники и перенесенные рабочие дни.
+// Нулевой календарь считает нерабочими только субботу и воскресенье.
+type Calendar struct {
+	holidays map[time.Time]bool
+	workdays map[time.Time]bool
+}
+
+// NewCalendar создает календарь по списку праздничных (нерабочих) дней и рабочих
+// дней, перенесенных на выходные
+func NewCalendar(holidays, workdays []time.Time) *Calendar {
+	c := &Calendar{
+		holidays: make(map[time.Time]bool, len(holidays)),
+		workdays: make(map[time.Time]bool, len(workdays)),
+	}
+	for _, day := range holidays {
+		c.holidays[Date(day)] = true
+	}
+	for _, day := range workdays {
+		c.workdays[Date(day)] = true
+	}
+	return c
+}
+
+// IsBusinessDay проверяет, является ли дата рабочим днем
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	day := Date(t)
+	if c != nil {
+		if c.workdays[day] {
+			return true
+		}
+		if c.holidays[day] {
+			return false
+		}
+	}
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// Following переносит дату, выпавшую на нерабочий день, на ближайший следующий рабочий день
+func (c *Calendar) Following(t time.Time) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
@@ -0,0 +1,102 @@
+package daycount
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownConvention = errors.New("unknown day count convention")
+
+// Convention - конвенция расчета количества дней для начисления процентов
+type Convention string
+
+const (
+	// Actual365 - фактическое число дней, база 365 дней
+	Actual365 Convention = "actual/365"
+	// ActualActual - фактическое число дней, база - фактическое число дней в году (365 или 366)
+	ActualActual Convention = "actual/actual"
+	// Thirty360 - каждый месяц считается равным 30 дням, база 360 дней
+	Thirty360 Convention = "30/360"
+)
+
+// Parse возвращает конвенцию по ее названию
+func Parse(name string) (Convention, error) {
+	switch c := Convention(name); c {
+	case Actual365, ActualActual, Thirty360:
+		return c, nil
+	}
+	return "", ErrUnknownConvention
+}
+
+// YearFraction возвращает долю года между датами from и to по конвенции.
+// Учитываются только календарные даты, время суток отбрасывается.
+func (c Convention) YearFraction(from, to time.Time) float64 {
+	from, to = Date(from), Date(to)
+	if !to.After(from) {
+		return 0
+	}
+
+	switch c {
+	case ActualActual:
+		// Период делится по границам календарных лет, каждая часть делится на длину своего года
+		var fraction float64
+		for from.Before(to) {
+			nextYear := time.Date(from.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			end := to
+			if nextYear.Before(end) {
+				end = nextYear
+			}
+			fraction += float64(Days(from, end)) / float64(daysInYear(from.Year()))
+			from = end
+		}
+		return fraction
+	case Thirty360:
+		return float64(days360(from, to)) / 360
+	default:
+		return float64(Days(from, to)) / 365
+	}
+}
+
+// Days возвращает фактическое число календарных дней между датами
+func Days(from, to time.Time) int {
+	return int(Date(to).Sub(Date(from)).Hours() / 24)
+}
+
+// Date возвращает календарную дату момента t (полночь UTC той же даты)
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// AddMonths прибавляет к дате месяцы, не переходя в следующий месяц: если в целевом месяце
+// нет такого числа, берется его последний день (31 января + 1 месяц = 28/29 февраля)
+func AddMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := daysInMonth(first.Year(), first.Month()); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// days360 - число дней по конвенции 30/360 (US bond basis)
+func days360(from, to time.Time) int {
+	d1, d2 := from.Day(), to.Day()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return 360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysInYear(year int) int {
+	if daysInMonth(year, time.February) == 29 {
+		return 366
+	}
+	return 365
+}
@@ -0,0 +1,114 @@
+package daycount
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Конвенции на датах конца месяца: 30/360 (US bond basis) приводит 31-е число к 30-му,
+// actual/365 считает фактические дни
+func TestYearFraction(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		thirty360 float64
+		actual365 float64
+	}{
+		{"31 Jan to 28 Feb", date(2023, time.January, 31), date(2023, time.February, 28), 28.0 / 360, 28.0 / 365},
+		{"31 Jan to 29 Feb leap year", date(2024, time.January, 31), date(2024, time.February, 29), 29.0 / 360, 29.0 / 365},
+		{"30 Jan to 31 Mar", date(2023, time.January, 30), date(2023, time.March, 31), 60.0 / 360, 60.0 / 365},
+		{"31 Jan to 31 Mar", date(2023, time.January, 31), date(2023, time.March, 31), 60.0 / 360, 59.0 / 365},
+		{"29 Feb to 31 Mar", date(2024, time.February, 29), date(2024, time.March, 31), 32.0 / 360, 31.0 / 365},
+		{"28 Feb to 31 Mar", date(2023, time.February, 28), date(2023, time.March, 31), 33.0 / 360, 31.0 / 365},
+		{"30 Apr to 31 May", date(2023, time.April, 30), date(2023, time.May, 31), 30.0 / 360, 31.0 / 365},
+		{"31 Dec to 31 Dec over leap year", date(2023, time.December, 31), date(2024, time.December, 31), 1, 366.0 / 365},
+		{"mid-month", date(2023, time.July, 15), date(2023, time.August, 15), 30.0 / 360, 31.0 / 365},
+		{"reversed period", date(2023, time.March, 31), date(2023, time.January, 31), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Thirty360.YearFraction(tt.from, tt.to); math.Abs(got-tt.thirty360) > 1e-12 {
+				t.Errorf("30/360 YearFraction() = %v, want %v", got, tt.thirty360)
+			}
+			if got := Actual365.YearFraction(tt.from, tt.to); math.Abs(got-tt.actual365) > 1e-12 {
+				t.Errorf("actual/365 YearFraction() = %v, want %v", got, tt.actual365)
+			}
+		})
+	}
+}
+
+func TestYearFractionActualActual(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     float64
+	}{
+		{"within leap year", date(2024, time.January, 31), date(2024, time.February, 29), 29.0 / 366},
+		{"across year end", date(2023, time.July, 1), date(2024, time.July, 1), 184.0/365 + 182.0/366},
+		{"full leap year", date(2024, time.January, 1), date(2025, time.January, 1), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ActualActual.YearFraction(tt.from, tt.to); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("YearFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{"31 Jan to February", date(2023, time.January, 31), 1, date(2023, time.February, 28)},
+		{"31 Jan to February leap year", date(2024, time.January, 31), 1, date(2024, time.February, 29)},
+		{"31 Mar to April", date(2023, time.March, 31), 1, date(2023, time.April, 30)},
+		{"30 Nov to next year", date(2023, time.November, 30), 3, date(2024, time.February, 29)},
+		{"mid-month", date(2023, time.May, 15), 12, date(2024, time.May, 15)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddMonths(tt.from, tt.months); !got.Equal(tt.want) {
+				t.Errorf("AddMonths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarFollowing(t *testing.T) {
+	calendar := NewCalendar(
+		[]time.Time{date(2024, time.January, 1), date(2024, time.January, 8)},
+		[]time.Time{date(2024, time.April, 27)},
+	)
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want time.Time
+	}{
+		{"business day", date(2024, time.January, 9), date(2024, time.January, 9)},
+		{"holiday", date(2024, time.January, 1), date(2024, time.January, 2)},
+		{"weekend before holiday", date(2024, time.January, 6), date(2024, time.January, 9)},
+		{"transferred workday", date(2024, time.April, 27), date(2024, time.April, 27)},
+		{"sunday", date(2024, time.April, 28), date(2024, time.April, 29)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendar.Following(tt.day); !got.Equal(tt.want) {
+				t.Errorf("Following() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -77,4 +77,32 @@ func (c *Client) SendCreditNotification(to string, creditID int64, amount float6
 
 	log.Printf("Email sent to %s", to)
 	return nil
-} 
\ No newline at end of file
+} 
+func (c *Client) SendBudgetAlert(to, category string, limit, spent float64, threshold int) error {
+	subject := fmt.Sprintf("Израсходовано %d%% бюджета", threshold)
+	if threshold >= 100 {
+		subject = "Бюджет исчерпан"
+	}
+
+	content := fmt.Sprintf(`
+		<h1>%s</h1>
+		<p>Категория: %s</p>
+		<p>Лимит на месяц: <strong>%.2f RUB</strong></p>
+		<p>Израсходовано: <strong>%.2f RUB</strong></p>
+		<small>Это автоматическое уведомление</small>
+	`, subject, category, limit, spent)
+
+	m := mail.NewMessage()
+	m.SetHeader("From", c.from)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", content)
+
+	if err := c.dialer.DialAndSend(m); err != nil {
+		log.Printf("SMTP error: %v", err)
+		return fmt.Errorf("ошибка отправки email: %v", err)
+	}
+
+	log.Printf("Email sent to %s", to)
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package pdfdoc формирует PDF-документы из заголовков, абзацев, реквизитов и таблиц.
+// Используется встроенный шрифт DejaVu Sans Condensed с поддержкой кириллицы.
+package pdfdoc
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+	"github.com/go-pdf/fpdf"
+)
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var regularFont []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var boldFont []byte
+
+const (
+	fontFamily = "DejaVu"
+	margin     = 15.0 // поля страницы, мм
+	lineHeight = 5.0  // высота строки текста, мм
+	rowHeight  = 6.0  // высота строки таблицы, мм
+)
+
+// Выравнивание текста в колонке таблицы
+const (
+	AlignLeft   = "L"
+	AlignCenter = "C"
+	AlignRight  = "R"
+)
+
+// Column описывает колонку таблицы
+type Column struct {
+	Title string
+	Width float64 // ширина, мм
+	Align string
+}
+
+// Document - PDF-документ формата A4. Страницы нумеруются в нижнем колонтитуле,
+// заголовок таблицы повторяется на каждой новой странице.
+type Document struct {
+	pdf     *fpdf.Fpdf
+	columns []Column
+}
+
+// New создает документ с указанным названием (метаданные PDF) и первой страницей
+func New(title string, createdAt time.Time) *Document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regularFont)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", boldFont)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("bank-api", true)
+	pdf.SetCreationDate(createdAt)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-margin + 3)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.CellFormat(0, lineHeight, fmt.Sprintf("Страница %d из {nb}", pdf.PageNo()), "", 0, AlignCenter, false, 0, "")
+	})
+	pdf.AddPage()
+
+	return &Document{pdf: pdf}
+}
+
+// Heading добавляет заголовок документа
+func (d *Document) Heading(text string) {
+	d.pdf.SetFont(fontFamily, "B", 14)
+	d.pdf.MultiCell(0, 7, text, "", AlignCenter, false)
+	d.pdf.Ln(3)
+}
+
+// Subheading добавляет заголовок раздела
+func (d *Document) Subheading(text string) {
+	d.pdf.Ln(2)
+	d.pdf.SetFont(fontFamily, "B", 11)
+	d.pdf.MultiCell(0, 6, text, "", AlignLeft, false)
+	d.pdf.Ln(1)
+}
+
+// Paragraph добавляет абзац текста с выравниванием по ширине
+func (d *Document) Paragraph(text string) {
+	d.pdf.SetFont(fontFamily, "", 10)
+	d.pdf.MultiCell(0, lineHeight, text, "", "J", false)
+	d.pdf.Ln(2)
+}
+
+// Notice добавляет текст в рамке (например, обязательную информацию в верхней части документа)
+func (d *Document) Notice(text string) {
+	d.pdf.SetFont(fontFamily, "B", 10)
+	d.pdf.MultiCell(0, 6, text, "1", AlignCenter, false)
+	d.pdf.Ln(3)
+}
+
+// Field добавляет строку реквизита "название: значение"
+func (d *Document) Field(label, value string) {
+	d.pdf.SetFont(fontFamily, "B", 10)
+	d.pdf.CellFormat(65, lineHeight, label, "", 0, AlignLeft, false, 0, "")
+	d.pdf.SetFont(fontFamily, "", 10)
+	d.pdf.MultiCell(0, lineHeight, value, "", AlignLeft, false)
+}
+
+// Table начинает таблицу с указанными колонками и выводит ее заголовок
+func (d *Document) Table(columns []Column) {
+	d.columns = columns
+	d.pdf.Ln(1)
+	d.header()
+}
+
+// Row добавляет строку таблицы. Строки можно добавлять по одной, не собирая таблицу в памяти.
+func (d *Document) Row(values ...string) {
+	d.row("", values)
+}
+
+// TotalRow добавляет итоговую строку таблицы, выделенную полужирным
+func (d *Document) TotalRow(values ...string) {
+	d.row("B", values)
+}
+
+// EndTable завершает таблицу
+func (d *Document) EndTable() {
+	d.columns = nil
+	d.pdf.Ln(3)
+}
+
+// Write записывает документ в w
+func (d *Document) Write(w io.Writer) error {
+	return d.pdf.Output(w)
+}
+
+// Bytes возвращает содержимое документа
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *Document) header() {
+	d.pdf.SetFont(fontFamily, "B", 9)
+	d.pdf.SetFillColor(230, 230, 230)
+	for _, column := range d.columns {
+		d.pdf.CellFormat(column.Width, rowHeight, column.Title, "1", 0, AlignCenter, true, 0, "")
+	}
+	d.pdf.Ln(-1)
+}
+
+func (d *Document) row(style string, values []string) {
+	// Перенос на новую страницу выполняется до вывода строки, чтобы повторить заголовок таблицы
+	_, pageHeight := d.pdf.GetPageSize()
+	if d.pdf.GetY()+rowHeight > pageHeight-margin {
+		d.pdf.AddPage()
+		d.header()
+	}
+
+	d.pdf.SetFont(fontFamily, style, 9)
+	for i, column := range d.columns {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		d.pdf.CellFormat(column.Width, rowHeight, value, "1", 0, column.Align, false, 0, "")
+	}
+	d.pdf.Ln(-1)
+}
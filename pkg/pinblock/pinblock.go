@@ -0,0 +1,114 @@
+package pinblock
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidPIN = errors.New("PIN must contain from 4 to 12 digits")
+	ErrInvalidPAN = errors.New("invalid card number")
+	ErrInvalidKey = errors.New("PIN key must be 16 or 24 bytes")
+)
+
+// Encryptor формирует PIN-блоки ISO 9564 формата 0 и шифрует их на PIN-ключе (3DES)
+type Encryptor struct {
+	block cipher.Block
+}
+
+// NewEncryptor создает шифратор по PIN-ключу, заданному в hex (двух- или трехкомпонентный 3DES)
+func NewEncryptor(hexKey string) (*Encryptor, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка декодирования PIN-ключа: %v", err)
+	}
+
+	switch len(key) {
+	case 16:
+		// Двухкомпонентный ключ K1K2 расширяется до K1K2K1
+		key = append(key, key[:8]...)
+	case 24:
+	default:
+		return nil, ErrInvalidKey
+	}
+
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encryptor{block: block}, nil
+}
+
+// Encrypt возвращает зашифрованный PIN-блок в hex
+func (e *Encryptor) Encrypt(pin, pan string) (string, error) {
+	clear, err := Format0(pin, pan)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted := make([]byte, des.BlockSize)
+	e.block.Encrypt(encrypted, clear)
+
+	return hex.EncodeToString(encrypted), nil
+}
+
+// Verify проверяет PIN по зашифрованному PIN-блоку за постоянное время
+func (e *Encryptor) Verify(pin, pan, encryptedBlock string) (bool, error) {
+	actual, err := e.Encrypt(pin, pan)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(encryptedBlock)) == 1, nil
+}
+
+// Format0 формирует открытый PIN-блок ISO 9564 формата 0:
+// поле PIN (0, длина PIN, цифры PIN, заполнение F) XOR поле PAN
+// (0000 и 12 правых цифр номера карты без контрольной цифры)
+func Format0(pin, pan string) ([]byte, error) {
+	if len(pin) < 4 || len(pin) > 12 || !isDigits(pin) {
+		return nil, ErrInvalidPIN
+	}
+
+	if len(pan) < 13 || !isDigits(pan) {
+		return nil, ErrInvalidPAN
+	}
+
+	pinField := fmt.Sprintf("0%X%s", len(pin), pin)
+	for len(pinField) < 16 {
+		pinField += "F"
+	}
+
+	panField := "0000" + pan[len(pan)-13:len(pan)-1]
+
+	pinBytes, err := hex.DecodeString(pinField)
+	if err != nil {
+		return nil, err
+	}
+
+	panBytes, err := hex.DecodeString(panField)
+	if err != nil {
+		return nil, err
+	}
+
+	block := make([]byte, des.BlockSize)
+	for i := range block {
+		block[i] = pinBytes[i] ^ panBytes[i]
+	}
+
+	return block, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package pinblock
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Контрольные значения PIN-блоков формата 0 - пример из ISO 9564-1 (PIN 1234, PAN 43219876543210987)
+// и блоки, посчитанные вручную по определению формата. Зашифрованные блоки получены независимо
+// через openssl enc -des-ede3 -nopad.
+
+func TestFormat0(t *testing.T) {
+	tests := []struct {
+		name string
+		pin  string
+		pan  string
+		want string
+	}{
+		{"ISO 9564-1 example", "1234", "43219876543210987", "0412AC89ABCDEF67"},
+		{"16-digit PAN", "1234", "4111111111111111", "041225EEEEEEEEEE"},
+		{"6-digit PIN", "123456", "5432109876543210", "0612155F789ABCDE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := Format0(tt.pin, tt.pan)
+			if err != nil {
+				t.Fatalf("Format0() error = %v", err)
+			}
+			if got := strings.ToUpper(hex.EncodeToString(block)); got != tt.want {
+				t.Errorf("Format0() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat0Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		pin  string
+		pan  string
+		want error
+	}{
+		{"short PIN", "123", "4111111111111111", ErrInvalidPIN},
+		{"long PIN", "1234567890123", "4111111111111111", ErrInvalidPIN},
+		{"non-digit PIN", "12a4", "4111111111111111", ErrInvalidPIN},
+		{"short PAN", "1234", "411111111111", ErrInvalidPAN},
+		{"non-digit PAN", "1234", "41111111111111x1", ErrInvalidPAN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Format0(tt.pin, tt.pan); err != tt.want {
+				t.Errorf("Format0() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		pin  string
+		pan  string
+		want string
+	}{
+		{"double-length key", "0123456789ABCDEFFEDCBA9876543210", "1234", "43219876543210987", "c967c8198151a458"},
+		{"double-length key, 16-digit PAN", "0123456789ABCDEFFEDCBA9876543210", "1234", "4111111111111111", "2a3d408a1977dde9"},
+		{"triple-length key", "0123456789ABCDEFFEDCBA987654321089ABCDEF01234567", "1234", "43219876543210987", "a5dc5f7f40a400fe"},
+		{"triple-length key, 16-digit PAN", "0123456789ABCDEFFEDCBA987654321089ABCDEF01234567", "1234", "4111111111111111", "6a953d63752e5e1b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encryptor, err := NewEncryptor(tt.key)
+			if err != nil {
+				t.Fatalf("NewEncryptor() error = %v", err)
+			}
+
+			got, err := encryptor.Encrypt(tt.pin, tt.pan)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Encrypt() = %s, want %s", got, tt.want)
+			}
+
+			ok, err := encryptor.Verify(tt.pin, tt.pan, got)
+			if err != nil || !ok {
+				t.Errorf("Verify() = %v, %v, want true", ok, err)
+			}
+			ok, err = encryptor.Verify("9999", tt.pan, got)
+			if err != nil || ok {
+				t.Errorf("Verify() with wrong PIN = %v, %v, want false", ok, err)
+			}
+		})
+	}
+}
+
+// Ключ из трех одинаковых компонент сводит 3DES к одинарному DES: проверяем расширение
+// двухкомпонентного ключа на классическом векторе DES (ключ 133457799BBCDFF1)
+func TestNewEncryptorKeyExpansion(t *testing.T) {
+	encryptor, err := NewEncryptor("133457799BBCDFF1133457799BBCDFF1")
+	if err != nil {
+		t.Fatalf("NewEncryptor() error = %v", err)
+	}
+
+	plain, _ := hex.DecodeString("0123456789ABCDEF")
+	encrypted := make([]byte, len(plain))
+	encryptor.block.Encrypt(encrypted, plain)
+
+	if got := hex.EncodeToString(encrypted); got != "85e813540f0ab405" {
+		t.Errorf("Encrypt() = %s, want 85e813540f0ab405", got)
+	}
+}
+
+func TestNewEncryptorInvalidKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"not hex", "0123456789ABCDEFFEDCBA987654321G"},
+		{"single-length key", "0123456789ABCDEF"},
+		{"odd length", "0123456789ABCDEFFEDCBA98765432"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEncryptor(tt.key); err == nil {
+				t.Error("NewEncryptor() error = nil, want error")
+			}
+		})
+	}
+}